@@ -0,0 +1,114 @@
+// Package benchmark provides a harness for comparing the eviction policies of gocache against deterministic workloads
+//
+// It is a package of its own so that gocache itself does not depend on the testing package.
+package benchmark
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/TwinProduction/gocache"
+)
+
+const (
+	// workloadSeed is the seed used to generate the keys accessed by a Workload, so that every eviction policy is
+	// benchmarked against the exact same sequence of keys
+	workloadSeed = 42
+
+	// workloadCacheSizeRatio is the number of distinct keys of a Workload for each entry that the cache used by
+	// Policy can hold
+	workloadCacheSizeRatio = 10
+)
+
+// Workload is a deterministic sequence of cache keys accessed by Policy
+type Workload struct {
+	// Name is the name of the workload
+	Name string
+
+	// NumberOfKeys is the number of distinct keys that may be accessed by the workload
+	NumberOfKeys int
+
+	// newGenerator returns a function that returns the index of the next key to access, between 0 and NumberOfKeys-1
+	newGenerator func(random *rand.Rand) func() int
+}
+
+// UniformWorkload returns a Workload in which every key is equally likely to be accessed
+func UniformWorkload(numberOfKeys int) Workload {
+	return Workload{
+		Name:         "uniform",
+		NumberOfKeys: numberOfKeys,
+		newGenerator: func(random *rand.Rand) func() int {
+			return func() int {
+				return random.Intn(numberOfKeys)
+			}
+		},
+	}
+}
+
+// ZipfianWorkload returns a Workload in which a small number of keys account for most of the accesses, which is
+// typical of real-world caches
+//
+// The skew must be greater than 1, and the greater the skew, the more accesses are concentrated on the same keys.
+func ZipfianWorkload(numberOfKeys int, skew float64) Workload {
+	return Workload{
+		Name:         "zipfian",
+		NumberOfKeys: numberOfKeys,
+		newGenerator: func(random *rand.Rand) func() int {
+			zipf := rand.NewZipf(random, skew, 1, uint64(numberOfKeys-1))
+			return func() int {
+				return int(zipf.Uint64())
+			}
+		},
+	}
+}
+
+// SequentialScanWorkload returns a Workload in which every key is accessed one after the other, over and over again
+func SequentialScanWorkload(numberOfKeys int) Workload {
+	return Workload{
+		Name:         "sequential-scan",
+		NumberOfKeys: numberOfKeys,
+		newGenerator: func(_ *rand.Rand) func() int {
+			next := -1
+			return func() int {
+				next = (next + 1) % numberOfKeys
+				return next
+			}
+		},
+	}
+}
+
+// Policy measures the throughput and the hit ratio of an eviction policy for a given workload
+//
+// Each iteration accesses the next key of the workload and sets it if it isn't in the cache already, using a cache
+// that can only hold a tenth of the keys of the workload. On top of the usual metrics, the percentage of accesses
+// that resulted in a hit is reported as "hit%".
+//
+// e.g.
+//     func BenchmarkLRU(b *testing.B) {
+//         benchmark.Policy(b, gocache.LeastRecentlyUsed, benchmark.ZipfianWorkload(100000, 1.1))
+//     }
+func Policy(b *testing.B, policy gocache.EvictionPolicy, workload Workload) {
+	keys := make([]string, workload.NumberOfKeys)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	cacheSize := workload.NumberOfKeys / workloadCacheSizeRatio
+	if cacheSize == 0 {
+		cacheSize = 1
+	}
+	cache := gocache.NewCache().WithEvictionPolicy(policy).WithMaxSize(cacheSize)
+	next := workload.newGenerator(rand.New(rand.NewSource(workloadSeed)))
+	hits := 0
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		key := keys[next()]
+		if _, ok := cache.Get(key); ok {
+			hits++
+		} else {
+			cache.Set(key, key)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(hits)/float64(b.N)*100, "hit%")
+}
@@ -0,0 +1,19 @@
+package benchmark
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/TwinProduction/gocache"
+)
+
+func BenchmarkPolicies(b *testing.B) {
+	workloads := []Workload{UniformWorkload(100000), ZipfianWorkload(100000, 1.1), SequentialScanWorkload(100000)}
+	for _, workload := range workloads {
+		for _, evictionPolicy := range []gocache.EvictionPolicy{gocache.FirstInFirstOut, gocache.LeastRecentlyUsed, gocache.MostRecentlyUsed, gocache.Random} {
+			b.Run(fmt.Sprintf("%s/%s", workload.Name, evictionPolicy), func(b *testing.B) {
+				Policy(b, evictionPolicy, workload)
+			})
+		}
+	}
+}
@@ -40,8 +40,14 @@ func main() {
 	}
 	autoSave := os.Getenv("AUTOSAVE") == "true"
 	log.Println("AUTOSAVE is set to", autoSave)
+	password := os.Getenv("PASSWORD")
+	if len(password) == 0 {
+		log.Println("PASSWORD is not set (authentication disabled)")
+	} else {
+		log.Println("PASSWORD is set (authentication enabled)")
+	}
 	cache := gocache.NewCache().WithEvictionPolicy(gocache.LeastRecentlyUsed).WithMaxSize(maxCacheSize).WithMaxMemoryUsage(maxMemoryUsage)
-	server := gocacheserver.NewServer(cache).WithPort(port)
+	server := gocacheserver.NewServer(cache).WithPort(port).WithPassword(password)
 	if autoSave {
 		server = server.WithAutoSave(10*time.Minute, "/app/data/gocache.bak")
 	}
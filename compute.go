@@ -0,0 +1,163 @@
+package gocache
+
+import (
+	"context"
+	"time"
+)
+
+// computation is a computation of the value of a key started by GetOrComputeWithTTL, which other callers of
+// GetOrComputeWithTTL for the same key can wait for rather than computing the value themselves
+type computation struct {
+	// done is closed once the computation has completed, successfully or not
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// GetOrCompute retrieves the value of a key if it exists, or computes it using the function passed as parameter and
+// stores it if it doesn't
+//
+// See GetOrComputeWithTTL for more details.
+func (cache *Cache) GetOrCompute(key string, f func() (interface{}, error)) (interface{}, error) {
+	return cache.GetOrComputeWithTTL(key, f, cache.options.DefaultTTL)
+}
+
+// GetOrComputeWithTTL retrieves the value of a key if it exists, or computes it using the function passed as parameter
+// and stores it with the given expiration time if it doesn't
+//
+// The function is only called if the key doesn't exist, and concurrent callers for the same missing key wait for a
+// single computation rather than each calling their own function, which prevents the same expensive computation from
+// running several times at once. If the function returns an error, nothing is stored and the error is returned to
+// every caller waiting for that computation. If the function panics, the callers waiting for it get
+// ErrComputationPanicked.
+//
+// Note that the cache is not locked while the function runs, so it may freely use the cache. The same rules as
+// SetWithTTL apply to the TTL.
+func (cache *Cache) GetOrComputeWithTTL(key string, f func() (interface{}, error), ttl time.Duration) (interface{}, error) {
+	return cache.getOrCompute(context.Background(), key, func(context.Context) (interface{}, time.Duration, error) {
+		value, err := f()
+		return value, ttl, err
+	})
+}
+
+// GetOrComputeWithContext is the same as GetOrCompute, except that the context passed as parameter is passed to the
+// function computing the value, and that the caller stops waiting for a computation started by another caller once
+// the context is done
+//
+// If the context is already done, ctx.Err() is returned without looking up the key.
+// Note that the computation is shared by every caller waiting for it, but only the context of the caller that started
+// it is passed to the function, which means that if that context is canceled and the function returns ctx.Err(), the
+// error is also returned to the other callers waiting for that computation.
+func (cache *Cache) GetOrComputeWithContext(ctx context.Context, key string, f func(context.Context) (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return cache.getOrCompute(ctx, key, func(ctx context.Context) (interface{}, time.Duration, error) {
+		value, err := f(ctx)
+		return value, cache.options.DefaultTTL, err
+	})
+}
+
+// GetWithLoad retrieves an entry using the key passed as parameter, or loads it using the loader configured with
+// WithLoader if it doesn't exist
+//
+// Unlike Get, which also uses the loader, this returns the error returned by the loader, in which case nothing is
+// stored. If there is no such entry and no loader has been configured, ErrKeyDoesNotExist is returned.
+func (cache *Cache) GetWithLoad(key string) (interface{}, error) {
+	if value, ok := cache.getWithoutLoading(key); ok {
+		return value, nil
+	}
+	if cache.loader == nil {
+		return nil, ErrKeyDoesNotExist
+	}
+	return cache.load(key)
+}
+
+// load loads the value of a key using the loader and stores it with the TTL returned by the loader
+//
+// Concurrent loads of the same key share a single call to the loader, like GetOrCompute.
+func (cache *Cache) load(key string) (interface{}, error) {
+	return cache.getOrCompute(context.Background(), key, func(context.Context) (interface{}, time.Duration, error) {
+		return cache.loader(key)
+	})
+}
+
+// getOrCompute retrieves the value of a key if it exists, or computes it using the function passed as parameter and
+// stores it with the TTL returned by the function if it doesn't
+func (cache *Cache) getOrCompute(ctx context.Context, key string, f func(context.Context) (interface{}, time.Duration, error)) (interface{}, error) {
+	if value, ok := cache.getWithoutLoading(key); ok {
+		return value, nil
+	}
+	cache.computationsMutex.Lock()
+	if c, inFlight := cache.computations[key]; inFlight {
+		cache.computationsMutex.Unlock()
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if cache.options.ReturnCopies {
+			return copyValue(c.value), c.err
+		}
+		return c.value, c.err
+	}
+	// The value may have been computed by another caller between the first lookup and the lock being acquired
+	if value, ok := cache.getWithoutLoading(key); ok {
+		cache.computationsMutex.Unlock()
+		return value, nil
+	}
+	return cache.compute(ctx, key, f, 0)
+}
+
+// revalidate reloads the value of a stale entry using the loader in the background and stores it with the grace
+// period passed as parameter, unless the key is already being loaded or computed
+//
+// If the loader fails or panics, the stale value is left untouched.
+func (cache *Cache) revalidate(key string, grace time.Duration) {
+	cache.computationsMutex.Lock()
+	if _, inFlight := cache.computations[key]; inFlight {
+		cache.computationsMutex.Unlock()
+		return
+	}
+	// The lock is released by compute once the computation is registered, so that no other revalidation of the same
+	// key can start in the meantime
+	go func() {
+		defer func() {
+			_ = recover()
+		}()
+		_, _ = cache.compute(context.Background(), key, func(context.Context) (interface{}, time.Duration, error) {
+			return cache.loader(key)
+		}, grace)
+	}()
+}
+
+// compute computes the value of a key using the function passed as parameter and stores it with the TTL returned by
+// the function and the grace period passed as parameter (see SetWithTTLAndGrace)
+//
+// The caller is responsible for locking computationsMutex, which is unlocked once the computation is registered, so
+// that other callers can wait for it rather than starting their own.
+func (cache *Cache) compute(ctx context.Context, key string, f func(context.Context) (interface{}, time.Duration, error), grace time.Duration) (interface{}, error) {
+	if cache.computations == nil {
+		cache.computations = make(map[string]*computation)
+	}
+	c := &computation{done: make(chan struct{}), err: ErrComputationPanicked}
+	cache.computations[key] = c
+	cache.computationsMutex.Unlock()
+	defer func() {
+		cache.computationsMutex.Lock()
+		delete(cache.computations, key)
+		cache.computationsMutex.Unlock()
+		close(c.done)
+	}()
+	var ttl time.Duration
+	c.value, ttl, c.err = f(ctx)
+	if c.err != nil {
+		return nil, c.err
+	}
+	// The value must be stored before the computation is removed, so that no other computation starts in between
+	cache.SetWithTTLAndGrace(key, c.value, ttl, grace)
+	if cache.options.ReturnCopies {
+		return copyValue(c.value), nil
+	}
+	return c.value, nil
+}
@@ -0,0 +1,273 @@
+package gocache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_GetOrCompute(t *testing.T) {
+	cache := NewCache()
+	numberOfCalls := 0
+	compute := func() (interface{}, error) {
+		numberOfCalls++
+		return "value", nil
+	}
+	for i := 0; i < 2; i++ {
+		value, err := cache.GetOrCompute("key", compute)
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if value != "value" {
+			t.Error("expected value, got", value)
+		}
+	}
+	if numberOfCalls != 1 {
+		t.Errorf("expected the value to have been computed once, got %d", numberOfCalls)
+	}
+}
+
+func TestCache_GetOrComputeConcurrently(t *testing.T) {
+	cache := NewCache()
+	numberOfCalls := int32(0)
+	compute := func() (interface{}, error) {
+		atomic.AddInt32(&numberOfCalls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return "value", nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value, err := cache.GetOrCompute("key", compute); err != nil || value != "value" {
+				t.Errorf("expected value and no error, got %v and %v", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if numberOfCalls != 1 {
+		t.Errorf("expected concurrent callers to have shared a single computation, got %d computations", numberOfCalls)
+	}
+}
+
+func TestCache_GetOrComputeWithError(t *testing.T) {
+	cache := NewCache()
+	expectedErr := errors.New("failed")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		cache.GetOrCompute("key", func() (interface{}, error) {
+			close(started)
+			<-release
+			return nil, expectedErr
+		})
+	}()
+	<-started
+	done := make(chan error)
+	go func() {
+		_, err := cache.GetOrCompute("key", func() (interface{}, error) {
+			return "value", nil
+		})
+		done <- err
+	}()
+	// Give the second caller enough time to start waiting for the first computation
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if err := <-done; err != expectedErr {
+		t.Errorf("expected the error of the computation to have been propagated to the waiting caller, got %v", err)
+	}
+	if _, ok := cache.Get("key"); ok {
+		t.Error("expected nothing to have been cached")
+	}
+}
+
+func TestCache_GetOrComputeWhenFunctionPanics(t *testing.T) {
+	cache := NewCache()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to have been propagated to the caller")
+			}
+		}()
+		cache.GetOrCompute("key", func() (interface{}, error) {
+			panic("oops")
+		})
+	}()
+	// The failed computation must not prevent the value from being computed again
+	if value, err := cache.GetOrCompute("key", func() (interface{}, error) { return "value", nil }); err != nil || value != "value" {
+		t.Errorf("expected value and no error, got %v and %v", value, err)
+	}
+}
+
+func TestCache_GetOrComputeWithTTL(t *testing.T) {
+	cache := NewCache()
+	cache.GetOrComputeWithTTL("key", func() (interface{}, error) {
+		return "value", nil
+	}, time.Hour)
+	if ttl, err := cache.TTL("key"); err != nil || ttl <= 0 {
+		t.Error("expected the key to have an expiration time")
+	}
+}
+
+func TestCache_GetOrComputeWithContext(t *testing.T) {
+	cache := NewCache()
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	computed := make(chan error)
+	go func() {
+		_, err := cache.GetOrComputeWithContext(ctx, "key", func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		computed <- err
+	}()
+	<-started
+	waiterCtx, cancelWaiter := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelWaiter()
+	if _, err := cache.GetOrComputeWithContext(waiterCtx, "key", func(context.Context) (interface{}, error) {
+		return "value", nil
+	}); err != context.DeadlineExceeded {
+		t.Error("expected the waiting caller to have stopped waiting once its context was done, got", err)
+	}
+	cancel()
+	if err := <-computed; err != context.Canceled {
+		t.Error("expected the function to have been canceled, got", err)
+	}
+	if _, ok := cache.Get("key"); ok {
+		t.Error("expected nothing to have been cached")
+	}
+	if _, err := cache.GetOrComputeWithContext(ctx, "key", func(context.Context) (interface{}, error) {
+		return "value", nil
+	}); err != context.Canceled {
+		t.Error("expected context.Canceled, since the context is already done, got", err)
+	}
+	value, err := cache.GetOrComputeWithContext(context.Background(), "key", func(context.Context) (interface{}, error) {
+		return "value", nil
+	})
+	if err != nil || value != "value" {
+		t.Errorf("expected value and no error, got %v and %v", value, err)
+	}
+}
+
+func TestCache_WithLoader(t *testing.T) {
+	numberOfCalls := 0
+	cache := NewCache().WithLoader(func(key string) (interface{}, time.Duration, error) {
+		numberOfCalls++
+		if key == "missing" {
+			return nil, 0, ErrKeyDoesNotExist
+		}
+		return "loaded-" + key, time.Hour, nil
+	})
+	cache.Set("key", "value")
+	if value, ok := cache.Get("key"); !ok || value != "value" {
+		t.Errorf("expected value to have been retrieved without using the loader, got %v", value)
+	}
+	if numberOfCalls != 0 {
+		t.Error("expected the loader to not have been called for an existing key")
+	}
+	for i := 0; i < 2; i++ {
+		if value, ok := cache.Get("other-key"); !ok || value != "loaded-other-key" {
+			t.Errorf("expected loaded-other-key, got %v", value)
+		}
+	}
+	if numberOfCalls != 1 {
+		t.Error("expected the loaded value to have been stored, got", numberOfCalls, "calls to the loader")
+	}
+	if ttl, err := cache.TTL("other-key"); err != nil || ttl <= 0 || ttl > time.Hour {
+		t.Errorf("expected the loaded entry to have been stored with the TTL returned by the loader, got %v and %v", ttl, err)
+	}
+	if value, ok := cache.Get("missing"); ok || value != nil {
+		t.Errorf("expected the entry to be reported as missing when the loader fails, got %v and %v", value, ok)
+	}
+	if _, ok := cache.getWithoutLoading("missing"); ok {
+		t.Error("expected nothing to have been stored when the loader fails")
+	}
+}
+
+func TestCache_WithLoaderAndStaleEntry(t *testing.T) {
+	var numberOfCalls int32
+	release := make(chan bool)
+	cache := NewCache().WithLoader(func(key string) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&numberOfCalls, 1)
+		<-release
+		return "fresh", 20 * time.Millisecond, nil
+	})
+	cache.SetWithTTLAndGrace("key", "stale", 10*time.Millisecond, time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	// The stale value must be served right away while the entry is reloaded in the background
+	for i := 0; i < 3; i++ {
+		if value, stale, ok := cache.GetStale("key"); !ok || !stale || value != "stale" {
+			t.Fatalf("expected the stale value while the entry is being reloaded, got %v, %v and %v", value, stale, ok)
+		}
+	}
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for value, _ := cache.Peek("key"); value != "fresh"; value, _ = cache.Peek("key") {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the entry to have been reloaded in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if calls := atomic.LoadInt32(&numberOfCalls); calls != 1 {
+		t.Error("expected a single reload for concurrent retrievals of the stale entry, got", calls)
+	}
+	if value, stale, ok := cache.GetStale("key"); !ok || stale || value != "fresh" {
+		t.Errorf("expected the fresh value, got %v, %v and %v", value, stale, ok)
+	}
+	// The reloaded entry must keep the grace period of the stale entry
+	time.Sleep(30 * time.Millisecond)
+	if value, stale, ok := cache.GetStale("key"); !ok || !stale || value != "fresh" {
+		t.Errorf("expected the reloaded entry to be served stale once its TTL has elapsed, got %v, %v and %v", value, stale, ok)
+	}
+}
+
+func TestCache_GetWithLoad(t *testing.T) {
+	cache := NewCache()
+	if _, err := cache.GetWithLoad("key"); err != ErrKeyDoesNotExist {
+		t.Error("expected ErrKeyDoesNotExist, since there is no loader, got", err)
+	}
+	expectedErr := errors.New("failed")
+	cache.WithLoader(func(key string) (interface{}, time.Duration, error) {
+		if key == "key" {
+			return "value", NoExpiration, nil
+		}
+		return nil, 0, expectedErr
+	})
+	if value, err := cache.GetWithLoad("key"); err != nil || value != "value" {
+		t.Errorf("expected value and no error, got %v and %v", value, err)
+	}
+	if ttl, err := cache.TTL("key"); err != ErrKeyHasNoExpiration {
+		t.Errorf("expected the loaded entry to have no expiration, got %v and %v", ttl, err)
+	}
+	if _, err := cache.GetWithLoad("other-key"); err != expectedErr {
+		t.Error("expected the error of the loader to have been returned, got", err)
+	}
+}
+
+func TestCache_WithLoaderConcurrently(t *testing.T) {
+	numberOfCalls := int32(0)
+	cache := NewCache().WithLoader(func(key string) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&numberOfCalls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return "value", NoExpiration, nil
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value, err := cache.GetWithLoad("key"); err != nil || value != "value" {
+				t.Errorf("expected value and no error, got %v and %v", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if numberOfCalls != 1 {
+		t.Errorf("expected concurrent misses to have shared a single call to the loader, got %d calls", numberOfCalls)
+	}
+}
@@ -0,0 +1,28 @@
+package gocache
+
+import "context"
+
+// GetWithContext is the same as Get, except that if the context passed as parameter is already done, nil, false and
+// ctx.Err() are returned without acquiring the lock
+//
+// This makes it possible for the cache to participate in request-scoped code, but note that once the lock has been
+// acquired, the context is no longer checked. Get remains the fastest way to retrieve an entry.
+func (cache *Cache) GetWithContext(ctx context.Context, key string) (interface{}, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	value, ok := cache.Get(key)
+	return value, ok, nil
+}
+
+// SetWithContext is the same as Set, except that if the context passed as parameter is already done, ctx.Err() is
+// returned and nothing is stored
+//
+// See GetWithContext for more details.
+func (cache *Cache) SetWithContext(ctx context.Context, key string, value interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	cache.Set(key, value)
+	return nil
+}
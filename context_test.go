@@ -0,0 +1,42 @@
+package gocache
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCache_GetWithContext(t *testing.T) {
+	cache := NewCache()
+	cache.Set("key", "value")
+	value, ok, err := cache.GetWithContext(context.Background(), "key")
+	if err != nil || !ok || value != "value" {
+		t.Errorf("expected value, true and no error, got %v, %v and %v", value, ok, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	value, ok, err = cache.GetWithContext(ctx, "key")
+	if err != context.Canceled || ok || value != nil {
+		t.Errorf("expected nil, false and context.Canceled, got %v, %v and %v", value, ok, err)
+	}
+	if cache.Stats().Hits != 1 {
+		t.Error("expected the canceled call to not have counted as accessing the entry, got", cache.Stats().Hits, "hits")
+	}
+}
+
+func TestCache_SetWithContext(t *testing.T) {
+	cache := NewCache()
+	if err := cache.SetWithContext(context.Background(), "key", "value"); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if value, _ := cache.Get("key"); value != "value" {
+		t.Error("expected value, got", value)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cache.SetWithContext(ctx, "other-key", "value"); err != context.Canceled {
+		t.Error("expected context.Canceled, got", err)
+	}
+	if _, ok := cache.Get("other-key"); ok {
+		t.Error("expected nothing to have been stored")
+	}
+}
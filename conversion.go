@@ -0,0 +1,276 @@
+package gocache
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// TypeNone is the type returned by Type when there is no such entry
+const TypeNone = "none"
+
+// TypeString is the type returned by Type when the value of an entry can be retrieved using GetString
+const TypeString = "string"
+
+// GetInt retrieves an entry using the key passed as parameter and converts its value to an int64
+//
+// Integer types are converted directly, while string and []byte values are parsed as a base 10 integer.
+// If there is no such entry, the value returned will be 0, the boolean will be false and the error will be nil.
+// If the entry exists but its value cannot be converted to an int64, ErrNotInteger or ErrWrongType is returned.
+func (cache *Cache) GetInt(key string) (int64, bool, error) {
+	value, ok := cache.Get(key)
+	if !ok {
+		return 0, false, nil
+	}
+	number, err := toInt64(value)
+	if err != nil {
+		return 0, true, err
+	}
+	return number, true, nil
+}
+
+// GetString retrieves an entry using the key passed as parameter and converts its value to a string
+//
+// []byte values are converted directly, while numeric values are formatted in base 10.
+// If there is no such entry, the value returned will be an empty string, the boolean will be false and the error
+// will be nil.
+// If the entry exists but its value cannot be converted to a string, ErrWrongType is returned.
+func (cache *Cache) GetString(key string) (string, bool, error) {
+	value, ok := cache.Get(key)
+	if !ok {
+		return "", false, nil
+	}
+	s, err := toString(value)
+	if err != nil {
+		return "", true, err
+	}
+	return s, true, nil
+}
+
+// GetBytes retrieves an entry using the key passed as parameter and converts its value to a []byte
+//
+// If the value is already a []byte, it is returned as is. Otherwise, the same conversion rules as GetString apply.
+// If there is no such entry, the value returned will be nil, the boolean will be false and the error will be nil.
+// If the entry exists but its value cannot be converted to a []byte, ErrWrongType is returned.
+func (cache *Cache) GetBytes(key string) ([]byte, bool, error) {
+	value, ok := cache.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	if b, isBytes := value.([]byte); isBytes {
+		return b, true, nil
+	}
+	s, err := toString(value)
+	if err != nil {
+		return nil, true, err
+	}
+	return []byte(s), true, nil
+}
+
+// StrLen retrieves an entry using the key passed as parameter and returns the length of its value in bytes, once
+// converted to a string
+//
+// The same conversion rules as GetString apply. If there is no such entry, 0 is returned.
+// If the entry exists but its value cannot be converted to a string, ErrWrongType is returned.
+func (cache *Cache) StrLen(key string) (int, error) {
+	value, ok := cache.Get(key)
+	if !ok {
+		return 0, nil
+	}
+	if b, isBytes := value.([]byte); isBytes {
+		return len(b), nil
+	}
+	s, err := toString(value)
+	if err != nil {
+		return 0, err
+	}
+	return len(s), nil
+}
+
+// Type returns the type of the value of an entry, without counting as accessing it
+//
+// Values that can be converted to a string (see GetString), which includes []byte and numeric values, are of type
+// TypeString, like they would be in Redis. Any other value is reported using its Go type, e.g. "[]string" or
+// "*main.User". If there is no such entry, TypeNone is returned.
+func (cache *Cache) Type(key string) string {
+	cache.mutex.RLock()
+	entry, ok := cache.get(key)
+	if !ok || entry.Expired() {
+		cache.mutex.RUnlock()
+		return TypeNone
+	}
+	value := entry.Value
+	cache.mutex.RUnlock()
+	if _, err := toString(value); err == nil {
+		return TypeString
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Append appends a suffix to the value of an entry and returns the length of the resulting value in bytes
+//
+// If there is no such entry, or if it has expired, an entry with the suffix as value and no expiration time is
+// created, unless the cache was configured with a default TTL (see WithDefaultTTL). Otherwise, the entry keeps its
+// expiration time.
+// []byte values remain []byte values, while any other value that can be converted to a string (see GetString)
+// becomes a string. If the value of the entry cannot be converted to a string, the entry is left untouched and
+// ErrWrongType is returned.
+//
+// If the entry grows beyond the limits of the cache as a result, other entries are evicted, but never the entry
+// appended to.
+func (cache *Cache) Append(key string, suffix string) (int, error) {
+	cache.prepareSet(key, suffix)
+	cache.mutex.Lock()
+	defer cache.unlockAndCallOnEvict()
+	entry, ok := cache.get(key)
+	if ok && entry.Expired() {
+		cache.deleteExpired(key)
+		ok = false
+	}
+	if !ok {
+		cache.set(key, suffix, cache.options.DefaultTTL)
+		return len(suffix), nil
+	}
+	if b, isBytes := entry.Value.([]byte); isBytes {
+		// A new slice is always allocated, since the original one may still be referenced by the caller that set it
+		value := make([]byte, 0, len(b)+len(suffix))
+		value = append(append(value, b...), suffix...)
+		cache.updateExistingEntryValue(entry, value)
+		cache.evictUntilWithinLimits(entry)
+		return len(value), nil
+	}
+	s, err := toString(entry.Value)
+	if err != nil {
+		return 0, err
+	}
+	value := s + suffix
+	cache.updateExistingEntryValue(entry, value)
+	cache.evictUntilWithinLimits(entry)
+	return len(value), nil
+}
+
+// toInt64 converts a value to an int64
+//
+// Returns ErrNotInteger if the value is a string, a []byte or a number that cannot be represented as an int64,
+// and ErrWrongType if the value is of any other type.
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint:
+		if uint64(v) > math.MaxInt64 {
+			return 0, ErrNotInteger
+		}
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, ErrNotInteger
+		}
+		return int64(v), nil
+	case float32, float64:
+		return 0, ErrNotInteger
+	case string:
+		return parseInt64(v)
+	case []byte:
+		return parseInt64(string(v))
+	default:
+		return 0, ErrWrongType
+	}
+}
+
+// toString converts a value to a string
+//
+// Returns ErrWrongType if the value is neither a string, a []byte nor a number.
+func toString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int, int8, int16, int32, int64, uint8, uint16, uint32:
+		number, _ := toInt64(v)
+		return strconv.FormatInt(number, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", ErrWrongType
+	}
+}
+
+// fromInt64 converts a number back to the type of the value passed as parameter, so that numeric operations don't
+// change the type of the values stored in the cache
+//
+// If the number cannot be represented using the type of the original value, it is returned as an int64.
+func fromInt64(original interface{}, number int64) interface{} {
+	switch original.(type) {
+	case int:
+		if int64(int(number)) == number {
+			return int(number)
+		}
+	case int8:
+		if number >= math.MinInt8 && number <= math.MaxInt8 {
+			return int8(number)
+		}
+	case int16:
+		if number >= math.MinInt16 && number <= math.MaxInt16 {
+			return int16(number)
+		}
+	case int32:
+		if number >= math.MinInt32 && number <= math.MaxInt32 {
+			return int32(number)
+		}
+	case uint:
+		if number >= 0 && uint64(uint(number)) == uint64(number) {
+			return uint(number)
+		}
+	case uint8:
+		if number >= 0 && number <= math.MaxUint8 {
+			return uint8(number)
+		}
+	case uint16:
+		if number >= 0 && number <= math.MaxUint16 {
+			return uint16(number)
+		}
+	case uint32:
+		if number >= 0 && number <= math.MaxUint32 {
+			return uint32(number)
+		}
+	case uint64:
+		if number >= 0 {
+			return uint64(number)
+		}
+	case string:
+		return strconv.FormatInt(number, 10)
+	case []byte:
+		return []byte(strconv.FormatInt(number, 10))
+	}
+	return number
+}
+
+func parseInt64(s string) (int64, error) {
+	number, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	return number, nil
+}
@@ -0,0 +1,280 @@
+package gocache
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache_GetInt(t *testing.T) {
+	cache := NewCache()
+	cache.Set("int", 5)
+	cache.Set("int64", int64(-42))
+	cache.Set("uint8", uint8(8))
+	cache.Set("string", "123")
+	cache.Set("bytes", []byte("456"))
+	scenarios := map[string]int64{
+		"int":    5,
+		"int64":  -42,
+		"uint8":  8,
+		"string": 123,
+		"bytes":  456,
+	}
+	for key, expectedValue := range scenarios {
+		value, ok, err := cache.GetInt(key)
+		if err != nil {
+			t.Errorf("[%s] expected no error, got %v", key, err)
+		}
+		if !ok {
+			t.Errorf("[%s] expected key to exist", key)
+		}
+		if value != expectedValue {
+			t.Errorf("[%s] expected %d, got %d", key, expectedValue, value)
+		}
+	}
+}
+
+func TestCache_GetIntWhenKeyDoesNotExist(t *testing.T) {
+	cache := NewCache()
+	value, ok, err := cache.GetInt("key")
+	if err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if ok {
+		t.Error("expected key to not exist")
+	}
+	if value != 0 {
+		t.Error("expected value to be 0, got", value)
+	}
+}
+
+func TestCache_GetIntWithMismatchedType(t *testing.T) {
+	cache := NewCache()
+	cache.Set("string", "not-a-number")
+	cache.Set("float", 1.5)
+	cache.Set("overflow", uint64(1<<63))
+	cache.Set("struct", struct{ A int }{A: 1})
+	scenarios := map[string]error{
+		"string":   ErrNotInteger,
+		"float":    ErrNotInteger,
+		"overflow": ErrNotInteger,
+		"struct":   ErrWrongType,
+	}
+	for key, expectedErr := range scenarios {
+		_, ok, err := cache.GetInt(key)
+		if !ok {
+			t.Errorf("[%s] expected key to exist", key)
+		}
+		if err != expectedErr {
+			t.Errorf("[%s] expected error %v, got %v", key, expectedErr, err)
+		}
+	}
+}
+
+func TestCache_GetString(t *testing.T) {
+	cache := NewCache()
+	cache.Set("string", "value")
+	cache.Set("bytes", []byte("value"))
+	cache.Set("int", 5)
+	cache.Set("uint64", uint64(1<<63))
+	cache.Set("float", 1.5)
+	scenarios := map[string]string{
+		"string": "value",
+		"bytes":  "value",
+		"int":    "5",
+		"uint64": "9223372036854775808",
+		"float":  "1.5",
+	}
+	for key, expectedValue := range scenarios {
+		value, ok, err := cache.GetString(key)
+		if err != nil {
+			t.Errorf("[%s] expected no error, got %v", key, err)
+		}
+		if !ok {
+			t.Errorf("[%s] expected key to exist", key)
+		}
+		if value != expectedValue {
+			t.Errorf("[%s] expected %s, got %s", key, expectedValue, value)
+		}
+	}
+}
+
+func TestCache_GetStringWithMismatchedType(t *testing.T) {
+	cache := NewCache()
+	cache.Set("key", []string{"a", "b"})
+	if _, ok, err := cache.GetString("key"); !ok || err != ErrWrongType {
+		t.Errorf("expected key to exist and error to be %v, got %v", ErrWrongType, err)
+	}
+	if _, ok, err := cache.GetString("key-that-does-not-exist"); ok || err != nil {
+		t.Error("expected key to not exist and no error to be returned")
+	}
+}
+
+func TestCache_GetBytes(t *testing.T) {
+	cache := NewCache()
+	cache.Set("bytes", []byte{0, 1, 2})
+	cache.Set("string", "value")
+	cache.Set("int", 10)
+	scenarios := map[string][]byte{
+		"bytes":  {0, 1, 2},
+		"string": []byte("value"),
+		"int":    []byte("10"),
+	}
+	for key, expectedValue := range scenarios {
+		value, ok, err := cache.GetBytes(key)
+		if err != nil {
+			t.Errorf("[%s] expected no error, got %v", key, err)
+		}
+		if !ok {
+			t.Errorf("[%s] expected key to exist", key)
+		}
+		if !bytes.Equal(value, expectedValue) {
+			t.Errorf("[%s] expected %v, got %v", key, expectedValue, value)
+		}
+	}
+}
+
+func TestCache_GetBytesWithMismatchedType(t *testing.T) {
+	cache := NewCache()
+	cache.Set("key", true)
+	if _, ok, err := cache.GetBytes("key"); !ok || err != ErrWrongType {
+		t.Errorf("expected key to exist and error to be %v, got %v", ErrWrongType, err)
+	}
+	if value, ok, err := cache.GetBytes("key-that-does-not-exist"); ok || err != nil || value != nil {
+		t.Error("expected key to not exist and no error to be returned")
+	}
+}
+
+func TestCache_Append(t *testing.T) {
+	cache := NewCache()
+	if length, err := cache.Append("key", "hello"); err != nil || length != 5 {
+		t.Errorf("expected a missing key to be created, got %d and %v", length, err)
+	}
+	cache.Expire("key", time.Hour)
+	if length, err := cache.Append("key", " world"); err != nil || length != 11 {
+		t.Errorf("expected 11, got %d and %v", length, err)
+	}
+	if value, _ := cache.Get("key"); value != "hello world" {
+		t.Error("expected hello world, got", value)
+	}
+	if ttl, err := cache.TTL("key"); err != nil || ttl <= 0 {
+		t.Error("expected TTL of the key to have been preserved")
+	}
+	cache.Set("bytes", []byte("a"))
+	cache.Set("int", 1)
+	if length, err := cache.Append("bytes", "b"); err != nil || length != 2 {
+		t.Errorf("expected 2, got %d and %v", length, err)
+	}
+	if value, _ := cache.Get("bytes"); !bytes.Equal(value.([]byte), []byte("ab")) {
+		t.Errorf("expected []byte value to remain a []byte value, got %v (%T)", value, value)
+	}
+	if length, err := cache.Append("int", "0"); err != nil || length != 2 {
+		t.Errorf("expected 2, got %d and %v", length, err)
+	}
+	if value, _ := cache.Get("int"); value != "10" {
+		t.Errorf("expected numeric value to have been converted to a string, got %v (%T)", value, value)
+	}
+}
+
+func TestCache_AppendConcurrently(t *testing.T) {
+	cache := NewCache()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Append("key", "a")
+		}()
+	}
+	wg.Wait()
+	if value, _ := cache.Get("key"); value != strings.Repeat("a", 100) {
+		t.Error("expected no append to have been lost, got", value)
+	}
+}
+
+func TestCache_AppendWithMaxMemoryUsage(t *testing.T) {
+	const maxMemoryUsage = 1000
+	cache := NewCache().WithMaxSize(NoMaxSize).WithMaxMemoryUsage(maxMemoryUsage)
+	for i := 0; i < 5; i++ {
+		cache.Set(strconv.Itoa(i), "value")
+	}
+	for i := 0; i < 100; i++ {
+		cache.Append("key", "0123456789")
+		if cache.MemoryUsage() > maxMemoryUsage && cache.Count() > 1 {
+			t.Fatalf("expected memory usage to be at most %d after appending, got %d", maxMemoryUsage, cache.MemoryUsage())
+		}
+	}
+	// The entry appended to must never be evicted, even once it exceeds the limit on its own
+	if value, ok := cache.Get("key"); !ok || value != strings.Repeat("0123456789", 100) {
+		t.Error("expected the entry appended to to have been kept, got", value)
+	}
+	if cache.Count() != 1 {
+		t.Error("expected every other entry to have been evicted, got", cache.Count(), "entries")
+	}
+	if err := cache.DebugVerify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCache_AppendWithMismatchedType(t *testing.T) {
+	cache := NewCache()
+	cache.Set("key", []string{"a"})
+	if _, err := cache.Append("key", "b"); err != ErrWrongType {
+		t.Errorf("expected error %v, got %v", ErrWrongType, err)
+	}
+	if value, _ := cache.Get("key"); len(value.([]string)) != 1 {
+		t.Error("expected value to have been left untouched, got", value)
+	}
+}
+
+func TestCache_StrLen(t *testing.T) {
+	cache := NewCache()
+	cache.Set("string", "héllo")
+	cache.Set("bytes", []byte{0, 1, 2})
+	cache.Set("int", -42)
+	cache.Set("slice", []string{"a"})
+	scenarios := map[string]int{
+		"string":                  6,
+		"bytes":                   3,
+		"int":                     3,
+		"key-that-does-not-exist": 0,
+	}
+	for key, expectedLength := range scenarios {
+		if length, err := cache.StrLen(key); err != nil || length != expectedLength {
+			t.Errorf("[%s] expected %d, got %d and %v", key, expectedLength, length, err)
+		}
+	}
+	if _, err := cache.StrLen("slice"); err != ErrWrongType {
+		t.Errorf("expected error %v, got %v", ErrWrongType, err)
+	}
+}
+
+func TestCache_Type(t *testing.T) {
+	cache := NewCache()
+	cache.Set("string", "value")
+	cache.Set("bytes", []byte("value"))
+	cache.Set("int", 5)
+	cache.Set("slice", []string{"a", "b"})
+	cache.Set("struct", struct{ A int }{A: 1})
+	cache.Set("nil", nil)
+	cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	scenarios := map[string]string{
+		"string":                  TypeString,
+		"bytes":                   TypeString,
+		"int":                     TypeString,
+		"slice":                   "[]string",
+		"struct":                  "struct { A int }",
+		"nil":                     "<nil>",
+		"expired":                 TypeNone,
+		"key-that-does-not-exist": TypeNone,
+	}
+	for key, expectedType := range scenarios {
+		if actualType := cache.Type(key); actualType != expectedType {
+			t.Errorf("[%s] expected type %s, got %s", key, expectedType, actualType)
+		}
+	}
+}
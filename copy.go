@@ -0,0 +1,72 @@
+package gocache
+
+import "reflect"
+
+// copyValue returns a deep copy of the value passed as parameter if it's a slice, a map or an array, or
+// the value itself otherwise.
+//
+// Only built-in composite types are copied. Pointers, channels, functions and structs are returned as is, meaning that
+// the values they reference are still shared, but slices, maps and arrays nested within other slices, maps and arrays
+// are copied as well.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return value
+	case []byte:
+		if v == nil {
+			return v
+		}
+		copied := make([]byte, len(v))
+		copy(copied, v)
+		return copied
+	}
+	return deepCopy(reflect.ValueOf(value)).Interface()
+}
+
+func deepCopy(original reflect.Value) reflect.Value {
+	switch original.Kind() {
+	case reflect.Slice:
+		if original.IsNil() {
+			return original
+		}
+		copied := reflect.MakeSlice(original.Type(), original.Len(), original.Len())
+		if !isComposite(original.Type().Elem().Kind()) {
+			reflect.Copy(copied, original)
+			return copied
+		}
+		for i := 0; i < original.Len(); i++ {
+			copied.Index(i).Set(deepCopy(original.Index(i)))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(original.Type()).Elem()
+		for i := 0; i < original.Len(); i++ {
+			copied.Index(i).Set(deepCopy(original.Index(i)))
+		}
+		return copied
+	case reflect.Map:
+		if original.IsNil() {
+			return original
+		}
+		copied := reflect.MakeMapWithSize(original.Type(), original.Len())
+		iterator := original.MapRange()
+		for iterator.Next() {
+			copied.SetMapIndex(iterator.Key(), deepCopy(iterator.Value()))
+		}
+		return copied
+	case reflect.Interface:
+		if original.IsNil() {
+			return original
+		}
+		copied := reflect.New(original.Type()).Elem()
+		copied.Set(deepCopy(original.Elem()))
+		return copied
+	default:
+		return original
+	}
+}
+
+// isComposite returns whether a kind may hold a slice, a map or an array that would need to be copied
+func isComposite(kind reflect.Kind) bool {
+	return kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map || kind == reflect.Interface
+}
@@ -0,0 +1,88 @@
+package gocache
+
+import "math"
+
+// Increment increments the integer value of an entry by delta, which may be negative, and returns the resulting value
+//
+// If there is no such entry, or if it has expired, the value is considered to be 0, meaning that an entry with delta
+// as value and no expiration time is created, unless the cache was configured with a default TTL
+// (see WithDefaultTTL). Otherwise, the entry keeps its expiration time.
+// If the value of the entry is not an integer, or if incrementing it would overflow, the entry is left untouched and
+// ErrNotInteger or ErrWrongType is returned.
+//
+// The resulting value is stored using the same type as the original value (see GetInt for the supported types), or
+// as an int64 if the entry is created. If the entry grows beyond the limits of the cache as a result, other entries are
+// evicted, but never the entry incremented.
+func (cache *Cache) Increment(key string, delta int64) (int64, error) {
+	cache.prepareSet(key, delta)
+	cache.mutex.Lock()
+	defer cache.unlockAndCallOnEvict()
+	entry, ok := cache.get(key)
+	if ok && entry.Expired() {
+		cache.deleteExpired(key)
+		ok = false
+	}
+	if !ok {
+		cache.set(key, delta, cache.options.DefaultTTL)
+		return delta, nil
+	}
+	number, err := toInt64(entry.Value)
+	if err != nil {
+		return 0, err
+	}
+	if (delta > 0 && number > math.MaxInt64-delta) || (delta < 0 && number < math.MinInt64-delta) {
+		return 0, ErrNotInteger
+	}
+	number += delta
+	cache.updateExistingEntryValue(entry, fromInt64(entry.Value, number))
+	// The value may have grown, e.g. if it no longer fits in its original type or if it's a string with more digits
+	cache.evictUntilWithinLimits(entry)
+	return number, nil
+}
+
+// Decrement decrements the integer value of an entry by delta, which may be negative, and returns the resulting value
+//
+// The same rules as Increment apply, except that delta cannot be math.MinInt64, since decrementing by it would be
+// the same as incrementing by a number that does not fit in an int64, in which case ErrNotInteger is returned.
+func (cache *Cache) Decrement(key string, delta int64) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, ErrNotInteger
+	}
+	return cache.Increment(key, -delta)
+}
+
+// DecrementAndDeleteAtZero decrements the integer value of an entry by delta, and deletes the entry if the resulting
+// value is less than or equal to 0, which makes it suitable for consuming credits that must disappear once exhausted.
+//
+// The value returned is the resulting value, and the boolean returned is whether the entry still exists after the
+// operation. If there is no such entry, nothing happens and the value returned will be 0 and the boolean will be false.
+// If the value of the entry is not an integer, or if decrementing it would overflow, the entry is left untouched and
+// the value returned will be 0 and the boolean will be true.
+//
+// The resulting value is stored using the same type as the original value (see GetInt for the supported types). Like
+// with Increment, if the entry grows beyond the limits of the cache as a result, other entries are evicted, but never the
+// entry decremented.
+func (cache *Cache) DecrementAndDeleteAtZero(key string, delta int64) (int64, bool) {
+	cache.mutex.Lock()
+	defer cache.unlockAndCallOnEvict()
+	entry, ok := cache.get(key)
+	if !ok || entry.Expired() {
+		if ok {
+			cache.deleteExpired(key)
+		}
+		return 0, false
+	}
+	number, err := toInt64(entry.Value)
+	if err != nil || (delta > 0 && number < math.MinInt64+delta) || (delta < 0 && number > math.MaxInt64+delta) {
+		return 0, true
+	}
+	number -= delta
+	if number <= 0 {
+		cache.delete(key)
+		return number, false
+	}
+	cache.updateExistingEntryValue(entry, fromInt64(entry.Value, number))
+	// The value may have grown, e.g. if delta is negative and the value is a string with more digits as a result
+	cache.evictUntilWithinLimits(entry)
+	return number, true
+}
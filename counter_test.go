@@ -0,0 +1,234 @@
+package gocache
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCache_Increment(t *testing.T) {
+	cache := NewCache()
+	value, err := cache.Increment("counter", 5)
+	if err != nil || value != 5 {
+		t.Errorf("expected a missing key to be considered as 0, got %d and %v", value, err)
+	}
+	if value, _ := cache.Get("counter"); value != int64(5) {
+		t.Errorf("expected the created entry to have an int64 value of 5, got %v (%T)", value, value)
+	}
+	cache.SetWithTTL("counter", "10", time.Hour)
+	value, err = cache.Increment("counter", -3)
+	if err != nil || value != 7 {
+		t.Errorf("expected 7, got %d and %v", value, err)
+	}
+	if value, _ := cache.Get("counter"); value != "7" {
+		t.Errorf("expected cached value to be 7 with the same type as the original value, got %v (%T)", value, value)
+	}
+	if ttl, err := cache.TTL("counter"); err != nil || ttl <= 0 {
+		t.Error("expected TTL of the key to have been preserved")
+	}
+}
+
+func TestCache_IncrementWithMaxMemoryUsage(t *testing.T) {
+	scenarios := []struct {
+		name          string
+		value         interface{}
+		delta         int64
+		expectedValue interface{}
+	}{
+		{
+			// int8 can't hold 128, so the value is stored as an int64, which is larger
+			name:          "int8-that-overflows",
+			value:         int8(math.MaxInt8),
+			delta:         1,
+			expectedValue: int64(math.MaxInt8 + 1),
+		},
+		{
+			name:          "string-with-more-digits",
+			value:         "-99",
+			delta:         -1,
+			expectedValue: "-100",
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			populate := func(cache *Cache) {
+				for _, key := range []string{"a", "b", "c"} {
+					cache.Set(key, "value")
+				}
+				cache.Set("counter", scenario.value)
+			}
+			// Use the exact memory usage of the entries as the limit, so that any growth exceeds it
+			probe := NewCache().WithMaxSize(NoMaxSize).WithMaxMemoryUsage(Megabyte)
+			populate(probe)
+			maxMemoryUsage := probe.MemoryUsage()
+			cache := NewCache().WithMaxSize(NoMaxSize).WithMaxMemoryUsage(maxMemoryUsage)
+			populate(cache)
+			if _, err := cache.Increment("counter", scenario.delta); err != nil {
+				t.Fatal("expected no error, got", err)
+			}
+			if cache.MemoryUsage() > maxMemoryUsage {
+				t.Errorf("expected memory usage to be at most %d after incrementing, got %d", maxMemoryUsage, cache.MemoryUsage())
+			}
+			if cache.Count() != 3 {
+				t.Error("expected exactly one entry to have been evicted, got", cache.Count(), "entries")
+			}
+			if value, _ := cache.Get("counter"); value != scenario.expectedValue {
+				t.Errorf("expected the entry incremented to not have been evicted, got %v (%T)", value, value)
+			}
+			if err := cache.DebugVerify(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCache_IncrementWhenKeyHasExpired(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("counter", 100, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if value, err := cache.Increment("counter", 1); err != nil || value != 1 {
+		t.Errorf("expected an expired key to be considered as 0, got %d and %v", value, err)
+	}
+	if _, err := cache.TTL("counter"); err != ErrKeyHasNoExpiration {
+		t.Error("expected the new entry to have no expiration time")
+	}
+}
+
+func TestCache_IncrementWithInvalidValue(t *testing.T) {
+	cache := NewCache()
+	cache.Set("string", "not-a-number")
+	cache.Set("struct", struct{}{})
+	cache.Set("max", int64(math.MaxInt64))
+	cache.Set("min", int64(math.MinInt64))
+	scenarios := []struct {
+		key         string
+		delta       int64
+		expectedErr error
+	}{
+		{key: "string", delta: 1, expectedErr: ErrNotInteger},
+		{key: "struct", delta: 1, expectedErr: ErrWrongType},
+		{key: "max", delta: 1, expectedErr: ErrNotInteger},
+		{key: "min", delta: -1, expectedErr: ErrNotInteger},
+	}
+	for _, scenario := range scenarios {
+		before, _ := cache.Get(scenario.key)
+		if _, err := cache.Increment(scenario.key, scenario.delta); err != scenario.expectedErr {
+			t.Errorf("[%s] expected error %v, got %v", scenario.key, scenario.expectedErr, err)
+		}
+		if after, _ := cache.Get(scenario.key); after != before {
+			t.Errorf("[%s] expected value to have been left untouched, got %v", scenario.key, after)
+		}
+	}
+}
+
+func TestCache_Decrement(t *testing.T) {
+	cache := NewCache()
+	value, err := cache.Decrement("counter", 5)
+	if err != nil || value != -5 {
+		t.Errorf("expected a missing key to be considered as 0, got %d and %v", value, err)
+	}
+	cache.Set("counter", uint8(10))
+	value, err = cache.Decrement("counter", 3)
+	if err != nil || value != 7 {
+		t.Errorf("expected 7, got %d and %v", value, err)
+	}
+	if value, _ := cache.Get("counter"); value != uint8(7) {
+		t.Errorf("expected cached value to be 7 with the same type as the original value, got %v (%T)", value, value)
+	}
+	if _, err = cache.Decrement("counter", math.MinInt64); err != ErrNotInteger {
+		t.Errorf("expected error %v, got %v", ErrNotInteger, err)
+	}
+	if value, _ := cache.Get("counter"); value != uint8(7) {
+		t.Errorf("expected value to have been left untouched, got %v", value)
+	}
+}
+
+func TestCache_DecrementAndDeleteAtZero(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("credits", 3, time.Hour)
+	value, exists := cache.DecrementAndDeleteAtZero("credits", 1)
+	if value != 2 || !exists {
+		t.Errorf("expected value to be 2 and key to still exist, got %d and %v", value, exists)
+	}
+	if value, _ := cache.Get("credits"); value != 2 {
+		t.Errorf("expected cached value to be 2 with the same type as the original value, got %v (%T)", value, value)
+	}
+	if ttl, err := cache.TTL("credits"); err != nil || ttl <= 0 {
+		t.Error("expected TTL of the key to have been preserved")
+	}
+	value, exists = cache.DecrementAndDeleteAtZero("credits", 2)
+	if value != 0 || exists {
+		t.Errorf("expected value to be 0 and key to no longer exist, got %d and %v", value, exists)
+	}
+	if _, ok := cache.Get("credits"); ok {
+		t.Error("expected key to have been deleted")
+	}
+}
+
+func TestCache_DecrementAndDeleteAtZeroWithMaxMemoryUsage(t *testing.T) {
+	populate := func(cache *Cache) {
+		for _, key := range []string{"a", "b", "c"} {
+			cache.Set(key, "value")
+		}
+		cache.Set("credits", "99")
+	}
+	// Use the exact memory usage of the entries as the limit, so that any growth exceeds it
+	probe := NewCache().WithMaxSize(NoMaxSize).WithMaxMemoryUsage(Megabyte)
+	populate(probe)
+	maxMemoryUsage := probe.MemoryUsage()
+	cache := NewCache().WithMaxSize(NoMaxSize).WithMaxMemoryUsage(maxMemoryUsage)
+	populate(cache)
+	if value, exists := cache.DecrementAndDeleteAtZero("credits", -1); value != 100 || !exists {
+		t.Fatalf("expected value to be 100 and key to still exist, got %d and %v", value, exists)
+	}
+	if cache.MemoryUsage() > maxMemoryUsage {
+		t.Errorf("expected memory usage to be at most %d after decrementing, got %d", maxMemoryUsage, cache.MemoryUsage())
+	}
+	if cache.Count() != 3 {
+		t.Error("expected exactly one entry to have been evicted, got", cache.Count(), "entries")
+	}
+	if value, _ := cache.Get("credits"); value != "100" {
+		t.Errorf("expected the entry decremented to not have been evicted, got %v (%T)", value, value)
+	}
+	if err := cache.DebugVerify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCache_DecrementAndDeleteAtZeroBelowZero(t *testing.T) {
+	cache := NewCache()
+	cache.Set("credits", "1")
+	value, exists := cache.DecrementAndDeleteAtZero("credits", 5)
+	if value != -4 || exists {
+		t.Errorf("expected value to be -4 and key to no longer exist, got %d and %v", value, exists)
+	}
+	if cache.Count() != 0 {
+		t.Error("expected key to have been deleted")
+	}
+}
+
+func TestCache_DecrementAndDeleteAtZeroWhenKeyDoesNotExist(t *testing.T) {
+	cache := NewCache()
+	value, exists := cache.DecrementAndDeleteAtZero("credits", 1)
+	if value != 0 || exists {
+		t.Errorf("expected value to be 0 and key to not exist, got %d and %v", value, exists)
+	}
+	if cache.Count() != 0 {
+		t.Error("expected key to not have been created")
+	}
+}
+
+func TestCache_DecrementAndDeleteAtZeroWithInvalidValue(t *testing.T) {
+	cache := NewCache()
+	cache.Set("not-a-number", "value")
+	cache.Set("min", int64(math.MinInt64+1))
+	for _, key := range []string{"not-a-number", "min"} {
+		value, exists := cache.DecrementAndDeleteAtZero(key, 2)
+		if value != 0 || !exists {
+			t.Errorf("[%s] expected value to be 0 and key to still exist, got %d and %v", key, value, exists)
+		}
+	}
+	if value, _ := cache.Get("not-a-number"); value != "value" {
+		t.Error("expected value to have been left untouched, got", value)
+	}
+}
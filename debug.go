@@ -0,0 +1,66 @@
+package gocache
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DebugVerify checks the consistency of the internal data structures of the cache, and returns an error describing
+// the first discrepancy found, if any
+//
+// More specifically, it verifies that walking the linked list from the head reaches the tail and vice versa, that the
+// list and the map contain the exact same entries, that the entries are sorted by sequence number and, if the cache
+// has a maxMemoryUsage, that the memory usage matches the size of the entries.
+//
+// This is meant to be used for debugging and in tests. Since it walks every entry while the cache is locked, you
+// should avoid calling it frequently on a large cache.
+func (cache *Cache) DebugVerify() error {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+	if (cache.head == nil) != (cache.tail == nil) {
+		return fmt.Errorf("head is %v but tail is %v", cache.head, cache.tail)
+	}
+	if cache.head != nil && (cache.head.previous != nil || cache.tail.next != nil) {
+		return errors.New("head has a previous entry or tail has a next entry")
+	}
+	numberOfEntries, memoryUsage := 0, 0
+	var last *Entry
+	for entry := cache.head; entry != nil; entry = entry.next {
+		numberOfEntries++
+		if numberOfEntries > len(cache.entries) {
+			return fmt.Errorf("walking from head via next visits more entries than the %d in the map", len(cache.entries))
+		}
+		if entry.previous != last {
+			return fmt.Errorf("previous entry of %s does not point back to the entry before it", entry.Key)
+		}
+		if last != nil && entry.Sequence >= last.Sequence {
+			return fmt.Errorf("entry %s has a sequence number of %d, which isn't lower than %d", entry.Key, entry.Sequence, last.Sequence)
+		}
+		if entryFromMap, ok := cache.entries[entry.Key]; !ok || entryFromMap != entry {
+			return fmt.Errorf("entry %s is in the list but not in the map", entry.Key)
+		}
+		memoryUsage += entry.sizeInBytes
+		last = entry
+	}
+	if last != cache.tail {
+		return errors.New("walking from head via next does not reach tail")
+	}
+	if numberOfEntries != len(cache.entries) {
+		return fmt.Errorf("expected %d entries in the list, got %d", len(cache.entries), numberOfEntries)
+	}
+	numberOfEntries = 0
+	for entry := cache.tail; entry != nil; entry = entry.previous {
+		numberOfEntries++
+		if numberOfEntries > len(cache.entries) {
+			return fmt.Errorf("walking from tail via previous visits more entries than the %d in the map", len(cache.entries))
+		}
+		last = entry
+	}
+	if last != cache.head || numberOfEntries != len(cache.entries) {
+		return errors.New("walking from tail via previous does not reach head")
+	}
+	if cache.options.MaxMemoryUsage != NoMaxMemoryUsage && memoryUsage != cache.memoryUsage {
+		return fmt.Errorf("expected a memory usage of %d bytes based on the entries, got %d", memoryUsage, cache.memoryUsage)
+	}
+	return nil
+}
@@ -15,24 +15,67 @@ type Entry struct {
 	Value interface{}
 
 	// RelevantTimestamp is the variable used to store either:
-	// - creation timestamp, if the Cache's EvictionPolicy is FirstInFirstOut
-	// - last access timestamp, if the Cache's EvictionPolicy is LeastRecentlyUsed
+	// - creation timestamp, if the Cache's EvictionPolicy is FirstInFirstOut or Random
+	// - last access timestamp, if the Cache's EvictionPolicy is LeastRecentlyUsed or MostRecentlyUsed
 	//
 	// Note that updating an existing entry will also update this value
 	RelevantTimestamp time.Time
 
+	// Sequence is a number that increases every time the RelevantTimestamp is updated, which means that it reflects
+	// the exact order of the entries, even when their RelevantTimestamp are identical.
+	//
+	// Note that sequence numbers are only comparable between entries of the same cache.
+	Sequence uint64
+
 	// Expiration is the unix time in nanoseconds at which the entry will expire (-1 means no expiration)
 	Expiration int64
 
+	// TTL is the duration after which the entry was last set to expire, which is used to extend its expiration time
+	// every time it is accessed if the cache uses sliding expiration (see Cache.WithSlidingExpiration).
+	// 0 means that the entry has no expiration time, or that it was created before this field was introduced.
+	TTL time.Duration
+
 	next     *Entry
 	previous *Entry
+
+	// pinned determines whether the entry is exempt from evictions
+	pinned bool
+
+	// lastAccessed is the last time the entry was set or accessed, regardless of the eviction policy of the cache
+	lastAccessed time.Time
+
+	// accesses is the number of times the entry was accessed since it was created
+	accesses uint64
+
+	// grace is how long the entry may still be served once the TTL it was set with has elapsed, which is included in
+	// its Expiration and its TTL (see Cache.SetWithTTLAndGrace)
+	grace time.Duration
+
+	// sizeInBytes is the size of the entry as it was when it was last counted towards the memory usage of the cache,
+	// or 0 if the cache has no maximum memory usage
+	sizeInBytes int
 }
 
 // Accessed updates the Entry's RelevantTimestamp to now
+//
+// The cache only calls this when an entry is accessed if its EvictionPolicy is LeastRecentlyUsed or MostRecentlyUsed,
+// along with updating the Sequence, so that the order of the entries is preserved when they are saved and read back.
+// Under FirstInFirstOut and Random, the RelevantTimestamp remains the time at which the entry was created or updated.
 func (entry *Entry) Accessed() {
 	entry.RelevantTimestamp = time.Now()
 }
 
+// expireIn sets the expiration time of the Entry to the TTL passed as parameter from now (-1 is NoExpiration)
+func (entry *Entry) expireIn(ttl time.Duration) {
+	if ttl != NoExpiration {
+		entry.Expiration = time.Now().Add(ttl).UnixNano()
+		entry.TTL = ttl
+	} else {
+		entry.Expiration = NoExpiration
+		entry.TTL = 0
+	}
+}
+
 // Expired returns whether the Entry has expired
 func (entry Entry) Expired() bool {
 	if entry.Expiration > 0 {
@@ -43,6 +86,11 @@ func (entry Entry) Expired() bool {
 	return false
 }
 
+// stale returns whether the TTL the Entry was set with has elapsed, while its grace period hasn't
+func (entry *Entry) stale() bool {
+	return entry.grace > 0 && entry.Expiration > 0 && time.Now().UnixNano() > entry.Expiration-int64(entry.grace)
+}
+
 // SizeInBytes returns the size of an entry in bytes, approximately.
 func (entry *Entry) SizeInBytes() int {
 	return toBytes(entry.Key) + toBytes(entry.Value) + 32
@@ -1,6 +1,6 @@
 module github.com/TwinProduction/gocache
 
-go 1.16
+go 1.18
 
 require (
 	github.com/go-redis/redis v6.15.9+incompatible
@@ -9,3 +9,5 @@ require (
 	github.com/tidwall/redcon v1.3.2
 	go.etcd.io/bbolt v1.3.5
 )
+
+require golang.org/x/sys v0.0.0-20200519105757-fe76b779f299 // indirect
@@ -3,7 +3,9 @@ package gocache
 import (
 	"errors"
 	"reflect"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,7 +15,7 @@ var (
 
 const (
 	// NoMaxSize means that the cache has no maximum number of entries in the cache
-	// Setting Cache.maxSize to this value also means there will be no eviction
+	// Setting the MaxSize of a Cache to this value also means there will be no eviction
 	NoMaxSize = 0
 
 	// NoMaxMemoryUsage means that the cache has no maximum number of entries in the cache
@@ -25,6 +27,12 @@ const (
 	// NoExpiration is the value that must be used as TTL to specify that the given key should never expire
 	NoExpiration = -1
 
+	// defaultScanCount is the number of keys returned by Scan if the count passed as parameter is lower than 1
+	defaultScanCount = 10
+
+	// maximumNumberOfScanCursors is the number of cursors returned by Scan that are remembered at once
+	maximumNumberOfScanCursors = 100
+
 	Kilobyte = 1024
 	Megabyte = 1024 * Kilobyte
 	Gigabyte = 1024 * Megabyte
@@ -34,21 +42,16 @@ var (
 	ErrKeyDoesNotExist       = errors.New("key does not exist")
 	ErrKeyHasNoExpiration    = errors.New("key has no expiration")
 	ErrJanitorAlreadyRunning = errors.New("janitor is already running")
+	ErrNotInteger            = errors.New("value is not an integer or out of range")
+	ErrWrongType             = errors.New("value is of the wrong type")
+	ErrComputationPanicked   = errors.New("computation of the value panicked")
+	ErrUnsupportedFormat     = errors.New("unsupported serialization format or version")
 )
 
 // Cache is the core struct of gocache which contains the data as well as all relevant configuration fields
 type Cache struct {
-	// maxSize is the maximum amount of entries that can be in the cache at any given time
-	// By default, this is set to DefaultMaxSize
-	maxSize int
-
-	// maxMemoryUsage is the maximum amount of memory that can be taken up by the cache at any time
-	// By default, this is set to NoMaxMemoryUsage, meaning that the default behavior is to not evict
-	// based on maximum memory usage
-	maxMemoryUsage int
-
-	// evictionPolicy is the eviction policy
-	evictionPolicy EvictionPolicy
+	// options is the configuration of the cache, each field of which is set by the corresponding WithX function
+	options Options
 
 	// stats is the object that contains cache statistics/metrics
 	stats *Statistics
@@ -71,61 +74,157 @@ type Cache struct {
 	// memoryUsage is the approximate memory usage of the cache (dataset only) in bytes
 	memoryUsage int
 
-	// forceNilInterfaceOnNilPointer determines whether all Set-like functions should set a value as nil if the
-	// interface passed has a nil value but not a nil type.
-	//
-	// By default, interfaces are only nil when both their type and value is nil.
-	// This means that when you pass a pointer to a nil value, the type of the interface
-	// will still show as nil, which means that if you don't cast the interface after
-	// retrieving it, a nil check will return that the value is not false.
-	forceNilInterfaceOnNilPointer bool
+	// onFull is the function called whenever a new entry is about to be added to a cache that has already reached
+	// its maxSize
+	onFull func(cache *Cache)
+
+	// onEvict is the function called whenever an entry is evicted
+	onEvict func(key string, value interface{})
+
+	// loader is the function used to load the value of an entry that doesn't exist when it is retrieved
+	loader func(key string) (interface{}, time.Duration, error)
+
+	// warmupKeys are the keys loaded through the loader when Warmup is called
+	warmupKeys []string
+
+	// warmupWorkers is the maximum number of keys loaded concurrently by Warmup
+	warmupWorkers int
+
+	// eventChannel is the channel to which an Event is sent whenever an entry is set, deleted, expired or evicted
+	eventChannel chan<- Event
+
+	// evictedEntries are the entries evicted since the cache was locked, which onEvict must be called with once the
+	// cache is unlocked
+	evictedEntries []*Entry
+
+	// onEvictQueue are the entries evicted that the goroutine started by WithAsyncOnEvict has yet to call onEvict with
+	onEvictQueue []*Entry
+
+	// onEvictSignal is used to wake up the goroutine started by WithAsyncOnEvict whenever entries are queued, or nil
+	// if onEvict is called synchronously
+	onEvictSignal chan bool
+
+	// stopAsyncOnEvict is the channel used to stop the goroutine started by WithAsyncOnEvict
+	stopAsyncOnEvict chan bool
+
+	// sequence is the last sequence number assigned to an entry
+	sequence uint64
+
+	// scanCursors are the entries at which the iterations of Scan stopped, indexed by the cursor returned, so that
+	// they can be resumed without walking from the tail
+	scanCursors map[uint64]*Entry
+
+	// backgroundWorkers is the number of goroutines currently running in the background on behalf of the cache
+	backgroundWorkers int32
+
+	// watchers are the functions registered through WatchKey, indexed by key and then by watcher ID
+	watchers map[string]map[uint64]func(op string, value interface{})
+
+	// lastWatcherID is the ID assigned to the last function registered through WatchKey or channel returned by
+	// InvalidationBridge
+	lastWatcherID uint64
+
+	// invalidationBridges are the channels returned by InvalidationBridge, indexed by ID
+	invalidationBridges map[uint64]*invalidationBridge
+
+	// writeBehindFlush is the function configured through WithWriteBehind to write dirty entries to a backing store
+	writeBehindFlush func(batch map[string]interface{}) error
+
+	// dirtyEntries are the entries set since the last successful flush, indexed by key, or nil if write-behind isn't
+	// configured
+	dirtyEntries map[string]interface{}
+
+	// flushMutex prevents concurrent flushes, so that an older batch can never be written after a newer one
+	flushMutex sync.Mutex
+
+	// stopWriteBehind is the channel used to stop the goroutine that periodically flushes the dirty entries
+	stopWriteBehind chan bool
+
+	// computations are the computations started by GetOrComputeWithTTL that have not completed yet, indexed by key
+	computations map[string]*computation
+
+	// computationsMutex is the lock for accessing computations, which is separate from mutex because the cache must
+	// not be locked while a value is being computed
+	computationsMutex sync.Mutex
 }
 
 // MaxSize returns the maximum amount of keys that can be present in the cache before
 // new entries trigger the eviction of the tail
 func (cache *Cache) MaxSize() int {
-	return cache.maxSize
+	return cache.options.MaxSize
 }
 
 // MaxMemoryUsage returns the configured maxMemoryUsage of the cache
 func (cache *Cache) MaxMemoryUsage() int {
-	return cache.maxMemoryUsage
+	return cache.options.MaxMemoryUsage
 }
 
 // EvictionPolicy returns the EvictionPolicy of the Cache
 func (cache *Cache) EvictionPolicy() EvictionPolicy {
-	return cache.evictionPolicy
+	return cache.options.EvictionPolicy
 }
 
 // Stats returns statistics from the cache
 func (cache *Cache) Stats() Statistics {
 	cache.mutex.RLock()
 	stats := Statistics{
-		EvictedKeys: cache.stats.EvictedKeys,
-		ExpiredKeys: cache.stats.ExpiredKeys,
-		Hits:        cache.stats.Hits,
-		Misses:      cache.stats.Misses,
+		EvictedKeys:   cache.stats.EvictedKeys,
+		ExpiredKeys:   cache.stats.ExpiredKeys,
+		Hits:          cache.stats.Hits,
+		Misses:        cache.stats.Misses,
+		DroppedEvents: cache.stats.DroppedEvents,
 	}
 	cache.mutex.RUnlock()
 	return stats
 }
 
+// ResetStatistics resets all statistics returned by Stats to 0
+func (cache *Cache) ResetStatistics() {
+	cache.mutex.Lock()
+	cache.stats = &Statistics{}
+	cache.mutex.Unlock()
+}
+
+// BackgroundWorkers returns the number of goroutines currently running in the background on behalf of the cache,
+// such as the janitor
+func (cache *Cache) BackgroundWorkers() int {
+	return int(atomic.LoadInt32(&cache.backgroundWorkers))
+}
+
 // MemoryUsage returns the current memory usage of the cache's dataset in bytes
 // If MaxMemoryUsage is set to NoMaxMemoryUsage, this will return 0
 func (cache *Cache) MemoryUsage() int {
 	return cache.memoryUsage
 }
 
+// CanEvict returns whether entries may be evicted from the cache, which is only the case if the cache has either a
+// maximum size or a maximum memory usage
+func (cache *Cache) CanEvict() bool {
+	return cache.options.MaxSize != NoMaxSize || cache.options.MaxMemoryUsage != NoMaxMemoryUsage
+}
+
+// IsFull returns whether the cache has reached its maximum size, in other words, whether creating a new entry would
+// cause another entry to be evicted
+//
+// Note that the maximum memory usage is not taken into account, because whether a new entry would cause an eviction
+// depends on the size of that entry. If the cache has no maximum size, this always returns false.
+func (cache *Cache) IsFull() bool {
+	if cache.options.MaxSize == NoMaxSize {
+		return false
+	}
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+	return len(cache.entries) >= cache.options.MaxSize
+}
+
 // WithMaxSize sets the maximum amount of entries that can be in the cache at any given time
 // A maxSize of 0 or less means infinite
 func (cache *Cache) WithMaxSize(maxSize int) *Cache {
-	if maxSize < 0 {
-		maxSize = NoMaxSize
-	}
-	if maxSize != NoMaxSize && cache.Count() == 0 {
-		cache.entries = make(map[string]*Entry, maxSize)
+	cache.options.MaxSize = maxSize
+	cache.options.normalize()
+	if cache.options.MaxSize != NoMaxSize && cache.Count() == 0 {
+		cache.entries = make(map[string]*Entry, cache.options.MaxSize)
 	}
-	cache.maxSize = maxSize
 	return cache
 }
 
@@ -135,17 +234,16 @@ func (cache *Cache) WithMaxSize(maxSize int) *Cache {
 //
 // Setting this to NoMaxMemoryUsage will disable eviction by memory usage
 func (cache *Cache) WithMaxMemoryUsage(maxMemoryUsageInBytes int) *Cache {
-	if maxMemoryUsageInBytes < 0 {
-		maxMemoryUsageInBytes = NoMaxMemoryUsage
-	}
-	cache.maxMemoryUsage = maxMemoryUsageInBytes
+	cache.options.MaxMemoryUsage = maxMemoryUsageInBytes
+	cache.options.normalize()
 	return cache
 }
 
 // WithEvictionPolicy sets eviction algorithm.
-// Defaults to FirstInFirstOut (FIFO)
+// Defaults to FirstInFirstOut (FIFO), which is also used if the policy is empty
 func (cache *Cache) WithEvictionPolicy(policy EvictionPolicy) *Cache {
-	cache.evictionPolicy = policy
+	cache.options.EvictionPolicy = policy
+	cache.options.normalize()
 	return cache
 }
 
@@ -187,7 +285,162 @@ func (cache *Cache) WithEvictionPolicy(policy EvictionPolicy) *Cache {
 //
 // Defaults to true
 func (cache *Cache) WithForceNilInterfaceOnNilPointer(forceNilInterfaceOnNilPointer bool) *Cache {
-	cache.forceNilInterfaceOnNilPointer = forceNilInterfaceOnNilPointer
+	cache.options.ForceNilInterfaceOnNilPointer = forceNilInterfaceOnNilPointer
+	return cache
+}
+
+// WithOnFull sets the function that will be called whenever a new entry is about to be added to a cache that already
+// reached its maxSize, before any eviction takes place.
+//
+// The function is called without the cache's lock held, which means that it's safe for the callback to call the
+// cache's functions, e.g. to increase the cache's maximum size using Cache.WithMaxSize. If the cache is no longer
+// full once the callback returns, no eviction will take place.
+//
+// Unlike evictions, this only applies to the maxSize, not to the maxMemoryUsage.
+//
+// The callback is triggered every time the condition is met. If you only want to be notified the first time
+// the cache becomes full, you can wrap your callback with a sync.Once.
+func (cache *Cache) WithOnFull(callback func(cache *Cache)) *Cache {
+	cache.onFull = callback
+	return cache
+}
+
+// WithOnEvict sets the function that will be called whenever an entry is evicted to make room for other entries,
+// whether it is because of the maxSize or because of the maxMemoryUsage.
+//
+// The function is only called for evictions, not for entries that are deleted explicitly or that have expired.
+// It is called after the entry has been removed and without the cache's lock held, which means that it's safe for the
+// callback to call the cache's functions. It is called by the goroutine that caused the eviction, before the function
+// that caused the eviction returns, unless WithAsyncOnEvict is enabled.
+func (cache *Cache) WithOnEvict(callback func(key string, value interface{})) *Cache {
+	cache.onEvict = callback
+	return cache
+}
+
+// WithLoader sets the function used to load the value of an entry when it is retrieved but doesn't exist, which makes
+// the cache a read-through cache.
+//
+// The value returned by the loader is stored with the TTL returned by the loader, and returned by the function that
+// retrieved it. If the loader returns an error, nothing is stored; Get then reports the entry as missing, while
+// GetWithLoad returns the error.
+// Concurrent retrievals of the same missing key share a single call to the loader, which is called without the cache's
+// lock held, so it may freely use the cache.
+//
+// Note that only Get and the functions built on it, such as GetByKeys and GetWithLoad, use the loader.
+func (cache *Cache) WithLoader(loader func(key string) (interface{}, time.Duration, error)) *Cache {
+	cache.loader = loader
+	return cache
+}
+
+// WithEventChannel sets the channel to which an Event is sent whenever an entry is set, deleted, expired or evicted,
+// similarly to the keyspace notifications of Redis.
+//
+// Unlike WatchKey, this reports the events of every key through a single stream, in the order in which they happened.
+// Events are sent without blocking, which means that if the channel is full, the event is dropped and counted in
+// the DroppedEvents statistic (see Stats). Make sure to use a buffered channel that is consumed continuously.
+//
+// Pass nil to stop sending events.
+func (cache *Cache) WithEventChannel(ch chan<- Event) *Cache {
+	cache.mutex.Lock()
+	cache.eventChannel = ch
+	cache.mutex.Unlock()
+	return cache
+}
+
+// WithRejectNewEntriesWhenFullyPinned sets whether a new entry should be rejected when the cache is full and every
+// other entry is pinned (see Cache.Pin).
+//
+// If set to false, the cache will grow beyond its maxSize/maxMemoryUsage rather than reject the new entry.
+// If set to true, the new entry will be evicted as soon as it is created, which means that Set-like functions will
+// effectively do nothing for new keys.
+//
+// Defaults to false
+func (cache *Cache) WithRejectNewEntriesWhenFullyPinned(reject bool) *Cache {
+	cache.options.RejectNewEntriesWhenFullyPinned = reject
+	return cache
+}
+
+// WithReturnCopies sets whether Get-like functions should return a deep copy of values that are slices, maps or arrays
+// rather than the value stored in the cache.
+//
+// Because slices and maps are references, mutating a slice or a map returned by Get would otherwise silently modify
+// the value stored in the cache as well.
+//
+// Note that this has a cost proportional to the size of the value, and that only built-in composite types are copied.
+// Pointers and structs are returned as is, which means that the values they reference are still shared.
+//
+// Defaults to false
+func (cache *Cache) WithReturnCopies(returnCopies bool) *Cache {
+	cache.options.ReturnCopies = returnCopies
+	return cache
+}
+
+// WithEvictionBatchRatio sets the fraction of the max size of the cache to free at once whenever an eviction is needed
+//
+// For instance, with a max size of 1000 and a ratio of 0.1, adding an entry to a full cache evicts entries until the
+// cache is down to 900 entries, meaning that the next 100 entries can be added without triggering any eviction.
+// This reduces how often evictions take place when the cache constantly churns, at the cost of keeping fewer entries.
+//
+// The ratio must be between 0 and 1. Defaults to 0, which means that only one entry is evicted at a time.
+func (cache *Cache) WithEvictionBatchRatio(ratio float64) *Cache {
+	cache.options.EvictionBatchRatio = ratio
+	cache.options.normalize()
+	return cache
+}
+
+// WithInitialCapacity preallocates enough space for the cache to hold the given number of entries without having to
+// grow, which avoids repeatedly growing the underlying map when a large number of entries are about to be added,
+// such as before a large SetAll or before calling ReadFromFile with a large file.
+//
+// Note that this has no effect if the cache already has entries, and that the space preallocated is not released
+// until the cache is cleared.
+// WithMaxSize already preallocates enough space for the max size of the cache, so this is mostly useful without one.
+func (cache *Cache) WithInitialCapacity(initialCapacity int) *Cache {
+	cache.options.InitialCapacity = initialCapacity
+	if initialCapacity > 0 && cache.Count() == 0 {
+		cache.mutex.Lock()
+		cache.entries = make(map[string]*Entry, initialCapacity)
+		cache.mutex.Unlock()
+	}
+	return cache
+}
+
+// WithPersistenceCompression sets whether the files written by SaveToFile and SaveToFileAs should be compressed using
+// gzip, which significantly reduces their size at the cost of making saving slower.
+//
+// Regardless of this setting, ReadFromFile and ReadFromFileAs detect whether a file is compressed and read it
+// accordingly, which means that files saved before enabling or disabling compression can still be read.
+//
+// Defaults to false
+func (cache *Cache) WithPersistenceCompression(persistenceCompression bool) *Cache {
+	cache.options.PersistenceCompression = persistenceCompression
+	return cache
+}
+
+// WithDefaultTTL sets the TTL used by the functions that don't take a TTL as parameter, such as Set, SetAll, GetSet,
+// GetOrSet, SetIfAbsent, SetIfPresent, GetOrCompute, as well as Increment and Append when they create an entry.
+//
+// Functions that take a TTL as parameter, such as SetWithTTL, always use the TTL passed as parameter instead, which
+// means that passing NoExpiration explicitly still creates an entry that never expires.
+// A TTL of 0 or lower disables the default TTL.
+//
+// Defaults to NoExpiration
+func (cache *Cache) WithDefaultTTL(ttl time.Duration) *Cache {
+	cache.options.DefaultTTL = ttl
+	cache.options.normalize()
+	return cache
+}
+
+// WithSlidingExpiration sets whether accessing an entry that has an expiration time should push its expiration time
+// back by the TTL the entry was set with, so that entries only expire once they haven't been accessed for that long.
+//
+// Accessing an entry means retrieving it with a function that counts as accessing it, such as Get, GetByKeys and
+// GetOrSet, or touching it with Touch. Functions that don't count as accessing an entry, such as GetAll, Iterator and
+// Type, leave its expiration time untouched.
+//
+// Defaults to false
+func (cache *Cache) WithSlidingExpiration(slidingExpiration bool) *Cache {
+	cache.options.SlidingExpiration = slidingExpiration
 	return cache
 }
 
@@ -197,20 +450,14 @@ func (cache *Cache) WithForceNilInterfaceOnNilPointer(forceNilInterfaceOnNilPoin
 //     gocache.NewCache().WithMaxSize(10000).WithEvictionPolicy(gocache.LeastRecentlyUsed)
 //
 func NewCache() *Cache {
-	return &Cache{
-		maxSize:                       DefaultMaxSize,
-		evictionPolicy:                FirstInFirstOut,
-		stats:                         &Statistics{},
-		entries:                       make(map[string]*Entry),
-		mutex:                         sync.RWMutex{},
-		stopJanitor:                   nil,
-		forceNilInterfaceOnNilPointer: true,
-	}
+	return newCache(DefaultOptions(), 0)
 }
 
 // Set creates or updates a key with a given value
+//
+// The entry never expires, unless the cache was configured with a default TTL (see WithDefaultTTL).
 func (cache *Cache) Set(key string, value interface{}) {
-	cache.SetWithTTL(key, value, NoExpiration)
+	cache.SetWithTTL(key, value, cache.options.DefaultTTL)
 }
 
 // SetWithTTL creates or updates a key with a given value and sets an expiration time (-1 is NoExpiration)
@@ -218,29 +465,267 @@ func (cache *Cache) Set(key string, value interface{}) {
 // The TTL provided must be greater than 0, or NoExpiration (-1). If a negative value that isn't -1 (NoExpiration) is
 // provided, the entry will not be created if the key doesn't exist
 func (cache *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	value = cache.prepareSet(key, value)
+	cache.mutex.Lock()
+	cache.set(key, value, ttl)
+	cache.unlockAndCallOnEvict()
+}
+
+// SetWithTTLAndGrace creates or updates a key with a given value and sets an expiration time, after which the entry
+// is considered stale rather than expired for an additional grace period
+//
+// While an entry is stale, Get keeps returning its value, and if a loader has been configured through WithLoader, the
+// entry is reloaded in the background, with the TTL returned by the loader and the same grace period. If the loader
+// fails, the stale value keeps being served until the grace period has elapsed as well, at which point the entry
+// expires. GetStale can be used to find out whether the value returned is stale.
+//
+// Note that the grace period is included in the expiration time of the entry, as returned by TTL for instance.
+// If the TTL is NoExpiration or if the grace period is 0 or lower, this is the same as SetWithTTL.
+func (cache *Cache) SetWithTTLAndGrace(key string, value interface{}, ttl, grace time.Duration) {
+	if ttl < 1 || grace <= 0 {
+		cache.SetWithTTL(key, value, ttl)
+		return
+	}
+	value = cache.prepareSet(key, value)
+	cache.mutex.Lock()
+	cache.set(key, value, ttl+grace)
+	if entry, ok := cache.get(key); ok {
+		entry.grace = grace
+	}
+	cache.unlockAndCallOnEvict()
+}
+
+// SetKeepTTL updates the value of a key without changing its expiration time, like SET with the KEEPTTL option in Redis
+//
+// If the key doesn't exist or has expired, it is created the same way Set would create it, which means that it never
+// expires, unless the cache was configured with a default TTL (see WithDefaultTTL).
+func (cache *Cache) SetKeepTTL(key string, value interface{}) {
+	value = cache.prepareSet(key, value)
+	cache.mutex.Lock()
+	defer cache.unlockAndCallOnEvict()
+	entry, ok := cache.get(key)
+	if !ok || entry.Expired() {
+		cache.set(key, value, cache.options.DefaultTTL)
+		return
+	}
+	expiration, ttl, grace := entry.Expiration, entry.TTL, entry.grace
+	cache.set(key, value, NoExpiration)
+	entry.Expiration, entry.TTL, entry.grace = expiration, ttl, grace
+}
+
+// GetSet sets the value of a key and returns the value it had before, as well as whether the key existed
+//
+// Like GETSET in Redis, the key will no longer have an expiration time, even if it had one before, unless the cache
+// was configured with a default TTL (see WithDefaultTTL). See GetSetWithTTL to set an expiration time as well.
+func (cache *Cache) GetSet(key string, value interface{}) (interface{}, bool) {
+	return cache.GetSetWithTTL(key, value, cache.options.DefaultTTL)
+}
+
+// GetSetWithTTL sets the value and the expiration time of a key and returns the value it had before, as well as
+// whether the key existed
+//
+// If the key had already expired, it is considered as not existing. The same rules as SetWithTTL apply to the TTL.
+func (cache *Cache) GetSetWithTTL(key string, value interface{}, ttl time.Duration) (interface{}, bool) {
+	value = cache.prepareSet(key, value)
+	cache.mutex.Lock()
+	var oldValue interface{}
+	entry, existed := cache.get(key)
+	if existed && entry.Expired() {
+		existed = false
+		cache.deleteExpired(key)
+	}
+	if existed {
+		oldValue = entry.Value
+		cache.stats.Hits++
+	} else {
+		cache.stats.Misses++
+	}
+	cache.set(key, value, ttl)
+	cache.unlockAndCallOnEvict()
+	if existed && cache.options.ReturnCopies {
+		oldValue = copyValue(oldValue)
+	}
+	return oldValue, existed
+}
+
+// GetOrSet retrieves the value of a key if it exists, or sets it to the value passed as parameter if it doesn't
+//
+// If the key exists, its value is returned along with true. Otherwise, the value passed as parameter is stored and
+// returned along with false. Because both happen within a single lock acquisition, concurrent callers are guaranteed
+// to all get the same value, which makes it possible to populate a key only once.
+// See GetOrSetWithTTL to set an expiration time as well.
+func (cache *Cache) GetOrSet(key string, value interface{}) (interface{}, bool) {
+	return cache.GetOrSetWithTTL(key, value, cache.options.DefaultTTL)
+}
+
+// GetOrSetWithTTL retrieves the value of a key if it exists, or sets it to the value passed as parameter with the
+// given expiration time if it doesn't
+//
+// If the key has already expired, it is considered as not existing and is replaced. Retrieving an existing key counts
+// as accessing it, exactly like Get. The same rules as SetWithTTL apply to the TTL.
+func (cache *Cache) GetOrSetWithTTL(key string, value interface{}, ttl time.Duration) (interface{}, bool) {
+	value = cache.prepareSet(key, value)
+	cache.mutex.Lock()
+	entry, exists := cache.get(key)
+	if exists && entry.Expired() {
+		exists = false
+		cache.deleteExpired(key)
+	}
+	if !exists {
+		cache.stats.Misses++
+		cache.set(key, value, ttl)
+		cache.unlockAndCallOnEvict()
+		return value, false
+	}
+	cache.stats.Hits++
+	cache.accessExistingEntry(entry)
+	existingValue := entry.Value
+	cache.mutex.Unlock()
+	if cache.options.ReturnCopies {
+		existingValue = copyValue(existingValue)
+	}
+	return existingValue, true
+}
+
+// SetIfAbsent creates a key with a given value, but only if the key doesn't already exist
+//
+// Returns true if the key was created, and false if it already existed.
+// See SetIfAbsentWithTTL to set an expiration time as well.
+func (cache *Cache) SetIfAbsent(key string, value interface{}) bool {
+	return cache.SetIfAbsentWithTTL(key, value, cache.options.DefaultTTL)
+}
+
+// SetIfAbsentWithTTL creates a key with a given value and sets an expiration time, but only if the key doesn't
+// already exist
+//
+// If the key has already expired, it is considered as not existing and is replaced. Unlike GetOrSet, an existing key
+// is left untouched, meaning that it does not count as accessing it. The same rules as SetWithTTL apply to the TTL.
+//
+// Returns true if the key was created, and false if it already existed.
+func (cache *Cache) SetIfAbsentWithTTL(key string, value interface{}, ttl time.Duration) bool {
+	value = cache.prepareSet(key, value)
+	cache.mutex.Lock()
+	defer cache.unlockAndCallOnEvict()
+	if entry, exists := cache.get(key); exists {
+		if !entry.Expired() {
+			return false
+		}
+		cache.deleteExpired(key)
+	}
+	cache.set(key, value, ttl)
+	_, created := cache.get(key)
+	return created
+}
+
+// SetIfPresent updates a key with a given value, but only if the key already exists
+//
+// Like Set, the key will no longer have an expiration time, even if it had one before.
+// Returns true if the key was updated, and false if it didn't exist.
+// See SetIfPresentWithTTL to set an expiration time as well.
+func (cache *Cache) SetIfPresent(key string, value interface{}) bool {
+	return cache.SetIfPresentWithTTL(key, value, cache.options.DefaultTTL)
+}
+
+// SetIfPresentWithTTL updates a key with a given value and sets an expiration time, but only if the key already
+// exists
+//
+// If the key has already expired, it is considered as not existing. Updating a key resets its position in the cache
+// like SetWithTTL does, regardless of the eviction policy. The same rules as SetWithTTL apply to the TTL.
+//
+// Returns true if the key was updated, and false if it didn't exist.
+func (cache *Cache) SetIfPresentWithTTL(key string, value interface{}, ttl time.Duration) bool {
+	value = cache.forceNilIfNilPointer(value)
+	cache.mutex.Lock()
+	defer cache.unlockAndCallOnEvict()
+	entry, exists := cache.get(key)
+	if !exists {
+		return false
+	}
+	if entry.Expired() {
+		cache.deleteExpired(key)
+		return false
+	}
+	cache.set(key, value, ttl)
+	return true
+}
+
+// prepareSet does what must be done before setting a value, without holding the lock, and returns the value to set
+func (cache *Cache) prepareSet(key string, value interface{}) interface{} {
+	if cache.onFull != nil && cache.isFullAndMissing(key) {
+		cache.onFull(cache)
+	}
+	return cache.forceNilIfNilPointer(value)
+}
+
+// forceNilIfNilPointer returns nil if the value passed as parameter is a nil pointer and forceNilInterfaceOnNilPointer
+// is enabled, or the value itself otherwise
+func (cache *Cache) forceNilIfNilPointer(value interface{}) interface{} {
 	// An interface is only nil if both its value and its type are nil, however, passing a nil pointer as an interface{}
 	// means that the interface itself is not nil, because the interface value is nil but not the type.
-	if cache.forceNilInterfaceOnNilPointer {
+	if cache.options.ForceNilInterfaceOnNilPointer {
 		if value != nil && (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
-			value = nil
+			return nil
 		}
 	}
-	cache.mutex.Lock()
+	return value
+}
+
+// set creates or updates a key with a given value and sets an expiration time, evicting entries if necessary
+//
+// Unlike SetWithTTL, the caller is responsible for locking the cache.
+func (cache *Cache) set(key string, value interface{}, ttl time.Duration) {
+	entry := cache.setWithoutEviction(key, value, ttl)
+	if entry == nil {
+		return
+	}
+	// If the cache doesn't have a maxSize/maxMemoryUsage, then there's no point
+	// checking if we need to evict an entry, so we'll just return now
+	if cache.options.MaxSize == NoMaxSize && cache.options.MaxMemoryUsage == NoMaxMemoryUsage {
+		return
+	}
+	// If there's a maxSize and the cache has more entries than the maxSize, evict
+	if cache.options.MaxSize != NoMaxSize && len(cache.entries) > cache.options.MaxSize && !cache.shouldOverflow(entry) {
+		if cache.options.EvictionBatchRatio == 0 {
+			cache.evict()
+		} else {
+			// Evict down to the target size in one pass, but never evict the entry that was just set
+			targetSize := cache.options.MaxSize - int(float64(cache.options.MaxSize)*cache.options.EvictionBatchRatio)
+			for len(cache.entries) > targetSize && cache.evictionCandidate() != entry && cache.evict() {
+			}
+		}
+	}
+	// If there's a maxMemoryUsage and the memoryUsage is above the maxMemoryUsage, evict
+	if cache.options.MaxMemoryUsage != NoMaxMemoryUsage && cache.memoryUsage > cache.options.MaxMemoryUsage {
+		for cache.memoryUsage > cache.options.MaxMemoryUsage && len(cache.entries) > 0 && !cache.shouldOverflow(entry) {
+			if !cache.evict() {
+				break
+			}
+		}
+	}
+}
+
+// setWithoutEviction creates or updates a key with a given value and sets an expiration time, without evicting
+// anything, even if the cache exceeds its maxSize or its maxMemoryUsage as a result
+//
+// Returns the entry created or updated, or nil if the TTL caused the entry to not be created or to be deleted.
+// The caller is responsible for locking the cache.
+func (cache *Cache) setWithoutEviction(key string, value interface{}, ttl time.Duration) *Entry {
 	entry, ok := cache.get(key)
 	if !ok {
 		// A negative TTL that isn't -1 (NoExpiration) or 0 is an entry that will expire instantly,
 		// so might as well just not create it in the first place
 		if ttl != NoExpiration && ttl < 1 {
-			cache.mutex.Unlock()
-			return
+			return nil
 		}
 		// Cache entry doesn't exist, so we have to create a new one
 		entry = &Entry{
 			Key:               key,
 			Value:             value,
 			RelevantTimestamp: time.Now(),
+			Sequence:          cache.nextSequence(),
 			next:              cache.head,
 		}
+		entry.lastAccessed = entry.RelevantTimestamp
 		if cache.head == nil {
 			cache.tail = entry
 		} else {
@@ -248,91 +733,199 @@ func (cache *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration)
 		}
 		cache.head = entry
 		cache.entries[key] = entry
-		if cache.maxMemoryUsage != NoMaxMemoryUsage {
-			cache.memoryUsage += entry.SizeInBytes()
-		}
+		cache.addToMemoryUsage(entry)
+		cache.notifyWatchers(key, WatchOperationSet, value)
 	} else {
 		// A negative TTL that isn't -1 (NoExpiration) or 0 is an entry that will expire instantly,
 		// so might as well just delete it immediately instead of updating it
 		if ttl != NoExpiration && ttl < 1 {
 			cache.delete(key)
-			cache.mutex.Unlock()
-			return
-		}
-		if cache.maxMemoryUsage != NoMaxMemoryUsage {
-			// Subtract the old entry from the cache's memoryUsage
-			cache.memoryUsage -= entry.SizeInBytes()
+			return nil
 		}
-		// Update existing entry's value
-		entry.Value = value
-		entry.RelevantTimestamp = time.Now()
-		if cache.maxMemoryUsage != NoMaxMemoryUsage {
-			// Add the memory usage of the new entry to the cache's memoryUsage
-			cache.memoryUsage += entry.SizeInBytes()
-		}
-		// Because we just updated the entry, we need to move it back to HEAD
-		cache.moveExistingEntryToHead(entry)
+		cache.updateExistingEntryValue(entry, value)
 	}
-	if ttl != NoExpiration {
-		entry.Expiration = time.Now().Add(ttl).UnixNano()
-	} else {
-		entry.Expiration = NoExpiration
+	entry.expireIn(ttl)
+	entry.grace = 0
+	return entry
+}
+
+// SetAll creates or updates multiple values
+//
+// All entries are set at once, with the default TTL of the cache (see WithDefaultTTL), and entries are only evicted
+// once all of them have been set. See SetAllWithTTLs for more details.
+func (cache *Cache) SetAll(entries map[string]interface{}) {
+	cache.SetAllWithTTL(entries, cache.options.DefaultTTL)
+}
+
+// SetAllWithTTL creates or updates multiple values, all with the same expiration time
+//
+// All entries are set at once, and entries are only evicted once all of them have been set. See SetAllWithTTLs for
+// more details.
+func (cache *Cache) SetAllWithTTL(entries map[string]interface{}, ttl time.Duration) {
+	if cache.onFull != nil && cache.IsFull() {
+		cache.onFull(cache)
 	}
-	// If the cache doesn't have a maxSize/maxMemoryUsage, then there's no point
-	// checking if we need to evict an entry, so we'll just return now
-	if cache.maxSize == NoMaxSize && cache.maxMemoryUsage == NoMaxMemoryUsage {
-		cache.mutex.Unlock()
-		return
+	cache.mutex.Lock()
+	for key, value := range entries {
+		cache.setWithoutEviction(key, cache.forceNilIfNilPointer(value), ttl)
 	}
-	// If there's a maxSize and the cache has more entries than the maxSize, evict
-	if cache.maxSize != NoMaxSize && len(cache.entries) > cache.maxSize {
-		cache.evict()
+	cache.evictUntilWithinLimits(nil)
+	cache.unlockAndCallOnEvict()
+}
+
+// ValueWithTTL is a value along with its TTL, as used by SetAllWithTTLs
+type ValueWithTTL struct {
+	Value interface{}
+	TTL   time.Duration
+}
+
+// SetAllWithTTLs creates or updates multiple values, each with its own expiration time
+//
+// All entries are set at once, and entries are only evicted once all of them have been set, meaning that if there
+// are more entries than the cache can hold, some of the entries passed as parameter will be evicted as well.
+// If the cache is full, the function configured through WithOnFull is called once, before any entry is set.
+// The same rules as SetWithTTL apply to each TTL.
+func (cache *Cache) SetAllWithTTLs(entries map[string]ValueWithTTL) {
+	if cache.onFull != nil && cache.IsFull() {
+		cache.onFull(cache)
 	}
-	// If there's a maxMemoryUsage and the memoryUsage is above the maxMemoryUsage, evict
-	if cache.maxMemoryUsage != NoMaxMemoryUsage && cache.memoryUsage > cache.maxMemoryUsage {
-		for cache.memoryUsage > cache.maxMemoryUsage && len(cache.entries) > 0 {
-			cache.evict()
-		}
+	cache.mutex.Lock()
+	for key, valueWithTTL := range entries {
+		cache.setWithoutEviction(key, cache.forceNilIfNilPointer(valueWithTTL.Value), valueWithTTL.TTL)
 	}
-	cache.mutex.Unlock()
+	cache.evictUntilWithinLimits(nil)
+	cache.unlockAndCallOnEvict()
 }
 
-// SetAll creates or updates multiple values
-func (cache *Cache) SetAll(entries map[string]interface{}) {
+// SetAllIfAbsent creates multiple entries, but only if none of the keys already exist
+//
+// Checking whether the keys exist and setting the entries is done as a single operation, meaning that either all
+// entries are set or none of them are. Like SetAllWithTTLs, entries are only evicted once all of them have been set.
+//
+// Returns true if the entries were set, and false if at least one of the keys already existed.
+func (cache *Cache) SetAllIfAbsent(entries map[string]interface{}) bool {
+	if cache.onFull != nil && cache.IsFull() {
+		cache.onFull(cache)
+	}
+	cache.mutex.Lock()
+	defer cache.unlockAndCallOnEvict()
+	for key := range entries {
+		if entry, ok := cache.get(key); ok {
+			if !entry.Expired() {
+				return false
+			}
+			cache.deleteExpired(key)
+		}
+	}
 	for key, value := range entries {
-		cache.SetWithTTL(key, value, NoExpiration)
+		cache.setWithoutEviction(key, cache.forceNilIfNilPointer(value), cache.options.DefaultTTL)
+	}
+	cache.evictUntilWithinLimits(nil)
+	return true
+}
+
+// evictUntilWithinLimits evicts entries until the cache no longer exceeds its maxSize and its maxMemoryUsage, or
+// until there are no entries left that can be evicted
+//
+// The protected entry, if not nil, is never evicted, which is used to prevent an entry that was just updated from
+// being evicted because of its own growth. Since an updated entry is moved to the head, it is only ever the eviction
+// candidate once every other entry has been evicted or is pinned.
+//
+// The caller is responsible for locking the cache.
+func (cache *Cache) evictUntilWithinLimits(protected *Entry) {
+	if cache.options.MaxSize != NoMaxSize {
+		for len(cache.entries) > cache.options.MaxSize && (protected == nil || cache.evictionCandidate() != protected) && cache.evict() {
+		}
+	}
+	if cache.options.MaxMemoryUsage != NoMaxMemoryUsage {
+		for cache.memoryUsage > cache.options.MaxMemoryUsage && (protected == nil || cache.evictionCandidate() != protected) && cache.evict() {
+		}
 	}
 }
 
 // Get retrieves an entry using the key passed as parameter
 // If there is no such entry, the value returned will be nil and the boolean will be false
 // If there is an entry, the value returned will be the value cached and the boolean will be true
+//
+// If a loader has been configured using WithLoader, a missing entry is loaded using the loader instead. If the loader
+// returns an error, the value returned will be nil and the boolean will be false. Use GetWithLoad to retrieve the error.
 func (cache *Cache) Get(key string) (interface{}, bool) {
+	value, ok := cache.getWithoutLoading(key)
+	if !ok && cache.loader != nil {
+		var err error
+		value, err = cache.load(key)
+		return value, err == nil
+	}
+	return value, ok
+}
+
+// GetStale is the same as Get, except that it also returns whether the value returned is stale, which is only ever
+// the case for an entry set with SetWithTTLAndGrace whose TTL has elapsed, but whose grace period hasn't
+func (cache *Cache) GetStale(key string) (value interface{}, stale bool, ok bool) {
+	value, stale, ok = cache.getStaleWithoutLoading(key)
+	if !ok && cache.loader != nil {
+		var err error
+		value, err = cache.load(key)
+		return value, false, err == nil
+	}
+	return value, stale, ok
+}
+
+// getWithoutLoading is the same as Get, except that missing entries are never loaded using the loader
+func (cache *Cache) getWithoutLoading(key string) (interface{}, bool) {
+	value, _, ok := cache.getStaleWithoutLoading(key)
+	return value, ok
+}
+
+// getStaleWithoutLoading is the same as GetStale, except that missing entries are never loaded using the loader
+//
+// Stale entries are still reloaded in the background if a loader has been configured.
+func (cache *Cache) getStaleWithoutLoading(key string) (interface{}, bool, bool) {
 	cache.mutex.Lock()
 	entry, ok := cache.get(key)
 	if !ok {
-		cache.mutex.Unlock()
 		cache.stats.Misses++
-		return nil, false
+		cache.mutex.Unlock()
+		return nil, false, false
 	}
 	if entry.Expired() {
-		cache.stats.ExpiredKeys++
-		cache.delete(key)
+		cache.deleteExpired(key)
 		cache.mutex.Unlock()
-		return nil, false
+		return nil, false, false
 	}
 	cache.stats.Hits++
-	if cache.evictionPolicy == LeastRecentlyUsed {
-		entry.Accessed()
-		if cache.head == entry {
-			cache.mutex.Unlock()
-			return entry.Value, true
-		}
-		// Because the eviction policy is LRU, we need to move the entry back to HEAD
-		cache.moveExistingEntryToHead(entry)
-	}
+	stale, grace := entry.stale(), entry.grace
+	cache.accessExistingEntry(entry)
+	value := entry.Value
 	cache.mutex.Unlock()
-	return entry.Value, true
+	if stale && cache.loader != nil {
+		cache.revalidate(key, grace)
+	}
+	if cache.options.ReturnCopies {
+		value = copyValue(value)
+	}
+	return value, stale, true
+}
+
+// Peek retrieves an entry using the key passed as parameter, without counting as accessing it
+//
+// Unlike Get, this does not update the position of the entry if the eviction policy is LeastRecentlyUsed, does not
+// extend its expiration time if sliding expiration is enabled, does not affect the hits and misses statistics and
+// never uses the loader configured with WithLoader, which makes it suitable for inspecting the cache.
+// Expired entries are reported as absent, but are not deleted.
+func (cache *Cache) Peek(key string) (interface{}, bool) {
+	cache.mutex.RLock()
+	entry, ok := cache.get(key)
+	if !ok || entry.Expired() {
+		cache.mutex.RUnlock()
+		return nil, false
+	}
+	value := entry.Value
+	cache.mutex.RUnlock()
+	if cache.options.ReturnCopies {
+		value = copyValue(value)
+	}
+	return value, true
 }
 
 // GetValue retrieves an entry using the key passed as parameter
@@ -354,6 +947,56 @@ func (cache *Cache) GetByKeys(keys []string) map[string]interface{} {
 	return entries
 }
 
+// ExistsAll checks whether multiple keys exist in the cache
+//
+// The map returned contains every key passed as parameter, with true as value if the key exists and hasn't expired,
+// and false otherwise.
+//
+// Note that unlike GetByKeys, this does not count as accessing the entries, which means that the position of the
+// entries is not updated if the eviction policy is LeastRecentlyUsed.
+func (cache *Cache) ExistsAll(keys []string) map[string]bool {
+	existingKeys := make(map[string]bool, len(keys))
+	cache.mutex.RLock()
+	for _, key := range keys {
+		entry, ok := cache.get(key)
+		existingKeys[key] = ok && !entry.Expired()
+	}
+	cache.mutex.RUnlock()
+	return existingKeys
+}
+
+// GetExisting retrieves multiple entries using the keys passed as parameter
+// Unlike GetByKeys, keys that do not exist in the cache are omitted from the map returned, which makes it possible to
+// tell apart a key that genuinely has the value nil from one that doesn't exist.
+func (cache *Cache) GetExisting(keys []string) map[string]interface{} {
+	entries := make(map[string]interface{})
+	for _, key := range keys {
+		if value, ok := cache.Get(key); ok {
+			entries[key] = value
+		}
+	}
+	return entries
+}
+
+// GetKeysThatExist returns the keys passed as parameter that exist in the cache and haven't expired, in the order in
+// which they were passed
+//
+// A key passed multiple times is returned as many times, which means that len(GetKeysThatExist(keys)) is the number
+// of existing keys counted the same way Redis' EXISTS counts them.
+//
+// Like ExistsAll, this does not count as accessing the entries.
+func (cache *Cache) GetKeysThatExist(keys []string) []string {
+	var existingKeys []string
+	cache.mutex.RLock()
+	for _, key := range keys {
+		if entry, ok := cache.get(key); ok && !entry.Expired() {
+			existingKeys = append(existingKeys, key)
+		}
+	}
+	cache.mutex.RUnlock()
+	return existingKeys
+}
+
 // GetAll retrieves all cache entries
 //
 // If the eviction policy is LeastRecentlyUsed, note that unlike Get and GetByKeys, this does not update the last access
@@ -370,13 +1013,18 @@ func (cache *Cache) GetAll() map[string]interface{} {
 	cache.mutex.Lock()
 	for key, entry := range cache.entries {
 		if entry.Expired() {
-			cache.delete(key)
+			cache.deleteWithOperation(key, WatchOperationExpire)
 			continue
 		}
 		entries[key] = entry.Value
 	}
 	cache.stats.Hits += uint64(len(entries))
 	cache.mutex.Unlock()
+	if cache.options.ReturnCopies {
+		for key, value := range entries {
+			entries[key] = copyValue(value)
+		}
+	}
 	return entries
 }
 
@@ -410,6 +1058,107 @@ func (cache *Cache) GetKeysByPattern(pattern string, limit int) []string {
 	return matchingKeys
 }
 
+// Scan iterates over the keys of the entries that have not expired and match the pattern passed as parameter,
+// returning up to count keys at a time along with the cursor to pass to the next call
+//
+// An iteration starts with a cursor of 0 and is over once the cursor returned is 0. If count is lower than 1, 10 is
+// used instead. Like GetKeysByPattern, this does not count as accessing the entries.
+//
+// The entries are visited in the order in which they would be evicted, and the cursor is the sequence number of the
+// last entry visited, so nothing needs to be kept between two calls for the iteration to be resumed. This relies on
+// the sequence numbers being greater than 0 and increasing from the tail to the head, which is why the entries read
+// from a file are renumbered (see ReadFromFile). This provides
+// the same guarantees as SCAN in Redis:
+//   - a key that exists from the beginning to the end of the iteration is always returned
+//   - a key that is created or deleted during the iteration may or may not be returned
+//   - a key that is accessed or updated during the iteration may be returned more than once, since that moves it
+//     back to the head of the cache
+func (cache *Cache) Scan(cursor uint64, pattern string, count int) ([]string, uint64) {
+	if count < 1 {
+		count = defaultScanCount
+	}
+	var keys []string
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry := cache.tail
+	if cursor != 0 {
+		if last, ok := cache.scanCursors[cursor]; ok && last.Sequence == cursor && cache.entries[last.Key] == last {
+			entry = last.previous
+		} else {
+			// The last entry visited has been moved or deleted since, so the entries that have not been visited yet,
+			// which are the ones with a greater sequence number, must be found by walking from the tail
+			for entry != nil && entry.Sequence <= cursor {
+				entry = entry.previous
+			}
+		}
+		delete(cache.scanCursors, cursor)
+	}
+	for ; entry != nil; entry = entry.previous {
+		if !entry.Expired() && MatchPattern(pattern, entry.Key) {
+			keys = append(keys, entry.Key)
+			if len(keys) >= count {
+				break
+			}
+		}
+	}
+	if entry == nil || entry.previous == nil {
+		return keys, 0
+	}
+	if cache.scanCursors == nil || len(cache.scanCursors) >= maximumNumberOfScanCursors {
+		// Forgetting the cursors of the iterations in progress doesn't break them, it only makes resuming them slower
+		cache.scanCursors = make(map[uint64]*Entry)
+	}
+	cache.scanCursors[entry.Sequence] = entry
+	return keys, entry.Sequence
+}
+
+// Keys returns the keys of all entries that have not expired, in the order in which they would be evicted, starting
+// with the tail, which is the next entry to be evicted (unless it is pinned)
+//
+// Like GetKeysByPattern, this does not count as accessing the entries.
+func (cache *Cache) Keys() []string {
+	cache.mutex.RLock()
+	keys := make([]string, 0, len(cache.entries))
+	for entry := cache.tail; entry != nil; entry = entry.previous {
+		if !entry.Expired() {
+			keys = append(keys, entry.Key)
+		}
+	}
+	cache.mutex.RUnlock()
+	return keys
+}
+
+// RandomKey returns the key of a random entry that has not expired
+//
+// The key is picked by iterating over the entries, which Go does in a randomized order, so while every key can be
+// returned, the distribution is not perfectly uniform. If there are no such entries, the boolean returned is false.
+func (cache *Cache) RandomKey() (string, bool) {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+	for key, entry := range cache.entries {
+		if !entry.Expired() {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// KeysMatching returns the keys of all entries that have not expired and that match the pattern passed as parameter,
+// in the same order as Keys
+//
+// See MatchPattern for the syntax of the pattern.
+func (cache *Cache) KeysMatching(pattern string) []string {
+	var matchingKeys []string
+	cache.mutex.RLock()
+	for entry := cache.tail; entry != nil; entry = entry.previous {
+		if !entry.Expired() && MatchPattern(pattern, entry.Key) {
+			matchingKeys = append(matchingKeys, entry.Key)
+		}
+	}
+	cache.mutex.RUnlock()
+	return matchingKeys
+}
+
 // Delete removes a key from the cache
 //
 // Returns false if the key did not exist.
@@ -420,6 +1169,30 @@ func (cache *Cache) Delete(key string) bool {
 	return ok
 }
 
+// GetAndDelete retrieves the value of an entry and deletes the entry, as a single operation
+//
+// If the entry had already expired, it is deleted as well, but is considered as not existing.
+// Returns the value the entry had and whether the key existed.
+func (cache *Cache) GetAndDelete(key string) (interface{}, bool) {
+	cache.mutex.Lock()
+	entry, ok := cache.get(key)
+	if !ok {
+		cache.stats.Misses++
+		cache.mutex.Unlock()
+		return nil, false
+	}
+	if entry.Expired() {
+		cache.stats.Misses++
+		cache.deleteExpired(key)
+		cache.mutex.Unlock()
+		return nil, false
+	}
+	cache.stats.Hits++
+	cache.delete(key)
+	cache.mutex.Unlock()
+	return entry.Value, true
+}
+
 // DeleteAll deletes multiple entries based on the keys passed as parameter
 //
 // Returns the number of keys deleted
@@ -435,6 +1208,75 @@ func (cache *Cache) DeleteAll(keys []string) int {
 	return numberOfKeysDeleted
 }
 
+// DeleteAllWithResults deletes multiple entries based on the keys passed as parameter
+//
+// The map returned contains every key passed as parameter, with true as value if the key existed and was deleted, and
+// false otherwise. Entries that had already expired but had not been removed yet are deleted as well, but are reported
+// as false, since they no longer existed from the perspective of the caller.
+func (cache *Cache) DeleteAllWithResults(keys []string) map[string]bool {
+	results := make(map[string]bool, len(keys))
+	cache.mutex.Lock()
+	for _, key := range keys {
+		entry, ok := cache.get(key)
+		if ok {
+			cache.delete(key)
+		}
+		results[key] = ok && !entry.Expired()
+	}
+	cache.mutex.Unlock()
+	return results
+}
+
+// EvictionCandidates returns the keys of the next n entries that would be evicted under the current eviction policy,
+// starting with the first one to go, without actually evicting them
+//
+// The order is the same as the one in which evictionCandidate picks the entries to evict: from the tail under
+// FirstInFirstOut and LeastRecentlyUsed, and from the entry right after the head under MostRecentlyUsed, with the
+// head last. Note that under MostRecentlyUsed, an entry created in the meantime becomes the head, which pushes the
+// former head to the front of the candidates.
+// Under Random, the entry evicted is only picked when the eviction takes place, so while every entry returned is a
+// candidate, the order has no meaning, other than the head, which was just created or updated, coming last.
+//
+// Pinned entries are skipped, since they cannot be evicted. Note that entries that have expired but have not been
+// deleted yet are included, as they would be evicted just like any other entry.
+func (cache *Cache) EvictionCandidates(n int) []string {
+	var candidates []string
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+	if cache.head == nil || n < 1 {
+		return candidates
+	}
+	switch cache.options.EvictionPolicy {
+	case MostRecentlyUsed:
+		for entry := cache.head.next; entry != nil && len(candidates) < n; entry = entry.next {
+			if !entry.pinned {
+				candidates = append(candidates, entry.Key)
+			}
+		}
+	case Random:
+		for _, entry := range cache.entries {
+			if len(candidates) == n {
+				break
+			}
+			if !entry.pinned && entry != cache.head {
+				candidates = append(candidates, entry.Key)
+			}
+		}
+	default:
+		for entry := cache.tail; entry != nil && len(candidates) < n; entry = entry.previous {
+			if !entry.pinned {
+				candidates = append(candidates, entry.Key)
+			}
+		}
+		return candidates
+	}
+	// The head is only evicted once it's the only entry left that isn't pinned
+	if len(candidates) < n && !cache.head.pinned {
+		candidates = append(candidates, cache.head.Key)
+	}
+	return candidates
+}
+
 // Count returns the total amount of entries in the cache, regardless of whether they're expired or not
 func (cache *Cache) Count() int {
 	cache.mutex.RLock()
@@ -443,9 +1285,39 @@ func (cache *Cache) Count() int {
 	return count
 }
 
+// CountLive returns the amount of entries in the cache that have not expired
+//
+// Unlike Count, this has to go through every entry, which makes it O(n).
+func (cache *Cache) CountLive() int {
+	count := 0
+	cache.mutex.RLock()
+	for _, entry := range cache.entries {
+		if !entry.Expired() {
+			count++
+		}
+	}
+	cache.mutex.RUnlock()
+	return count
+}
+
 // Clear deletes all entries from the cache
+//
+// If an event channel was configured through WithEventChannel or if InvalidationBridge is in use, every entry is
+// reported as deleted, from the tail to the head, which makes clearing the cache O(n).
 func (cache *Cache) Clear() {
 	cache.mutex.Lock()
+	if cache.eventChannel != nil || len(cache.invalidationBridges) > 0 {
+		for entry := cache.tail; entry != nil; entry = entry.previous {
+			cache.notifyWatchers(entry.Key, WatchOperationDelete, entry.Value)
+		}
+	} else {
+		// Only the watched keys need to be notified, which is much cheaper than going through every entry
+		for key := range cache.watchers {
+			if entry, ok := cache.get(key); ok {
+				cache.notifyWatchers(key, WatchOperationDelete, entry.Value)
+			}
+		}
+	}
 	cache.entries = make(map[string]*Entry)
 	cache.memoryUsage = 0
 	cache.head = nil
@@ -453,6 +1325,35 @@ func (cache *Cache) Clear() {
 	cache.mutex.Unlock()
 }
 
+// Pin prevents an existing entry from being evicted, regardless of the eviction policy
+//
+// Pinned entries can still expire and be deleted. Updating a pinned entry does not unpin it.
+// See Cache.WithRejectNewEntriesWhenFullyPinned for the behavior of the cache when it's full and every entry is pinned.
+//
+// Returns false if the key does not exist
+func (cache *Cache) Pin(key string) bool {
+	cache.mutex.Lock()
+	entry, ok := cache.get(key)
+	if ok {
+		entry.pinned = true
+	}
+	cache.mutex.Unlock()
+	return ok
+}
+
+// Unpin allows an entry previously pinned using Cache.Pin to be evicted again
+//
+// Returns false if the key does not exist
+func (cache *Cache) Unpin(key string) bool {
+	cache.mutex.Lock()
+	entry, ok := cache.get(key)
+	if ok {
+		entry.pinned = false
+	}
+	cache.mutex.Unlock()
+	return ok
+}
+
 // TTL returns the time until the cache entry specified by the key passed as parameter
 // will be deleted.
 func (cache *Cache) TTL(key string) (time.Duration, error) {
@@ -474,6 +1375,83 @@ func (cache *Cache) TTL(key string) (time.Duration, error) {
 	return timeUntilExpiration, nil
 }
 
+// IdleTime returns the time elapsed since the cache entry specified by the key passed as parameter was last set or
+// accessed, without counting as accessing it
+//
+// Unlike the RelevantTimestamp of the entry, this is tracked regardless of the eviction policy. Entries read from a
+// file are considered to have last been accessed at their RelevantTimestamp.
+func (cache *Cache) IdleTime(key string) (time.Duration, error) {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+	entry, ok := cache.get(key)
+	if !ok || entry.Expired() {
+		return 0, ErrKeyDoesNotExist
+	}
+	if entry.lastAccessed.IsZero() {
+		return time.Since(entry.RelevantTimestamp), nil
+	}
+	return time.Since(entry.lastAccessed), nil
+}
+
+// Frequency returns the number of times the cache entry specified by the key passed as parameter was accessed through
+// Get-like functions since it was created, without counting as accessing it
+//
+// Updating an entry does not count as accessing it, nor does it reset the number of accesses.
+func (cache *Cache) Frequency(key string) (uint64, error) {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+	entry, ok := cache.get(key)
+	if !ok || entry.Expired() {
+		return 0, ErrKeyDoesNotExist
+	}
+	return entry.accesses, nil
+}
+
+// TTLDistribution returns the number of entries whose time until expiration falls into each of the buckets passed
+// as parameter
+//
+// Each bucket is an upper bound, meaning that an entry is counted in the smallest bucket that is greater than or equal
+// to its TTL. Entries with no expiration are counted under NoExpiration, while entries whose TTL is greater than the
+// largest bucket are not counted at all. Expired entries that have not been deleted yet are ignored.
+//
+// e.g.
+//     cache.TTLDistribution([]time.Duration{time.Minute, time.Hour}) will return the number of entries expiring
+//     within a minute, the number of entries expiring between a minute and an hour from now, and the number of
+//     entries that never expire
+func (cache *Cache) TTLDistribution(buckets []time.Duration) map[time.Duration]int {
+	sortedBuckets := make([]time.Duration, len(buckets))
+	copy(sortedBuckets, buckets)
+	sort.Slice(sortedBuckets, func(i, j int) bool {
+		return sortedBuckets[i] < sortedBuckets[j]
+	})
+	distribution := make(map[time.Duration]int, len(buckets)+1)
+	for _, bucket := range sortedBuckets {
+		distribution[bucket] = 0
+	}
+	distribution[NoExpiration] = 0
+	now := time.Now().UnixNano()
+	cache.mutex.RLock()
+	for _, entry := range cache.entries {
+		if entry.Expiration == NoExpiration {
+			distribution[NoExpiration]++
+			continue
+		}
+		ttl := time.Duration(entry.Expiration - now)
+		if ttl < 0 {
+			continue
+		}
+		// Find the smallest bucket that is greater than or equal to the TTL of the entry
+		index := sort.Search(len(sortedBuckets), func(i int) bool {
+			return sortedBuckets[i] >= ttl
+		})
+		if index < len(sortedBuckets) {
+			distribution[sortedBuckets[index]]++
+		}
+	}
+	cache.mutex.RUnlock()
+	return distribution
+}
+
 // Expire sets a key's expiration time
 //
 // A TTL of -1 means that the key will never expire
@@ -482,18 +1460,208 @@ func (cache *Cache) TTL(key string) (time.Duration, error) {
 //
 // Returns true if the cache key exists and has had its expiration time altered
 func (cache *Cache) Expire(key string, ttl time.Duration) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
 	entry, ok := cache.get(key)
 	if !ok || entry.Expired() {
 		return false
 	}
-	if ttl != NoExpiration {
-		entry.Expiration = time.Now().Add(ttl).UnixNano()
-	} else {
-		entry.Expiration = NoExpiration
+	entry.expireIn(ttl)
+	return true
+}
+
+// ExpireAt sets the time at which a key expires
+//
+// A time in the past means that the key will expire immediately
+// If using LRU, note that this does not reset the position of the key
+//
+// Returns true if the cache key exists and has had its expiration time altered
+func (cache *Cache) ExpireAt(key string, t time.Time) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, ok := cache.get(key)
+	if !ok || entry.Expired() {
+		return false
+	}
+	entry.Expiration = t.UnixNano()
+	// The TTL is only used for sliding expiration, so there's no point keeping a TTL that has already elapsed
+	entry.TTL = 0
+	if ttl := time.Until(t); ttl > 0 {
+		entry.TTL = ttl
+	}
+	return true
+}
+
+// Persist removes the expiration time of a key, meaning that the key will no longer expire
+//
+// Unlike Expire with NoExpiration as TTL, this reports whether anything changed: it returns true only if the key
+// exists and had an expiration time, and false if the key doesn't exist or already had no expiration time.
+func (cache *Cache) Persist(key string) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, ok := cache.get(key)
+	if !ok || entry.Expired() || entry.Expiration == NoExpiration {
+		return false
+	}
+	entry.Expiration = NoExpiration
+	entry.TTL = 0
+	return true
+}
+
+// Touch marks multiple entries as accessed without retrieving their values
+//
+// If using LRU, each entry that exists is moved back to the head, exactly as if it had been retrieved. If using FIFO,
+// the position of the entries is left untouched. Expired entries are deleted and aren't counted.
+//
+// Returns the number of keys that existed
+func (cache *Cache) Touch(keys []string) int {
+	numberOfKeysTouched := 0
+	cache.mutex.Lock()
+	for _, key := range keys {
+		entry, ok := cache.get(key)
+		if !ok {
+			continue
+		}
+		if entry.Expired() {
+			cache.deleteExpired(key)
+			continue
+		}
+		cache.accessExistingEntry(entry)
+		numberOfKeysTouched++
+	}
+	cache.mutex.Unlock()
+	return numberOfKeysTouched
+}
+
+// RenameNX renames a key, but only if the new key doesn't already exist
+//
+// The entry keeps its value, its expiration time and its position in the cache, meaning that renaming an entry
+// does not count as accessing or updating it.
+//
+// Returns true if the key was renamed, false if the new key already exists, and ErrKeyDoesNotExist if the old key
+// doesn't exist.
+func (cache *Cache) RenameNX(oldKey, newKey string) (bool, error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, ok := cache.get(oldKey)
+	if !ok || entry.Expired() {
+		return false, ErrKeyDoesNotExist
+	}
+	if destination, exists := cache.get(newKey); exists {
+		if !destination.Expired() {
+			return false, nil
+		}
+		// The new key has already expired but hasn't been deleted yet, so we can just get rid of it
+		cache.deleteExpired(newKey)
+	}
+	cache.rename(entry, newKey)
+	return true, nil
+}
+
+// Rename renames a key, replacing the new key if it already exists
+//
+// Like RenameNX, the entry keeps its value, its expiration time and its position in the cache, meaning that renaming
+// an entry does not count as accessing or updating it.
+//
+// Returns ErrKeyDoesNotExist if the old key doesn't exist.
+func (cache *Cache) Rename(oldKey, newKey string) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, ok := cache.get(oldKey)
+	if !ok || entry.Expired() {
+		return ErrKeyDoesNotExist
+	}
+	if oldKey == newKey {
+		return nil
+	}
+	if destination, exists := cache.get(newKey); exists {
+		if destination.Expired() {
+			cache.deleteExpired(newKey)
+		} else {
+			cache.delete(newKey)
+		}
+	}
+	cache.rename(entry, newKey)
+	return nil
+}
+
+// Copy copies the value and the expiration time of an entry to another key
+//
+// Unlike Rename, the copy is inserted as a new entry, meaning that it is the most recent entry in the cache as far
+// as eviction is concerned, while the source entry is left untouched. Note that the value itself is not cloned, so
+// if it's a pointer, a map or a slice, both entries will reference the same underlying data.
+//
+// Returns false if the source key doesn't exist, if the source and destination keys are the same, or if the
+// destination key already exists and replace is false.
+func (cache *Cache) Copy(source, destination string, replace bool) bool {
+	if source == destination {
+		return false
+	}
+	cache.prepareSet(destination, nil)
+	cache.mutex.Lock()
+	defer cache.unlockAndCallOnEvict()
+	sourceEntry, ok := cache.get(source)
+	if !ok || sourceEntry.Expired() {
+		return false
+	}
+	if destinationEntry, exists := cache.get(destination); exists {
+		if destinationEntry.Expired() {
+			cache.deleteExpired(destination)
+		} else if !replace {
+			return false
+		} else {
+			cache.delete(destination)
+		}
+	}
+	ttl := time.Duration(NoExpiration)
+	if sourceEntry.Expiration != NoExpiration {
+		ttl = time.Until(time.Unix(0, sourceEntry.Expiration))
 	}
+	cache.set(destination, sourceEntry.Value, ttl)
+	entry, ok := cache.get(destination)
+	if !ok {
+		// The source entry expired in the meantime
+		return false
+	}
+	// The exact expiration time and TTL of the source are preserved, rather than the remaining duration used above
+	entry.Expiration, entry.TTL, entry.grace = sourceEntry.Expiration, sourceEntry.TTL, sourceEntry.grace
 	return true
 }
 
+// rename changes the key of an existing entry without changing its position, assuming that there is no entry for
+// the new key
+//
+// The caller is responsible for locking the cache.
+func (cache *Cache) rename(entry *Entry, newKey string) {
+	oldKey := entry.Key
+	// The size of the entry includes its key, so it has to be computed again
+	cache.removeFromMemoryUsage(entry)
+	delete(cache.entries, oldKey)
+	entry.Key = newKey
+	cache.entries[newKey] = entry
+	cache.addToMemoryUsage(entry)
+	cache.notifyWatchers(oldKey, WatchOperationDelete, entry.Value)
+	cache.notifyWatchers(newKey, WatchOperationSet, entry.Value)
+}
+
+// isFullAndMissing returns whether the cache has reached its maxSize and the key passed as parameter isn't in the cache,
+// in other words, whether creating an entry for said key would cause the cache to exceed its maxSize
+func (cache *Cache) isFullAndMissing(key string) bool {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+	if cache.options.MaxSize == NoMaxSize || len(cache.entries) < cache.options.MaxSize {
+		return false
+	}
+	_, ok := cache.get(key)
+	return !ok
+}
+
+// nextSequence returns the sequence number to assign to an entry whose RelevantTimestamp is being updated
+func (cache *Cache) nextSequence() uint64 {
+	cache.sequence++
+	return cache.sequence
+}
+
 // get retrieves an entry using the key passed as parameter, but unlike Get, it doesn't update the access time or
 // move the position of the entry to the head
 func (cache *Cache) get(key string) (*Entry, bool) {
@@ -502,17 +1670,80 @@ func (cache *Cache) get(key string) (*Entry, bool) {
 }
 
 func (cache *Cache) delete(key string) bool {
+	return cache.deleteWithOperation(key, WatchOperationDelete)
+}
+
+// deleteExpired deletes an entry that has expired and updates the statistics accordingly
+func (cache *Cache) deleteExpired(key string) {
+	cache.stats.ExpiredKeys++
+	cache.deleteWithOperation(key, WatchOperationExpire)
+}
+
+// deleteWithOperation deletes an entry and notifies the functions watching its key using the operation passed as
+// parameter, which is the reason for the deletion
+func (cache *Cache) deleteWithOperation(key, op string) bool {
 	entry, ok := cache.entries[key]
 	if ok {
-		if cache.maxMemoryUsage != NoMaxMemoryUsage {
-			cache.memoryUsage -= entry.SizeInBytes()
-		}
+		cache.removeFromMemoryUsage(entry)
 		cache.removeExistingEntryReferences(entry)
 		delete(cache.entries, key)
+		cache.notifyWatchers(key, op, entry.Value)
 	}
 	return ok
 }
 
+// updateExistingEntryValue replaces the value of an existing entry, updates the memory usage of the cache accordingly
+// and moves the entry back to the head, since updating an entry resets its position regardless of the eviction policy
+func (cache *Cache) updateExistingEntryValue(entry *Entry, value interface{}) {
+	cache.removeFromMemoryUsage(entry)
+	entry.Value = value
+	entry.RelevantTimestamp = time.Now()
+	entry.lastAccessed = entry.RelevantTimestamp
+	entry.Sequence = cache.nextSequence()
+	cache.addToMemoryUsage(entry)
+	cache.moveExistingEntryToHead(entry)
+	cache.notifyWatchers(entry.Key, WatchOperationSet, value)
+}
+
+// addToMemoryUsage computes the size of an entry and adds it to the memory usage of the cache, if the cache has a
+// maxMemoryUsage
+//
+// The size is computed only once and stored on the entry, so that the exact same size is subtracted once the entry is
+// removed, even if its value was mutated in the meantime.
+func (cache *Cache) addToMemoryUsage(entry *Entry) {
+	if cache.options.MaxMemoryUsage != NoMaxMemoryUsage {
+		entry.sizeInBytes = entry.SizeInBytes()
+		cache.memoryUsage += entry.sizeInBytes
+	}
+}
+
+// removeFromMemoryUsage subtracts the size computed by addToMemoryUsage from the memory usage of the cache
+func (cache *Cache) removeFromMemoryUsage(entry *Entry) {
+	cache.memoryUsage -= entry.sizeInBytes
+	entry.sizeInBytes = 0
+}
+
+// accessExistingEntry updates an existing entry that has just been retrieved, which, if the eviction policy is
+// LeastRecentlyUsed or MostRecentlyUsed, means moving it back to the head, and if the cache uses sliding expiration,
+// means extending its expiration time
+//
+// The caller is responsible for locking the cache and for making sure that the entry hasn't expired.
+func (cache *Cache) accessExistingEntry(entry *Entry) {
+	entry.lastAccessed = time.Now()
+	entry.accesses++
+	if cache.options.SlidingExpiration && entry.Expiration != NoExpiration && entry.TTL > 0 {
+		entry.Expiration = time.Now().Add(entry.TTL).UnixNano()
+	}
+	if cache.options.EvictionPolicy == LeastRecentlyUsed || cache.options.EvictionPolicy == MostRecentlyUsed {
+		entry.Accessed()
+		entry.Sequence = cache.nextSequence()
+		// Because the eviction policy is based on recency, we need to move the entry back to HEAD
+		if cache.head != entry {
+			cache.moveExistingEntryToHead(entry)
+		}
+	}
+}
+
 // moveExistingEntryToHead replaces the current cache head for an existing entry
 func (cache *Cache) moveExistingEntryToHead(entry *Entry) {
 	if !(entry == cache.head && entry == cache.tail) {
@@ -550,18 +1781,88 @@ func (cache *Cache) removeExistingEntryReferences(entry *Entry) {
 	entry.previous = nil
 }
 
-// evict removes the tail from the cache
-func (cache *Cache) evict() {
+// evictionCandidate returns the entry closest to the tail that isn't pinned, or nil if there is no such entry
+//
+// If the eviction policy is MostRecentlyUsed, the entry closest to the head that isn't pinned is returned instead, and
+// if the eviction policy is Random, a random entry that isn't pinned is returned instead. Either way, the head, which
+// is the entry that was just created or updated, is only returned if it is the only entry that isn't pinned.
+func (cache *Cache) evictionCandidate() *Entry {
+	if cache.options.EvictionPolicy == MostRecentlyUsed && cache.head != nil {
+		candidate := cache.head.next
+		for candidate != nil && candidate.pinned {
+			candidate = candidate.next
+		}
+		if candidate == nil && !cache.head.pinned {
+			return cache.head
+		}
+		return candidate
+	}
+	if cache.options.EvictionPolicy == Random {
+		for _, entry := range cache.entries {
+			if !entry.pinned && entry != cache.head {
+				return entry
+			}
+		}
+		if cache.head != nil && !cache.head.pinned {
+			return cache.head
+		}
+		return nil
+	}
+	candidate := cache.tail
+	for candidate != nil && candidate.pinned {
+		candidate = candidate.previous
+	}
+	return candidate
+}
+
+// shouldOverflow returns whether the cache should be allowed to grow beyond its maximum size rather than evicting
+// the entry passed as parameter, which only happens if every other entry is pinned and new entries aren't rejected
+func (cache *Cache) shouldOverflow(entry *Entry) bool {
+	return !cache.options.RejectNewEntriesWhenFullyPinned && len(cache.entries) > 1 && cache.evictionCandidate() == entry
+}
+
+// evict removes the tail from the cache, or the entry closest to the tail if the tail is pinned
+// (see evictionCandidate)
+//
+// Returns false if there was nothing to evict
+func (cache *Cache) evict() bool {
 	if cache.tail == nil || len(cache.entries) == 0 {
-		return
+		return false
+	}
+	candidate := cache.evictionCandidate()
+	if candidate == nil {
+		return false
+	}
+	cache.removeExistingEntryReferences(candidate)
+	delete(cache.entries, candidate.Key)
+	cache.removeFromMemoryUsage(candidate)
+	cache.stats.EvictedKeys++
+	cache.notifyWatchers(candidate.Key, WatchOperationEvict, candidate.Value)
+	if cache.onEvict != nil {
+		cache.evictedEntries = append(cache.evictedEntries, candidate)
 	}
-	if cache.tail != nil {
-		oldTail := cache.tail
-		cache.removeExistingEntryReferences(oldTail)
-		delete(cache.entries, oldTail.Key)
-		if cache.maxMemoryUsage != NoMaxMemoryUsage {
-			cache.memoryUsage -= oldTail.SizeInBytes()
+	return true
+}
+
+// unlockAndCallOnEvict unlocks the cache and then calls the function configured through WithOnEvict for each entry
+// evicted while the cache was locked, or queues them if WithAsyncOnEvict is enabled
+//
+// This must be used instead of unlocking the cache directly whenever entries may have been evicted.
+func (cache *Cache) unlockAndCallOnEvict() {
+	evictedEntries := cache.evictedEntries
+	cache.evictedEntries = nil
+	if cache.onEvictSignal != nil && len(evictedEntries) > 0 {
+		cache.onEvictQueue = append(cache.onEvictQueue, evictedEntries...)
+		select {
+		case cache.onEvictSignal <- true:
+		default:
+			// The worker has already been signaled and will pick up these entries as well
 		}
-		cache.stats.EvictedKeys++
+		cache.mutex.Unlock()
+		return
+	}
+	cache.mutex.Unlock()
+	for _, entry := range evictedEntries {
+		cache.onEvict(entry.Key, entry.Value)
 	}
 }
@@ -3,6 +3,8 @@ package gocache
 import (
 	"fmt"
 	"math/rand"
+	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
@@ -105,6 +107,43 @@ func BenchmarkCache_SetWithMaxSize(b *testing.B) {
 	}
 }
 
+func BenchmarkCache_SetWithMaxSizeAndEvictionBatchRatio(b *testing.B) {
+	for _, ratio := range []float64{0, 0.01, 0.1} {
+		b.Run(fmt.Sprintf("%.2f ratio", ratio), func(b *testing.B) {
+			cache := NewCache().WithMaxSize(10000).WithEvictionBatchRatio(ratio)
+			numberOfEvictionPasses, previousCount := 0, 0
+			for n := 0; n < b.N; n++ {
+				cache.Set(strconv.Itoa(n), "value")
+				// If the number of entries didn't grow, at least one entry had to be evicted
+				count := cache.Count()
+				if count <= previousCount {
+					numberOfEvictionPasses++
+				}
+				previousCount = count
+			}
+			b.ReportMetric(float64(numberOfEvictionPasses)/float64(b.N), "evictions/op")
+			b.ReportAllocs()
+		})
+	}
+}
+
+func BenchmarkCache_SetAllWithInitialCapacity(b *testing.B) {
+	const NumberOfEntries = 100000
+	entries := make(map[string]interface{}, NumberOfEntries)
+	for i := 0; i < NumberOfEntries; i++ {
+		entries[strconv.Itoa(i)] = "value"
+	}
+	for _, initialCapacity := range []int{0, NumberOfEntries} {
+		b.Run(fmt.Sprintf("%d initial capacity", initialCapacity), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				cache := NewCache().WithMaxSize(NoMaxSize).WithInitialCapacity(initialCapacity)
+				cache.SetAll(entries)
+			}
+			b.ReportAllocs()
+		})
+	}
+}
+
 func BenchmarkCache_SetWithMaxSizeAndLRU(b *testing.B) {
 	values := map[string]string{
 		"small":  "a",
@@ -249,3 +288,56 @@ func BenchmarkCache_WithForceNilInterfaceOnNilPointerWithConcurrency(b *testing.
 		}
 	}
 }
+
+func BenchmarkCache_SaveToFile(b *testing.B) {
+	cache := NewCache().WithMaxSize(NoMaxSize)
+	for i := 0; i < 10000; i++ {
+		cache.Set(fmt.Sprintf("user:%d", i), fmt.Sprintf(`{"id":%d,"name":"user-%d","email":"user-%d@example.com","active":%t}`, i, i, i, i%2 == 0))
+	}
+	for _, compression := range []bool{false, true} {
+		b.Run(fmt.Sprintf("compression=%t", compression), func(b *testing.B) {
+			file := b.TempDir() + "/" + TestCacheFile
+			cache.WithPersistenceCompression(compression)
+			for n := 0; n < b.N; n++ {
+				if err := cache.SaveToFile(file); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if fileInfo, err := os.Stat(file); err == nil {
+				b.ReportMetric(float64(fileInfo.Size()), "bytes/file")
+			}
+			b.ReportAllocs()
+		})
+	}
+}
+
+func BenchmarkShardedCache_GetSetConcurrently(b *testing.B) {
+	value := strings.Repeat("a", 256)
+	options := DefaultOptions()
+	options.EvictionPolicy = LeastRecentlyUsed
+	caches := map[string]interface {
+		Set(key string, value interface{})
+		Get(key string) (interface{}, bool)
+	}{
+		"cache":              NewCacheWithOptions(options),
+		"sharded cache (16)": NewShardedCache(16, options),
+	}
+	for name, cache := range caches {
+		for _, numberOfGoroutines := range []int{8, 32, 128} {
+			b.Run(fmt.Sprintf("%s with %d goroutines", name, numberOfGoroutines), func(b *testing.B) {
+				// RunParallel starts parallelism*GOMAXPROCS goroutines
+				b.SetParallelism(divideRoundingUp(numberOfGoroutines, runtime.GOMAXPROCS(0)))
+				b.RunParallel(func(pb *testing.PB) {
+					// The global source of math/rand is locked, so each goroutine uses its own to avoid skewing the results
+					r := rand.New(rand.NewSource(rand.Int63()))
+					for pb.Next() {
+						key := strconv.Itoa(r.Intn(10000))
+						cache.Set(key, value)
+						_, _ = cache.Get(key)
+					}
+				})
+				b.ReportAllocs()
+			})
+		}
+	}
+}
@@ -3,7 +3,12 @@ package gocache
 import (
 	"bytes"
 	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -53,6 +58,21 @@ func TestCache_Stats(t *testing.T) {
 	}
 }
 
+func TestCache_ResetStatistics(t *testing.T) {
+	cache := NewCache().WithMaxSize(1)
+	cache.Set("key", "value")
+	cache.Set("key2", "value")
+	cache.Get("key2")
+	cache.Get("key-that-does-not-exist")
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.EvictedKeys != 1 {
+		t.Errorf("expected 1 hit, 1 miss and 1 evicted key, got %+v", stats)
+	}
+	cache.ResetStatistics()
+	if stats := cache.Stats(); stats != (Statistics{}) {
+		t.Errorf("expected all statistics to have been reset, got %+v", stats)
+	}
+}
+
 func TestCache_Get(t *testing.T) {
 	cache := NewCache().WithMaxSize(10)
 	cache.Set("key", "value")
@@ -93,6 +113,57 @@ func TestCache_GetValue(t *testing.T) {
 	}
 }
 
+func TestCache_Peek(t *testing.T) {
+	cache := NewCache().WithMaxSize(10).WithEvictionPolicy(LeastRecentlyUsed)
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.SetWithTTL("key3", "value3", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if value, ok := cache.Peek("key1"); !ok || value != "value1" {
+		t.Errorf("expected value1, got %v", value)
+	}
+	if cache.head.Key != "key3" {
+		t.Errorf("expected head to still be key3, but was %s", cache.head.Key)
+	}
+	if _, ok := cache.Peek("key3"); ok {
+		t.Error("expected key3 to be reported as absent, because it has expired")
+	}
+	if _, ok := cache.Peek("key4"); ok {
+		t.Error("expected key4 to not exist")
+	}
+	if cache.Stats().Hits != 0 || cache.Stats().Misses != 0 {
+		t.Error("expected Peek to not have affected the statistics")
+	}
+}
+
+func TestCache_IdleTimeAndFrequency(t *testing.T) {
+	cache := NewCache().WithEvictionPolicy(FirstInFirstOut)
+	cache.Set("key", "value")
+	time.Sleep(10 * time.Millisecond)
+	if idleTime, err := cache.IdleTime("key"); err != nil || idleTime < 10*time.Millisecond {
+		t.Errorf("expected an idle time of at least 10ms, got %v and %v", idleTime, err)
+	}
+	cache.Get("key")
+	cache.Get("key")
+	if idleTime, err := cache.IdleTime("key"); err != nil || idleTime >= 10*time.Millisecond {
+		t.Errorf("expected the idle time to have been reset by accessing the key, got %v and %v", idleTime, err)
+	}
+	if frequency, err := cache.Frequency("key"); err != nil || frequency != 2 {
+		t.Errorf("expected a frequency of 2, got %d and %v", frequency, err)
+	}
+	// Neither IdleTime, Frequency nor Peek should count as accessing the key
+	cache.Peek("key")
+	if frequency, _ := cache.Frequency("key"); frequency != 2 {
+		t.Error("expected the frequency to still be 2, got", frequency)
+	}
+	if _, err := cache.IdleTime("key-that-does-not-exist"); err != ErrKeyDoesNotExist {
+		t.Error("expected ErrKeyDoesNotExist, got", err)
+	}
+	if _, err := cache.Frequency("key-that-does-not-exist"); err != ErrKeyDoesNotExist {
+		t.Error("expected ErrKeyDoesNotExist, got", err)
+	}
+}
+
 func TestCache_GetByKeys(t *testing.T) {
 	cache := NewCache().WithMaxSize(10)
 	cache.Set("key1", "value1")
@@ -112,6 +183,80 @@ func TestCache_GetByKeys(t *testing.T) {
 	}
 }
 
+func TestCache_ExistsAll(t *testing.T) {
+	cache := NewCache().WithMaxSize(10).WithEvictionPolicy(LeastRecentlyUsed)
+	cache.Set("key1", "value1")
+	cache.Set("key2", nil)
+	cache.SetWithTTL("key3", "value3", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	existingKeys := cache.ExistsAll([]string{"key1", "key2", "key3", "key4"})
+	if len(existingKeys) != 4 {
+		t.Error("expected length of map to be 4")
+	}
+	if !existingKeys["key1"] {
+		t.Error("expected key1 to exist")
+	}
+	if !existingKeys["key2"] {
+		t.Error("expected key2 to exist, even though its value is nil")
+	}
+	if existingKeys["key3"] {
+		t.Error("expected key3 to not exist, because it has expired")
+	}
+	if existingKeys["key4"] {
+		t.Error("expected key4 to not exist")
+	}
+	// ExistsAll should not count as accessing the entries
+	if cache.head.Key != "key3" {
+		t.Errorf("expected head to still be key3, but was %s", cache.head.Key)
+	}
+}
+
+func TestCache_GetExisting(t *testing.T) {
+	cache := NewCache().WithMaxSize(10)
+	cache.Set("key1", "value1")
+	cache.Set("key2", nil)
+	cache.SetWithTTL("key3", "value3", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	keyValues := cache.GetExisting([]string{"key1", "key2", "key3", "key4"})
+	if len(keyValues) != 2 {
+		t.Error("expected length of map to be 2, got", len(keyValues))
+	}
+	if keyValues["key1"] != "value1" {
+		t.Errorf("expected: %s, but got: %s", "value1", keyValues["key1"])
+	}
+	if value, ok := keyValues["key2"]; !ok || value != nil {
+		t.Errorf("expected key2 to exist and be nil, but got: %s", value)
+	}
+	if _, ok := keyValues["key3"]; ok {
+		t.Error("expected key3 to be omitted, because it has expired")
+	}
+	if _, ok := keyValues["key4"]; ok {
+		t.Error("expected key4 to be omitted")
+	}
+	if cache.Stats().Hits != 2 {
+		t.Error("expected 2 hits, got", cache.Stats().Hits)
+	}
+}
+
+func TestCache_GetKeysThatExist(t *testing.T) {
+	cache := NewCache().WithMaxSize(10).WithEvictionPolicy(LeastRecentlyUsed)
+	cache.Set("key1", "value1")
+	cache.Set("key2", nil)
+	cache.SetWithTTL("key3", "value3", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	existingKeys := cache.GetKeysThatExist([]string{"key4", "key2", "key3", "key1", "key2"})
+	if len(existingKeys) != 3 || existingKeys[0] != "key2" || existingKeys[1] != "key1" || existingKeys[2] != "key2" {
+		t.Error("expected [key2 key1 key2], got", existingKeys)
+	}
+	if len(cache.GetKeysThatExist([]string{"key3", "key4"})) != 0 {
+		t.Error("expected no keys to exist")
+	}
+	// GetKeysThatExist should not count as accessing the entries
+	if cache.head.Key != "key3" {
+		t.Errorf("expected head to still be key3, but was %s", cache.head.Key)
+	}
+}
+
 func TestCache_GetAll(t *testing.T) {
 	cache := NewCache().WithMaxSize(10)
 	cache.Set("key1", "value1")
@@ -192,6 +337,127 @@ func TestCache_GetKeysByPatternWithExpiredKey(t *testing.T) {
 	}
 }
 
+func TestCache_Keys(t *testing.T) {
+	cache := NewCache().WithEvictionPolicy(LeastRecentlyUsed)
+	cache.Set("1", "one")
+	cache.Set("2", "two")
+	cache.Set("3", "three")
+	cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	cache.Get("1")
+	// (head) 1 - expired - 3 - 2 (tail)
+	if keys := cache.Keys(); !reflect.DeepEqual(keys, []string{"2", "3", "1"}) {
+		t.Error("expected the keys to be returned from the tail to the head without the expired key, got", keys)
+	}
+	if keys := NewCache().Keys(); len(keys) != 0 {
+		t.Error("expected no keys, got", keys)
+	}
+}
+
+func TestCache_RandomKey(t *testing.T) {
+	cache := NewCache()
+	if _, ok := cache.RandomKey(); ok {
+		t.Error("expected no key to be returned, since the cache is empty")
+	}
+	cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := cache.RandomKey(); ok {
+		t.Error("expected no key to be returned, since the only entry has expired")
+	}
+	cache.Set("1", "one")
+	cache.Set("2", "two")
+	returnedKeys := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		key, ok := cache.RandomKey()
+		if !ok || key == "expired" {
+			t.Fatal("expected a key that hasn't expired to be returned, got", key)
+		}
+		returnedKeys[key] = true
+	}
+	if len(returnedKeys) != 2 {
+		t.Error("expected both keys to have been returned at least once, got", returnedKeys)
+	}
+}
+
+func TestCache_KeysMatching(t *testing.T) {
+	cache := NewCache()
+	cache.Set("user:1", "john")
+	cache.Set("session:1", "abc")
+	cache.Set("user:2", "jane")
+	if keys := cache.KeysMatching("user:*"); !reflect.DeepEqual(keys, []string{"user:1", "user:2"}) {
+		t.Error("expected user:1 and user:2 in eviction order, got", keys)
+	}
+	if keys := cache.KeysMatching("nothing*"); len(keys) != 0 {
+		t.Error("expected no keys, got", keys)
+	}
+}
+
+func TestCache_WithDefaultTTL(t *testing.T) {
+	cache := NewCache().WithDefaultTTL(time.Hour)
+	cache.Set("set", "value")
+	cache.SetAll(map[string]interface{}{"set-all": "value"})
+	cache.GetSet("get-set", "value")
+	cache.GetOrSet("get-or-set", "value")
+	cache.SetIfAbsent("set-if-absent", "value")
+	cache.Increment("increment", 1)
+	cache.Append("append", "value")
+	for _, key := range []string{"set", "set-all", "get-set", "get-or-set", "set-if-absent", "increment", "append"} {
+		if ttl, err := cache.TTL(key); err != nil || ttl <= 59*time.Minute || ttl > time.Hour {
+			t.Errorf("[%s] expected the default TTL to have been applied, got %s and %v", key, ttl, err)
+		}
+	}
+	cache.SetWithTTL("explicit-ttl", "value", time.Minute)
+	if ttl, _ := cache.TTL("explicit-ttl"); ttl > time.Minute {
+		t.Error("expected the explicit TTL to take precedence over the default TTL, got", ttl)
+	}
+	cache.SetWithTTL("explicit-no-expiration", "value", NoExpiration)
+	if _, err := cache.TTL("explicit-no-expiration"); err != ErrKeyHasNoExpiration {
+		t.Error("expected an explicit NoExpiration to take precedence over the default TTL")
+	}
+	cache.WithDefaultTTL(0)
+	cache.Set("no-default-ttl", "value")
+	if _, err := cache.TTL("no-default-ttl"); err != ErrKeyHasNoExpiration {
+		t.Error("expected a default TTL of 0 to mean no default TTL")
+	}
+}
+
+func TestCache_WithSlidingExpiration(t *testing.T) {
+	cache := NewCache().WithSlidingExpiration(true).WithEvictionPolicy(LeastRecentlyUsed)
+	cache.SetWithTTL("key", "value", 50*time.Millisecond)
+	cache.SetWithTTL("other-key", "value", 50*time.Millisecond)
+	cache.Set("no-expiration", "value")
+	for i := 0; i < 4; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if _, ok := cache.Get("key"); !ok {
+			t.Fatal("expected the expiration time of the key to have been extended every time it was accessed")
+		}
+	}
+	if _, ok := cache.Get("other-key"); ok {
+		t.Error("expected the key that wasn't accessed to have expired")
+	}
+	if cache.head.Key != "key" {
+		t.Error("expected the key accessed to still have been moved to the head")
+	}
+	if _, err := cache.TTL("no-expiration"); err != ErrKeyHasNoExpiration {
+		t.Error("expected key with no expiration to still have no expiration after being accessed")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := cache.Get("key"); ok {
+		t.Error("expected the key to have expired once it was no longer accessed")
+	}
+}
+
+func TestCache_WithoutSlidingExpiration(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("key", "value", time.Hour)
+	time.Sleep(time.Millisecond)
+	before, _ := cache.TTL("key")
+	cache.Get("key")
+	if after, _ := cache.TTL("key"); after > before {
+		t.Error("expected the expiration time to not have been extended, since sliding expiration is disabled")
+	}
+}
+
 func TestCache_Set(t *testing.T) {
 	cache := NewCache().WithMaxSize(NoMaxSize)
 	cache.Set("key", "value")
@@ -405,6 +671,32 @@ func TestCache_SetWithTTLWhenTTLIsZeroAndEntryAlreadyExists(t *testing.T) {
 	}
 }
 
+func TestCache_SetWithTTLAndGrace(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTLAndGrace("key", "value", 20*time.Millisecond, 40*time.Millisecond)
+	if value, stale, ok := cache.GetStale("key"); !ok || stale || value != "value" {
+		t.Errorf("expected a fresh value, got %v, %v and %v", value, stale, ok)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if value, stale, ok := cache.GetStale("key"); !ok || !stale || value != "value" {
+		t.Errorf("expected the value to be served stale within the grace period, got %v, %v and %v", value, stale, ok)
+	}
+	if value, ok := cache.Get("key"); !ok || value != "value" {
+		t.Errorf("expected Get to return the stale value as well, got %v", value)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, _, ok := cache.GetStale("key"); ok {
+		t.Error("expected the entry to have expired once the grace period has elapsed")
+	}
+	// Setting the key again without a grace period must not leave a stale window behind
+	cache.SetWithTTLAndGrace("key", "value", 10*time.Millisecond, time.Hour)
+	cache.SetWithTTL("key", "value", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := cache.Get("key"); ok {
+		t.Error("expected the grace period to have been discarded when the key was set again")
+	}
+}
+
 func TestCache_EvictionsRespectMaxSize(t *testing.T) {
 	cache := NewCache().WithMaxSize(5)
 	for n := 0; n < 10; n++ {
@@ -416,6 +708,106 @@ func TestCache_EvictionsRespectMaxSize(t *testing.T) {
 	}
 }
 
+func TestCache_EvictionsWithMaxSizeOfOne(t *testing.T) {
+	for _, evictionPolicy := range []EvictionPolicy{FirstInFirstOut, LeastRecentlyUsed, MostRecentlyUsed, Random} {
+		t.Run(string(evictionPolicy), func(t *testing.T) {
+			cache := NewCache().WithMaxSize(1).WithEvictionPolicy(evictionPolicy)
+			for n := 0; n < 10; n++ {
+				key := fmt.Sprintf("test_%d", n)
+				cache.Set(key, n)
+				if cache.Count() != 1 || cache.head != cache.tail || cache.head.Key != key {
+					t.Fatalf("expected %s to be the only entry, got %d entries", key, cache.Count())
+				}
+			}
+			cache.mutex.Lock()
+			defer cache.mutex.Unlock()
+			// Evicting the last remaining entry must leave an empty list, and evicting from an empty list must do nothing
+			if !cache.evict() {
+				t.Error("expected the last remaining entry to have been evicted")
+			}
+			if cache.head != nil || cache.tail != nil || len(cache.entries) != 0 {
+				t.Error("expected the cache to be empty")
+			}
+			if cache.evict() {
+				t.Error("expected nothing to have been evicted, since the cache is empty")
+			}
+		})
+	}
+}
+
+func TestCache_LinkedListRemainsConsistent(t *testing.T) {
+	for _, evictionPolicy := range []EvictionPolicy{FirstInFirstOut, LeastRecentlyUsed, MostRecentlyUsed, Random} {
+		for _, maxSize := range []int{1, 2, 3, 10} {
+			t.Run(fmt.Sprintf("%s-%d", evictionPolicy, maxSize), func(t *testing.T) {
+				random := rand.New(rand.NewSource(int64(maxSize)))
+				cache := NewCache().WithMaxSize(maxSize).WithEvictionPolicy(evictionPolicy)
+				// Using few keys makes it likely for operations to target the head, the tail, or the only entry
+				randomKey := func() string {
+					return strconv.Itoa(random.Intn(maxSize + 2))
+				}
+				for i := 0; i < 5000; i++ {
+					var operation string
+					switch random.Intn(8) {
+					case 0, 1:
+						operation = "Set"
+						cache.Set(randomKey(), i)
+					case 2, 3:
+						operation = "Get"
+						cache.Get(randomKey())
+					case 4:
+						operation = "Delete"
+						cache.Delete(randomKey())
+					case 5:
+						operation = "Copy"
+						cache.Copy(randomKey(), randomKey(), random.Intn(2) == 0)
+					case 6:
+						operation = "Rename"
+						_ = cache.Rename(randomKey(), randomKey())
+					case 7:
+						operation = "Pin"
+						if random.Intn(2) == 0 {
+							cache.Pin(randomKey())
+						} else {
+							cache.Unpin(randomKey())
+						}
+					}
+					if err := cache.DebugVerify(); err != nil {
+						t.Fatalf("linked list corrupted after operation #%d (%s): %v", i, operation, err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestCache_DebugVerify(t *testing.T) {
+	cache := NewCache().WithMaxMemoryUsage(Megabyte)
+	if err := cache.DebugVerify(); err != nil {
+		t.Error("expected an empty cache to be consistent, got", err)
+	}
+	for n := 0; n < 5; n++ {
+		cache.Set(strconv.Itoa(n), n)
+	}
+	if err := cache.DebugVerify(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	cache.memoryUsage++
+	if err := cache.DebugVerify(); err == nil {
+		t.Error("expected the memory usage to have been reported as inconsistent")
+	}
+	cache.memoryUsage--
+	// Unlink the entry in the middle of the list without removing it from the map
+	entry, _ := cache.get("2")
+	entry.previous.next = entry.next
+	if err := cache.DebugVerify(); err == nil {
+		t.Error("expected the list to have been reported as inconsistent")
+	}
+	entry.next.previous = entry.previous
+	if err := cache.DebugVerify(); err == nil || !strings.Contains(err.Error(), "expected 5 entries") {
+		t.Error("expected the list to have been reported as missing an entry, got", err)
+	}
+}
+
 func TestCache_EvictionsWithFIFO(t *testing.T) {
 	cache := NewCache().WithMaxSize(3).WithEvictionPolicy(FirstInFirstOut)
 
@@ -445,6 +837,84 @@ func TestCache_EvictionsWithLRU(t *testing.T) {
 	}
 }
 
+func TestCache_EvictionsWithMRU(t *testing.T) {
+	cache := NewCache().WithMaxSize(3).WithEvictionPolicy(MostRecentlyUsed)
+
+	cache.Set("1", []byte("value"))
+	cache.Set("2", []byte("value"))
+	cache.Set("3", []byte("value"))
+	_, _ = cache.Get("1")
+	cache.Set("4", []byte("value"))
+
+	if _, ok := cache.Get("1"); ok {
+		t.Error("expected key 1 to have been evicted, because MRU")
+	}
+	for _, key := range []string{"2", "3", "4"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("expected key %s to still exist", key)
+		}
+	}
+}
+
+func TestCache_EvictionsWithMRUAndPinnedEntries(t *testing.T) {
+	cache := NewCache().WithMaxSize(3).WithEvictionPolicy(MostRecentlyUsed)
+	cache.Set("1", []byte("value"))
+	cache.Set("2", []byte("value"))
+	cache.Set("3", []byte("value"))
+	cache.Pin("3")
+	cache.Set("4", []byte("value"))
+	// (head) 4 - 3 (pinned) - 2 - 1 (tail)
+	if _, ok := cache.Get("2"); ok {
+		t.Error("expected key 2 to have been evicted, since it was the closest to the head that wasn't pinned")
+	}
+	if cache.Count() != 3 {
+		t.Error("expected 3 entries, got", cache.Count())
+	}
+}
+
+func TestCache_EvictionsWithRandom(t *testing.T) {
+	evictedKeys := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		cache := NewCache().WithMaxSize(3).WithEvictionPolicy(Random)
+		cache.Set("1", []byte("value"))
+		cache.Set("2", []byte("value"))
+		cache.Set("3", []byte("value"))
+		cache.Set("4", []byte("value"))
+		if cache.Count() != 3 {
+			t.Fatal("expected 3 entries, got", cache.Count())
+		}
+		if _, ok := cache.Get("4"); !ok {
+			t.Fatal("expected the key that was just set to never be evicted")
+		}
+		for _, key := range []string{"1", "2", "3"} {
+			if _, ok := cache.Get(key); !ok {
+				evictedKeys[key]++
+			}
+		}
+	}
+	if len(evictedKeys) != 3 {
+		t.Error("expected every key other than the one that was just set to have been evicted at least once, got", evictedKeys)
+	}
+}
+
+func TestCache_EvictionsWithRandomAndPinnedEntries(t *testing.T) {
+	cache := NewCache().WithMaxSize(3).WithEvictionPolicy(Random)
+	cache.Set("1", []byte("value"))
+	cache.Set("2", []byte("value"))
+	cache.Set("3", []byte("value"))
+	cache.Pin("1")
+	cache.Pin("3")
+	cache.Set("4", []byte("value"))
+	if _, ok := cache.Get("2"); ok {
+		t.Error("expected key 2 to have been evicted, since it was the only one that wasn't pinned")
+	}
+	cache.Pin("4")
+	cache.Set("5", []byte("value"))
+	if cache.Count() != 4 {
+		t.Error("expected the cache to have grown beyond its max size, since every other entry is pinned")
+	}
+}
+
 func TestCache_HeadToTailSimple(t *testing.T) {
 	cache := NewCache().WithMaxSize(3)
 	cache.Set("1", "1")
@@ -711,6 +1181,35 @@ func TestCache_HeadStaysTheSameIfCallRepeatedly(t *testing.T) {
 	}
 }
 
+func TestCache_GetAndDelete(t *testing.T) {
+	cache := NewCache()
+	cache.Set("1", "one")
+	cache.Set("2", "two")
+	cache.Set("3", "three")
+	value, ok := cache.GetAndDelete("2")
+	if !ok || value != "two" {
+		t.Errorf("expected two to be returned, got %v", value)
+	}
+	if cache.Count() != 2 {
+		t.Error("expected the key to have been deleted")
+	}
+	// (head) 3 - 1 (tail)
+	if cache.head.Key != "3" || cache.head.next.Key != "1" || cache.tail.previous.Key != "3" {
+		t.Error("expected the entries before and after the deleted key to reference each other")
+	}
+	if value, ok = cache.GetAndDelete("2"); ok || value != nil {
+		t.Errorf("expected nothing to be returned, since the key no longer exists, got %v", value)
+	}
+	cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok = cache.GetAndDelete("expired"); ok {
+		t.Error("expected false, since the key has expired")
+	}
+	if cache.Count() != 2 {
+		t.Error("expected the expired key to have been deleted")
+	}
+}
+
 func TestCache_Delete(t *testing.T) {
 	cache := NewCache()
 
@@ -767,88 +1266,797 @@ func TestCache_Delete(t *testing.T) {
 	}
 }
 
-func TestCache_DeleteAll(t *testing.T) {
+func TestCache_SetAllWithTTL(t *testing.T) {
 	cache := NewCache()
-	cache.Set("1", []byte("1"))
-	cache.Set("2", []byte("2"))
-	cache.Set("3", []byte("3"))
-	if len(cache.GetByKeys([]string{"1", "2", "3"})) != 3 {
-		t.Error("Expected keys 1, 2 and 3 to exist")
+	cache.SetAllWithTTL(map[string]interface{}{"1": "a", "2": "b"}, time.Hour)
+	for _, key := range []string{"1", "2"} {
+		if ttl, err := cache.TTL(key); err != nil || ttl <= 59*time.Minute {
+			t.Errorf("[%s] expected TTL to be close to an hour, got %s and %v", key, ttl, err)
+		}
 	}
-	numberOfDeletedKeys := cache.DeleteAll([]string{"1", "2", "3"})
-	if numberOfDeletedKeys != 3 {
-		t.Errorf("Expected 3 keys to have been deleted, but only %d were deleted", numberOfDeletedKeys)
+	cache.SetAllWithTTL(map[string]interface{}{"1": "a", "3": "c"}, NoExpiration)
+	if _, err := cache.TTL("1"); err != ErrKeyHasNoExpiration {
+		t.Error("expected expiration time of the updated key to have been cleared, got", err)
+	}
+	if cache.Count() != 3 {
+		t.Error("expected 3 entries, got", cache.Count())
 	}
 }
 
-func TestCache_TTL(t *testing.T) {
+func TestCache_SetAllWithMaxSize(t *testing.T) {
+	cache := NewCache().WithMaxSize(5)
+	entries := make(map[string]interface{})
+	for i := 0; i < 10; i++ {
+		entries[fmt.Sprintf("%d", i)] = i
+	}
+	cache.SetAll(entries)
+	if cache.Count() != 5 {
+		t.Errorf("expected cache to have been evicted down to its max size, got %d", cache.Count())
+	}
+	if cache.Stats().EvictedKeys != 5 {
+		t.Errorf("expected 5 keys to have been evicted, got %d", cache.Stats().EvictedKeys)
+	}
+}
+
+func TestCache_SetAllWithTTLs(t *testing.T) {
 	cache := NewCache()
-	ttl, err := cache.TTL("key")
-	if err != ErrKeyDoesNotExist {
-		t.Errorf("expected %s, got %s", ErrKeyDoesNotExist, err)
+	cache.SetAllWithTTLs(map[string]ValueWithTTL{
+		"1": {Value: "a", TTL: time.Minute},
+		"2": {Value: "b", TTL: time.Hour},
+		"3": {Value: "c", TTL: NoExpiration},
+		"4": {Value: "d", TTL: -5},
+	})
+	if cache.Count() != 3 {
+		t.Errorf("expected 3 keys, got %d", cache.Count())
 	}
-	cache.Set("key", "value")
-	_, err = cache.TTL("key")
-	if err != ErrKeyHasNoExpiration {
-		t.Error("Expected TTL on new key created using Set to have no expiration")
+	if ttl, err := cache.TTL("1"); err != nil || ttl > time.Minute || ttl < 59*time.Second {
+		t.Error("expected key 1 to expire in a minute, got", ttl)
 	}
-	cache.SetWithTTL("key", "value", time.Hour)
-	ttl, err = cache.TTL("key")
-	if err != nil {
-		t.Error("Unexpected error")
+	if ttl, err := cache.TTL("2"); err != nil || ttl > time.Hour || ttl < 59*time.Minute {
+		t.Error("expected key 2 to expire in an hour, got", ttl)
 	}
-	if ttl.Minutes() < 59 || ttl.Minutes() > 60 {
-		t.Error("Expected the TTL to be almost an hour")
+	if _, err := cache.TTL("3"); err != ErrKeyHasNoExpiration {
+		t.Error("expected key 3 to have no expiration")
 	}
-	cache.SetWithTTL("key", "value", 5*time.Millisecond)
-	time.Sleep(6 * time.Millisecond)
-	ttl, err = cache.TTL("key")
-	if err != ErrKeyDoesNotExist {
-		t.Error("key should've expired, thus TTL should've returned ")
+	if value, _ := cache.Get("2"); value != "b" {
+		t.Error("expected key 2 to have b as value, got", value)
 	}
 }
 
-func TestCache_Expire(t *testing.T) {
-	cache := NewCache()
-	if cache.Expire("key-that-does-not-exist", time.Minute) {
-		t.Error("Expected Expire to return false, because the key used did not exist")
+func TestCache_SetAllWithTTLsWithMaxSize(t *testing.T) {
+	cache := NewCache().WithMaxSize(5)
+	entries := make(map[string]ValueWithTTL)
+	for i := 0; i < 10; i++ {
+		entries[fmt.Sprintf("%d", i)] = ValueWithTTL{Value: i, TTL: time.Hour}
 	}
-	cache.Set("key", "value")
-	_, err := cache.TTL("key")
-	if err != ErrKeyHasNoExpiration {
-		t.Error("Expected TTL on new key created using Set to have no expiration")
+	cache.SetAllWithTTLs(entries)
+	if cache.Count() != 5 {
+		t.Errorf("expected cache to have been evicted down to its max size, got %d", cache.Count())
 	}
-	if !cache.Expire("key", time.Hour) {
-		t.Error("Expected Expire to return true")
+	if cache.Stats().EvictedKeys != 5 {
+		t.Errorf("expected 5 keys to have been evicted, got %d", cache.Stats().EvictedKeys)
 	}
-	ttl, err := cache.TTL("key")
-	if err != nil {
-		t.Error("Unexpected error")
+}
+
+func TestCache_SetAllIfAbsent(t *testing.T) {
+	cache := NewCache().WithMaxSize(3)
+	cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if !cache.SetAllIfAbsent(map[string]interface{}{"1": 1, "2": 2, "expired": "new-value"}) {
+		t.Fatal("expected entries to have been set")
 	}
-	if ttl.Minutes() < 59 || ttl.Minutes() > 60 {
-		t.Error("Expected the TTL to be almost an hour")
+	if cache.Count() != 3 {
+		t.Error("expected 3 entries, got", cache.Count())
 	}
-	if !cache.Expire("key", 5*time.Millisecond) {
-		t.Error("Expected Expire to return true")
+	if value, _ := cache.Get("expired"); value != "new-value" {
+		t.Error("expected expired key to have been treated as absent, got", value)
 	}
-	time.Sleep(6 * time.Millisecond)
-	_, err = cache.TTL("key")
-	if err != ErrKeyDoesNotExist {
-		t.Error("key should've expired, thus TTL should've returned ErrKeyDoesNotExist")
+	if cache.SetAllIfAbsent(map[string]interface{}{"3": 3, "1": "new-value"}) {
+		t.Error("expected false, since one of the keys already exists")
 	}
-	if cache.Expire("key", time.Hour) {
-		t.Error("Expire should've returned false, because the key should've already expired, thus no longer exist")
+	if _, ok := cache.Get("3"); ok {
+		t.Error("expected no entry to have been set, since one of the keys already exists")
 	}
-	cache.SetWithTTL("key", "value", time.Hour)
-	if !cache.Expire("key", NoExpiration) {
-		t.Error("Expire should've returned true")
+	if value, _ := cache.Get("1"); value != 1 {
+		t.Error("expected existing key to have been left untouched, got", value)
 	}
-	if _, err := cache.TTL("key"); err != ErrKeyHasNoExpiration {
-		t.Error("TTL should've returned ErrKeyHasNoExpiration")
+	if !cache.SetAllIfAbsent(map[string]interface{}{"3": 3, "4": 4}) {
+		t.Fatal("expected entries to have been set")
+	}
+	if cache.Count() != 3 {
+		t.Errorf("expected cache to have been evicted down to its max size, got %d", cache.Count())
 	}
 }
 
-func TestCache_Clear(t *testing.T) {
+func TestCache_SetKeepTTL(t *testing.T) {
+	cache := NewCache().WithMaxSize(10)
+	cache.SetWithTTL("key", "value", time.Hour)
+	cache.SetKeepTTL("key", "new-value")
+	if value, _ := cache.Get("key"); value != "new-value" {
+		t.Error("expected new-value, got", value)
+	}
+	if ttl, err := cache.TTL("key"); err != nil || ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("expected the key to have kept its TTL of ~1h, got %v and %v", ttl, err)
+	}
+	cache.SetKeepTTL("key-without-ttl", "value")
+	cache.SetKeepTTL("key-without-ttl", "new-value")
+	if _, err := cache.TTL("key-without-ttl"); err != ErrKeyHasNoExpiration {
+		t.Error("expected the key to have no expiration, got", err)
+	}
+	cache.SetWithTTL("expired-key", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	cache.SetKeepTTL("expired-key", "new-value")
+	if value, ok := cache.Get("expired-key"); !ok || value != "new-value" {
+		t.Errorf("expected the expired key to have been created again, got %v", value)
+	}
+	if _, err := cache.TTL("expired-key"); err != ErrKeyHasNoExpiration {
+		t.Error("expected the expired key to no longer have an expiration, got", err)
+	}
+}
+
+func TestCache_GetSet(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("key", "old-value", time.Hour)
+	oldValue, existed := cache.GetSet("key", "new-value")
+	if !existed || oldValue != "old-value" {
+		t.Errorf("expected old-value and the key to have existed, got %v and %v", oldValue, existed)
+	}
+	if value, _ := cache.Get("key"); value != "new-value" {
+		t.Error("expected new-value, got", value)
+	}
+	if _, err := cache.TTL("key"); err != ErrKeyHasNoExpiration {
+		t.Error("expected the expiration time of the key to have been cleared")
+	}
+}
+
+func TestCache_GetSetWhenKeyDoesNotExist(t *testing.T) {
+	cache := NewCache()
+	oldValue, existed := cache.GetSet("key", "value")
+	if existed || oldValue != nil {
+		t.Errorf("expected nil and the key to not have existed, got %v and %v", oldValue, existed)
+	}
+	if value, _ := cache.Get("key"); value != "value" {
+		t.Error("expected value, got", value)
+	}
+}
+
+func TestCache_GetSetWithTTL(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("expired", "old-value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	oldValue, existed := cache.GetSetWithTTL("expired", "new-value", time.Hour)
+	if existed || oldValue != nil {
+		t.Errorf("expected expired key to be considered as not existing, got %v and %v", oldValue, existed)
+	}
+	if ttl, err := cache.TTL("expired"); err != nil || ttl <= 0 {
+		t.Error("expected the key to have an expiration time")
+	}
+}
+
+func TestCache_GetOrSet(t *testing.T) {
+	cache := NewCache()
+	value, existed := cache.GetOrSet("key", "value")
+	if existed || value != "value" {
+		t.Errorf("expected value and the key to not have existed, got %v and %v", value, existed)
+	}
+	value, existed = cache.GetOrSet("key", "other-value")
+	if !existed || value != "value" {
+		t.Errorf("expected value and the key to have existed, got %v and %v", value, existed)
+	}
+	if value, _ := cache.Get("key"); value != "value" {
+		t.Error("expected the existing value to not have been replaced, got", value)
+	}
+}
+
+func TestCache_GetOrSetWithTTL(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("expired", "old-value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	value, existed := cache.GetOrSetWithTTL("expired", "new-value", time.Hour)
+	if existed || value != "new-value" {
+		t.Errorf("expected expired key to be considered as not existing, got %v and %v", value, existed)
+	}
+	if ttl, err := cache.TTL("expired"); err != nil || ttl <= 0 {
+		t.Error("expected the key to have an expiration time")
+	}
+}
+
+func TestCache_GetOrSetWithLRU(t *testing.T) {
+	cache := NewCache().WithMaxSize(3).WithEvictionPolicy(LeastRecentlyUsed)
+	cache.Set("1", 1)
+	cache.Set("2", 2)
+	cache.Set("3", 3)
+	// Retrieving an existing key must move it back to the head, making 2 the next entry to be evicted
+	cache.GetOrSet("1", 100)
+	cache.Set("4", 4)
+	if _, ok := cache.Get("1"); !ok {
+		t.Error("expected key 1 to not have been evicted, since it was accessed through GetOrSet")
+	}
+	if _, ok := cache.Get("2"); ok {
+		t.Error("expected key 2 to have been evicted")
+	}
+}
+
+func TestCache_SetIfAbsent(t *testing.T) {
+	cache := NewCache()
+	if !cache.SetIfAbsent("key", "value") {
+		t.Error("expected the key to have been created")
+	}
+	if cache.SetIfAbsent("key", "other-value") {
+		t.Error("expected the key to not have been created, since it already exists")
+	}
+	if value, _ := cache.Get("key"); value != "value" {
+		t.Error("expected the existing value to not have been replaced, got", value)
+	}
+}
+
+func TestCache_SetIfAbsentWithTTL(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("expired", "old-value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if !cache.SetIfAbsentWithTTL("expired", "new-value", time.Hour) {
+		t.Error("expected the expired key to have been replaced")
+	}
+	if ttl, err := cache.TTL("expired"); err != nil || ttl <= 0 {
+		t.Error("expected the key to have an expiration time")
+	}
+	if cache.SetIfAbsentWithTTL("key", "value", -2) {
+		t.Error("expected the key to not have been created, since the TTL means it expired immediately")
+	}
+}
+
+func TestCache_SetIfPresent(t *testing.T) {
+	cache := NewCache()
+	if cache.SetIfPresent("key", "value") {
+		t.Error("expected the key to not have been updated, since it doesn't exist")
+	}
+	if _, ok := cache.Get("key"); ok {
+		t.Error("expected the key to not have been created")
+	}
+	cache.SetWithTTL("key", "value", time.Hour)
+	if !cache.SetIfPresent("key", "new-value") {
+		t.Error("expected the key to have been updated")
+	}
+	if value, _ := cache.Get("key"); value != "new-value" {
+		t.Error("expected new-value, got", value)
+	}
+	if _, err := cache.TTL("key"); err != ErrKeyHasNoExpiration {
+		t.Error("expected the expiration time of the key to have been cleared")
+	}
+}
+
+func TestCache_SetIfPresentWithTTL(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("expired", "old-value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if cache.SetIfPresentWithTTL("expired", "new-value", time.Hour) {
+		t.Error("expected the expired key to be considered as not existing")
+	}
+	if _, ok := cache.Get("expired"); ok {
+		t.Error("expected the expired key to not have been updated")
+	}
+	cache.Set("key", "value")
+	if !cache.SetIfPresentWithTTL("key", "new-value", time.Hour) {
+		t.Error("expected the key to have been updated")
+	}
+	if ttl, err := cache.TTL("key"); err != nil || ttl <= 0 {
+		t.Error("expected the key to have an expiration time")
+	}
+}
+
+func TestCache_SetIfPresentWithFIFO(t *testing.T) {
+	cache := NewCache().WithMaxSize(3).WithEvictionPolicy(FirstInFirstOut)
+	cache.Set("1", 1)
+	cache.Set("2", 2)
+	cache.Set("3", 3)
+	// Updating a key resets its position, making 2 the next entry to be evicted
+	cache.SetIfPresent("1", 100)
+	cache.Set("4", 4)
+	if _, ok := cache.Get("1"); !ok {
+		t.Error("expected key 1 to not have been evicted, since it was updated through SetIfPresent")
+	}
+	if _, ok := cache.Get("2"); ok {
+		t.Error("expected key 2 to have been evicted")
+	}
+}
+
+func TestCache_SetIfAbsentConcurrently(t *testing.T) {
+	cache := NewCache()
+	var wg sync.WaitGroup
+	numberOfValuesSet := int32(0)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if cache.SetIfAbsent("key", i) {
+				atomic.AddInt32(&numberOfValuesSet, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if numberOfValuesSet != 1 {
+		t.Errorf("expected the value to have been set exactly once, got %d", numberOfValuesSet)
+	}
+}
+
+func TestCache_GetOrSetConcurrently(t *testing.T) {
+	cache := NewCache()
+	var wg sync.WaitGroup
+	numberOfValuesSet := int32(0)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, existed := cache.GetOrSet("key", i); !existed {
+				atomic.AddInt32(&numberOfValuesSet, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if numberOfValuesSet != 1 {
+		t.Errorf("expected the value to have been set exactly once, got %d", numberOfValuesSet)
+	}
+}
+
+func TestCache_DeleteAll(t *testing.T) {
+	cache := NewCache()
+	cache.Set("1", []byte("1"))
+	cache.Set("2", []byte("2"))
+	cache.Set("3", []byte("3"))
+	if len(cache.GetByKeys([]string{"1", "2", "3"})) != 3 {
+		t.Error("Expected keys 1, 2 and 3 to exist")
+	}
+	numberOfDeletedKeys := cache.DeleteAll([]string{"1", "2", "3"})
+	if numberOfDeletedKeys != 3 {
+		t.Errorf("Expected 3 keys to have been deleted, but only %d were deleted", numberOfDeletedKeys)
+	}
+}
+
+func TestCache_DeleteAllWithResults(t *testing.T) {
+	cache := NewCache()
+	cache.Set("1", "1")
+	cache.Set("2", "2")
+	cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	results := cache.DeleteAllWithResults([]string{"1", "2", "3", "expired"})
+	expectedResults := map[string]bool{"1": true, "2": true, "3": false, "expired": false}
+	if len(results) != len(expectedResults) {
+		t.Fatalf("expected %d results, got %d", len(expectedResults), len(results))
+	}
+	for key, expected := range expectedResults {
+		if results[key] != expected {
+			t.Errorf("expected result for key %s to be %v, got %v", key, expected, results[key])
+		}
+	}
+	if cache.Count() != 0 {
+		t.Error("expected all keys to have been deleted, but cache has", cache.Count())
+	}
+}
+
+func TestCache_CountLive(t *testing.T) {
+	cache := NewCache()
+	cache.Set("key", "value")
+	cache.SetWithTTL("key-with-ttl", "value", time.Hour)
+	cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if cache.Count() != 3 {
+		t.Errorf("expected Count to include the expired entry, got %d", cache.Count())
+	}
+	if cache.CountLive() != 2 {
+		t.Errorf("expected CountLive to exclude the expired entry, got %d", cache.CountLive())
+	}
+}
+
+func TestCache_ExpireAt(t *testing.T) {
+	cache := NewCache()
+	cache.Set("key", "value")
+	if !cache.ExpireAt("key", time.Now().Add(time.Hour)) {
+		t.Error("expected the expiration time of the key to have been set")
+	}
+	if ttl, err := cache.TTL("key"); err != nil || ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Error("expected the key to expire in an hour, got", ttl)
+	}
+	if !cache.ExpireAt("key", time.Now().Add(-time.Hour)) {
+		t.Error("expected the expiration time of the key to have been set")
+	}
+	if _, ok := cache.Get("key"); ok {
+		t.Error("expected the key to have expired, since its expiration time is in the past")
+	}
+	if cache.ExpireAt("key", time.Now().Add(time.Hour)) {
+		t.Error("expected false, since the key no longer exists")
+	}
+}
+
+func TestCache_Touch(t *testing.T) {
+	cache := NewCache().WithEvictionPolicy(LeastRecentlyUsed)
+	cache.Set("1", "one")
+	cache.Set("2", "two")
+	cache.Set("3", "three")
+	// (head) 3 - 2 - 1 (tail)
+	if numberOfKeysTouched := cache.Touch([]string{"1", "2", "4"}); numberOfKeysTouched != 2 {
+		t.Error("expected 2 keys to have been touched, got", numberOfKeysTouched)
+	}
+	// (head) 2 - 1 - 3 (tail)
+	if cache.head.Key != "2" || cache.head.next.Key != "1" || cache.tail.Key != "3" {
+		t.Error("expected the touched keys to have been moved to the head")
+	}
+	if cache.Stats().Hits != 0 || cache.Stats().Misses != 0 {
+		t.Error("touching keys should not count as hits or misses")
+	}
+}
+
+func TestCache_TouchWithFIFO(t *testing.T) {
+	cache := NewCache().WithEvictionPolicy(FirstInFirstOut)
+	cache.Set("1", "one")
+	cache.Set("2", "two")
+	cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if numberOfKeysTouched := cache.Touch([]string{"1", "expired"}); numberOfKeysTouched != 1 {
+		t.Error("expected 1 key to have been touched, got", numberOfKeysTouched)
+	}
+	// (head) 2 - 1 (tail)
+	if cache.head.Key != "2" || cache.tail.Key != "1" {
+		t.Error("touching a key should not change its position when using FIFO")
+	}
+	if cache.Count() != 2 {
+		t.Error("expected the expired key to have been deleted")
+	}
+}
+
+func TestCache_Persist(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("key", "value", time.Hour)
+	if !cache.Persist("key") {
+		t.Error("expected the expiration time of the key to have been removed")
+	}
+	if _, err := cache.TTL("key"); err != ErrKeyHasNoExpiration {
+		t.Error("expected the key to no longer have an expiration time")
+	}
+	if cache.Persist("key") {
+		t.Error("expected nothing to have changed, since the key already has no expiration time")
+	}
+	if cache.Persist("key-that-does-not-exist") {
+		t.Error("expected nothing to have changed, since the key does not exist")
+	}
+	cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if cache.Persist("expired") {
+		t.Error("expected nothing to have changed, since the key has already expired")
+	}
+}
+
+func TestCache_RenameNX(t *testing.T) {
+	cache := NewCache().WithMaxMemoryUsage(Kilobyte)
+	cache.SetWithTTL("1", "value", time.Hour)
+	cache.Set("2", "value")
+	memoryUsageBefore := cache.MemoryUsage()
+	renamed, err := cache.RenameNX("1", "renamed")
+	if err != nil || !renamed {
+		t.Fatalf("expected key to have been renamed, got %v and %v", renamed, err)
+	}
+	if value, ok := cache.get("renamed"); !ok || value.Value != "value" {
+		t.Error("expected renamed key to have the value of the old key")
+	}
+	if _, ok := cache.get("1"); ok {
+		t.Error("expected old key to no longer exist")
+	}
+	if ttl, err := cache.TTL("renamed"); err != nil || ttl <= 0 {
+		t.Error("expected renamed key to have kept its expiration time")
+	}
+	if cache.tail.Key != "renamed" {
+		t.Errorf("expected renamed key to have kept its position, but tail is %s", cache.tail.Key)
+	}
+	if cache.MemoryUsage() != memoryUsageBefore+len("renamed")-len("1") {
+		t.Errorf("expected memory usage to account for the length of the new key, got %d", cache.MemoryUsage())
+	}
+}
+
+func TestCache_RenameNXWhenNewKeyAlreadyExists(t *testing.T) {
+	cache := NewCache()
+	cache.Set("1", "value")
+	cache.Set("2", "other-value")
+	renamed, err := cache.RenameNX("1", "2")
+	if err != nil || renamed {
+		t.Fatalf("expected key to not have been renamed, got %v and %v", renamed, err)
+	}
+	if value, _ := cache.Get("2"); value != "other-value" {
+		t.Error("expected destination to have been left untouched, got", value)
+	}
+	if _, ok := cache.Get("1"); !ok {
+		t.Error("expected source to still exist")
+	}
+}
+
+func TestCache_RenameNXWhenKeyDoesNotExist(t *testing.T) {
+	cache := NewCache()
+	if _, err := cache.RenameNX("1", "2"); err != ErrKeyDoesNotExist {
+		t.Errorf("expected %v, got %v", ErrKeyDoesNotExist, err)
+	}
+}
+
+func TestCache_Rename(t *testing.T) {
+	cache := NewCache().WithMaxMemoryUsage(Kilobyte)
+	cache.SetWithTTL("1", "value", time.Hour)
+	cache.Set("2", "other-value")
+	cache.Set("3", "value")
+	if err := cache.Rename("1", "2"); err != nil {
+		t.Fatal("expected key to have been renamed, got", err)
+	}
+	if value, ok := cache.get("2"); !ok || value.Value != "value" {
+		t.Error("expected the new key to have been replaced by the value of the old key")
+	}
+	if _, ok := cache.get("1"); ok {
+		t.Error("expected old key to no longer exist")
+	}
+	if ttl, err := cache.TTL("2"); err != nil || ttl <= 0 {
+		t.Error("expected renamed key to have kept its expiration time")
+	}
+	if cache.tail.Key != "2" {
+		t.Errorf("expected renamed key to have kept its position, but tail is %s", cache.tail.Key)
+	}
+	if cache.Count() != 2 {
+		t.Errorf("expected the cache to have 2 entries, got %d", cache.Count())
+	}
+	expectedMemoryUsage := (&Entry{Key: "2", Value: "value"}).SizeInBytes() + (&Entry{Key: "3", Value: "value"}).SizeInBytes()
+	if cache.MemoryUsage() != expectedMemoryUsage {
+		t.Errorf("expected memory usage to be %d, got %d", expectedMemoryUsage, cache.MemoryUsage())
+	}
+	if err := cache.Rename("3", "3"); err != nil {
+		t.Error("expected renaming a key to itself to succeed, got", err)
+	}
+	if _, ok := cache.Get("3"); !ok {
+		t.Error("expected key renamed to itself to still exist")
+	}
+}
+
+func TestCache_RenameWhenKeyDoesNotExist(t *testing.T) {
+	cache := NewCache()
+	if err := cache.Rename("1", "2"); err != ErrKeyDoesNotExist {
+		t.Errorf("expected %v, got %v", ErrKeyDoesNotExist, err)
+	}
+}
+
+func TestCache_Scan(t *testing.T) {
+	cache := NewCache()
+	for i := 0; i < 25; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), i)
+	}
+	cache.Set("other", "value")
+	cache.SetWithTTL("key-expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	keys, cursor := cache.Scan(0, "key*", 10)
+	if len(keys) != 10 || cursor == 0 {
+		t.Fatalf("expected 10 keys and a cursor other than 0, got %d keys and %d", len(keys), cursor)
+	}
+	if keys[0] != "key0" || keys[9] != "key9" {
+		t.Error("expected keys to have been returned in the order in which they would be evicted, got", keys)
+	}
+	keys, cursor = cache.Scan(cursor, "key*", 10)
+	if len(keys) != 10 || cursor == 0 {
+		t.Fatalf("expected 10 keys and a cursor other than 0, got %d keys and %d", len(keys), cursor)
+	}
+	keys, cursor = cache.Scan(cursor, "key*", 10)
+	if len(keys) != 5 || cursor != 0 {
+		t.Errorf("expected the last 5 keys and a cursor of 0, got %d keys and %d", len(keys), cursor)
+	}
+	if keys, cursor := cache.Scan(0, "*", 0); len(keys) != 10 || cursor == 0 {
+		t.Errorf("expected the default count to be 10, got %d keys and %d", len(keys), cursor)
+	}
+}
+
+func TestCache_ScanWhenEntriesAreModifiedDuringTheIteration(t *testing.T) {
+	cache := NewCache().WithEvictionPolicy(LeastRecentlyUsed)
+	for i := 0; i < 30; i++ {
+		cache.Set(fmt.Sprintf("%d", i), i)
+	}
+	returnedKeys := make(map[string]int)
+	keys, cursor := cache.Scan(0, "*", 10)
+	for _, key := range keys {
+		returnedKeys[key]++
+	}
+	// Moving the last entry visited to the head forces the iteration to resume from the tail
+	cache.Get(keys[len(keys)-1])
+	cache.Delete("15")
+	cache.Set("new", "value")
+	for cursor != 0 {
+		keys, cursor = cache.Scan(cursor, "*", 10)
+		for _, key := range keys {
+			returnedKeys[key]++
+		}
+	}
+	for i := 0; i < 30; i++ {
+		if key := fmt.Sprintf("%d", i); i != 15 && returnedKeys[key] == 0 {
+			t.Errorf("expected %s to have been returned, since it existed during the whole iteration", key)
+		}
+	}
+	if returnedKeys["15"] != 0 {
+		t.Error("expected deleted key to not have been returned")
+	}
+	if returnedKeys["9"] != 2 {
+		t.Error("expected key accessed during the iteration to have been returned twice, got", returnedKeys["9"])
+	}
+}
+
+func TestCache_ScanAfterReadFromFile(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	cache := NewCache()
+	for i := 0; i < 30; i++ {
+		cache.Set(fmt.Sprintf("saved-%d", i), i)
+	}
+	// Simulate a file saved before sequence numbers were introduced
+	for _, entry := range cache.entries {
+		entry.Sequence = 0
+	}
+	if err := cache.SaveToFile(file); err != nil {
+		t.Fatal(err)
+	}
+	newCache := NewCache()
+	for i := 0; i < 30; i++ {
+		newCache.Set(fmt.Sprintf("existing-%d", i), i)
+	}
+	if _, err := newCache.ReadFromFile(file); err != nil {
+		t.Fatal(err)
+	}
+	returnedKeys := make(map[string]bool)
+	keys, cursor := newCache.Scan(0, "*", 7)
+	for {
+		for _, key := range keys {
+			returnedKeys[key] = true
+		}
+		if cursor == 0 {
+			break
+		}
+		keys, cursor = newCache.Scan(cursor, "*", 7)
+	}
+	if len(returnedKeys) != 60 {
+		t.Errorf("expected every one of the 60 keys to have been returned, got %d", len(returnedKeys))
+	}
+}
+
+func TestCache_Copy(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("source", "value", time.Hour)
+	cache.Set("other", "value")
+	if !cache.Copy("source", "destination", false) {
+		t.Fatal("expected key to have been copied")
+	}
+	if value, ok := cache.Get("destination"); !ok || value != "value" {
+		t.Error("expected destination to have the value of the source, got", value)
+	}
+	if _, ok := cache.Get("source"); !ok {
+		t.Error("expected source to still exist")
+	}
+	if cache.entries["destination"].Expiration != cache.entries["source"].Expiration {
+		t.Error("expected destination to have the same expiration time as the source")
+	}
+	if cache.head.Key != "destination" {
+		t.Error("expected destination to have been inserted as a new entry")
+	}
+	if cache.Copy("source", "other", false) {
+		t.Error("expected false, since the destination already exists")
+	}
+	if value, _ := cache.Get("other"); value != "value" {
+		t.Error("expected destination to have been left untouched, got", value)
+	}
+	cache.Set("source", "new-value")
+	if !cache.Copy("source", "other", true) {
+		t.Error("expected destination to have been replaced")
+	}
+	if value, _ := cache.Get("other"); value != "new-value" {
+		t.Error("expected new-value, got", value)
+	}
+	if ttl, err := cache.TTL("other"); err != ErrKeyHasNoExpiration {
+		t.Errorf("expected destination to have no expiration, got %s and %v", ttl, err)
+	}
+	if cache.Count() != 3 {
+		t.Error("expected 3 entries, got", cache.Count())
+	}
+}
+
+func TestCache_CopyWhenKeyDoesNotExist(t *testing.T) {
+	cache := NewCache()
+	if cache.Copy("source", "destination", true) {
+		t.Error("expected false, since the source doesn't exist")
+	}
+	cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if cache.Copy("expired", "destination", true) {
+		t.Error("expected false, since the source has expired")
+	}
+	cache.Set("key", "value")
+	if cache.Copy("key", "key", true) {
+		t.Error("expected false, since the source and the destination are the same")
+	}
+}
+
+func TestCache_TTLDistribution(t *testing.T) {
+	cache := NewCache()
+	cache.SetWithTTL("1", "value", 30*time.Second)
+	cache.SetWithTTL("2", "value", 45*time.Second)
+	cache.SetWithTTL("3", "value", 30*time.Minute)
+	cache.SetWithTTL("4", "value", 2*time.Hour)
+	cache.Set("5", "value")
+	cache.Set("6", "value")
+	distribution := cache.TTLDistribution([]time.Duration{time.Hour, time.Minute})
+	expectedDistribution := map[time.Duration]int{time.Minute: 2, time.Hour: 1, NoExpiration: 2}
+	if len(distribution) != len(expectedDistribution) {
+		t.Fatalf("expected %d buckets, got %d", len(expectedDistribution), len(distribution))
+	}
+	for bucket, expectedCount := range expectedDistribution {
+		if distribution[bucket] != expectedCount {
+			t.Errorf("expected bucket %s to have %d entries, got %d", bucket, expectedCount, distribution[bucket])
+		}
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	cache := NewCache()
+	ttl, err := cache.TTL("key")
+	if err != ErrKeyDoesNotExist {
+		t.Errorf("expected %s, got %s", ErrKeyDoesNotExist, err)
+	}
+	cache.Set("key", "value")
+	_, err = cache.TTL("key")
+	if err != ErrKeyHasNoExpiration {
+		t.Error("Expected TTL on new key created using Set to have no expiration")
+	}
+	cache.SetWithTTL("key", "value", time.Hour)
+	ttl, err = cache.TTL("key")
+	if err != nil {
+		t.Error("Unexpected error")
+	}
+	if ttl.Minutes() < 59 || ttl.Minutes() > 60 {
+		t.Error("Expected the TTL to be almost an hour")
+	}
+	cache.SetWithTTL("key", "value", 5*time.Millisecond)
+	time.Sleep(6 * time.Millisecond)
+	ttl, err = cache.TTL("key")
+	if err != ErrKeyDoesNotExist {
+		t.Error("key should've expired, thus TTL should've returned ")
+	}
+}
+
+func TestCache_Expire(t *testing.T) {
+	cache := NewCache()
+	if cache.Expire("key-that-does-not-exist", time.Minute) {
+		t.Error("Expected Expire to return false, because the key used did not exist")
+	}
+	cache.Set("key", "value")
+	_, err := cache.TTL("key")
+	if err != ErrKeyHasNoExpiration {
+		t.Error("Expected TTL on new key created using Set to have no expiration")
+	}
+	if !cache.Expire("key", time.Hour) {
+		t.Error("Expected Expire to return true")
+	}
+	ttl, err := cache.TTL("key")
+	if err != nil {
+		t.Error("Unexpected error")
+	}
+	if ttl.Minutes() < 59 || ttl.Minutes() > 60 {
+		t.Error("Expected the TTL to be almost an hour")
+	}
+	if !cache.Expire("key", 5*time.Millisecond) {
+		t.Error("Expected Expire to return true")
+	}
+	time.Sleep(6 * time.Millisecond)
+	_, err = cache.TTL("key")
+	if err != ErrKeyDoesNotExist {
+		t.Error("key should've expired, thus TTL should've returned ErrKeyDoesNotExist")
+	}
+	if cache.Expire("key", time.Hour) {
+		t.Error("Expire should've returned false, because the key should've already expired, thus no longer exist")
+	}
+	cache.SetWithTTL("key", "value", time.Hour)
+	if !cache.Expire("key", NoExpiration) {
+		t.Error("Expire should've returned true")
+	}
+	if _, err := cache.TTL("key"); err != ErrKeyHasNoExpiration {
+		t.Error("TTL should've returned ErrKeyHasNoExpiration")
+	}
+}
+
+func TestCache_Clear(t *testing.T) {
 	cache := NewCache().WithMaxSize(10)
 	cache.Set("k1", "v1")
 	cache.Set("k2", "v2")
@@ -879,6 +2087,21 @@ func TestCache_WithMaxSizeAndNegativeValue(t *testing.T) {
 	}
 }
 
+func TestCache_WithInitialCapacity(t *testing.T) {
+	cache := NewCache().WithMaxSize(NoMaxSize).WithInitialCapacity(1000)
+	for i := 0; i < 2000; i++ {
+		cache.Set(fmt.Sprintf("%d", i), i)
+	}
+	if cache.Count() != 2000 {
+		t.Errorf("expected the cache to be able to grow beyond its initial capacity, got %d entries", cache.Count())
+	}
+	// Setting the initial capacity of a cache that already has entries must not do anything
+	cache.WithInitialCapacity(5000)
+	if cache.Count() != 2000 {
+		t.Errorf("expected existing entries to have been kept, got %d entries", cache.Count())
+	}
+}
+
 func TestCache_WithMaxMemoryUsage(t *testing.T) {
 	const ValueSize = Kilobyte
 	cache := NewCache().WithMaxSize(0).WithMaxMemoryUsage(Kilobyte * 64)
@@ -890,6 +2113,22 @@ func TestCache_WithMaxMemoryUsage(t *testing.T) {
 	}
 }
 
+func TestCache_WithMaxMemoryUsageWhenValueIsMutatedAfterBeingSet(t *testing.T) {
+	cache := NewCache().WithMaxSize(0).WithMaxMemoryUsage(64 * Kilobyte)
+	value := []string{"a"}
+	cache.Set("key", value)
+	memoryUsageAfterSet := cache.MemoryUsage()
+	// Since slices are references, this modifies the value stored in the cache as well
+	value[0] = strings.Repeat("0", Kilobyte)
+	if cache.MemoryUsage() != memoryUsageAfterSet {
+		t.Errorf("expected memory usage to still be %d, got %d", memoryUsageAfterSet, cache.MemoryUsage())
+	}
+	cache.Delete("key")
+	if cache.MemoryUsage() != 0 {
+		t.Errorf("expected memory usage to be back to 0 after deleting the only entry, got %d", cache.MemoryUsage())
+	}
+}
+
 func TestCache_WithMaxMemoryUsageWhenAddingAnEntryThatCausesMoreThanOneEviction(t *testing.T) {
 	const ValueSize = Kilobyte
 	cache := NewCache().WithMaxSize(0).WithMaxMemoryUsage(64 * Kilobyte)
@@ -911,11 +2150,11 @@ func TestCache_WithMaxMemoryUsageAndNegativeValue(t *testing.T) {
 func TestCache_MemoryUsageAfterSet10000AndDelete5000(t *testing.T) {
 	const ValueSize = 64
 	cache := NewCache().WithMaxSize(10000).WithMaxMemoryUsage(Gigabyte)
-	for i := 0; i < cache.maxSize; i++ {
+	for i := 0; i < cache.options.MaxSize; i++ {
 		cache.Set(fmt.Sprintf("%05d", i), strings.Repeat("0", ValueSize))
 	}
 	memoryUsageBeforeDeleting := cache.MemoryUsage()
-	for i := 0; i < cache.maxSize/2; i++ {
+	for i := 0; i < cache.options.MaxSize/2; i++ {
 		key := fmt.Sprintf("%05d", i)
 		cache.Delete(key)
 	}
@@ -1053,3 +2292,327 @@ func TestCache(t *testing.T) {
 		t.Error("expected 5 to exist")
 	}
 }
+
+func TestCache_WithOnFull(t *testing.T) {
+	numberOfCalls := 0
+	cache := NewCache().WithMaxSize(3).WithOnFull(func(cache *Cache) {
+		numberOfCalls++
+	})
+	cache.Set("1", "1")
+	cache.Set("2", "2")
+	cache.Set("3", "3")
+	if numberOfCalls != 0 {
+		t.Errorf("expected the callback to not have been called yet, but it was called %d times", numberOfCalls)
+	}
+	// Updating an existing entry doesn't cause the cache to exceed its max size
+	cache.Set("3", "3")
+	if numberOfCalls != 0 {
+		t.Errorf("expected the callback to not have been called yet, but it was called %d times", numberOfCalls)
+	}
+	cache.Set("4", "4")
+	if numberOfCalls != 1 {
+		t.Errorf("expected the callback to have been called once, but it was called %d times", numberOfCalls)
+	}
+	if cache.Count() != 3 {
+		t.Errorf("expected the cache to have evicted an entry, but it has %d entries", cache.Count())
+	}
+}
+
+func TestCache_WithOnEvict(t *testing.T) {
+	evicted := make(map[string]interface{})
+	cache := NewCache().WithMaxSize(2)
+	cache.WithOnEvict(func(key string, value interface{}) {
+		// The cache must not be locked while the callback runs
+		if _, ok := cache.Get(key); ok {
+			t.Errorf("expected %s to have already been removed when the callback is called", key)
+		}
+		evicted[key] = value
+	})
+	cache.Set("1", "one")
+	cache.Set("2", "two")
+	cache.Delete("2")
+	cache.SetWithTTL("3", "three", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	cache.Get("3")
+	if len(evicted) != 0 {
+		t.Errorf("expected deleted and expired entries to not be reported as evicted, got %v", evicted)
+	}
+	cache.Set("4", "four")
+	cache.Set("5", "five")
+	if len(evicted) != 1 || evicted["1"] != "one" {
+		t.Errorf("expected only 1 to have been evicted, got %v", evicted)
+	}
+	cache.SetAllWithTTLs(map[string]ValueWithTTL{"6": {Value: "six", TTL: NoExpiration}, "7": {Value: "seven", TTL: NoExpiration}})
+	if len(evicted) != 3 || evicted["4"] != "four" || evicted["5"] != "five" {
+		t.Errorf("expected 4 and 5 to have been evicted as well, got %v", evicted)
+	}
+}
+
+func TestCache_WithOnFullWhenCallbackIncreasesMaxSize(t *testing.T) {
+	cache := NewCache().WithMaxSize(2)
+	cache.WithOnFull(func(cache *Cache) {
+		cache.WithMaxSize(cache.MaxSize() * 2)
+	})
+	for i := 0; i < 5; i++ {
+		cache.Set(fmt.Sprintf("%d", i), i)
+	}
+	if cache.Stats().EvictedKeys != 0 {
+		t.Errorf("expected no entries to have been evicted, but %d were", cache.Stats().EvictedKeys)
+	}
+	if cache.MaxSize() != 8 {
+		t.Errorf("expected max size to have been increased to 8, got %d", cache.MaxSize())
+	}
+	if cache.Count() != 5 {
+		t.Errorf("expected the cache to have 5 entries, got %d", cache.Count())
+	}
+}
+
+func TestCache_Pin(t *testing.T) {
+	cache := NewCache().WithMaxSize(3).WithEvictionPolicy(LeastRecentlyUsed)
+	cache.Set("config", "value")
+	if !cache.Pin("config") {
+		t.Fatal("expected Pin to return true, because the key exists")
+	}
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprintf("%d", i), i)
+	}
+	if _, ok := cache.Get("config"); !ok {
+		t.Error("expected pinned key to have survived the churn")
+	}
+	if cache.Count() != 3 {
+		t.Errorf("expected cache to still respect its max size, but it has %d entries", cache.Count())
+	}
+	if !cache.Unpin("config") {
+		t.Fatal("expected Unpin to return true, because the key exists")
+	}
+	for i := 100; i < 103; i++ {
+		cache.Set(fmt.Sprintf("%d", i), i)
+	}
+	if _, ok := cache.Get("config"); ok {
+		t.Error("expected unpinned key to have been evicted")
+	}
+}
+
+func TestCache_PinWhenKeyDoesNotExist(t *testing.T) {
+	cache := NewCache()
+	if cache.Pin("key") {
+		t.Error("expected Pin to return false, because the key doesn't exist")
+	}
+	if cache.Unpin("key") {
+		t.Error("expected Unpin to return false, because the key doesn't exist")
+	}
+}
+
+func TestCache_PinWhenAllEntriesArePinned(t *testing.T) {
+	cache := NewCache().WithMaxSize(2)
+	cache.Set("1", 1)
+	cache.Set("2", 2)
+	cache.Pin("1")
+	cache.Pin("2")
+	cache.Set("3", 3)
+	if cache.Count() != 3 {
+		t.Errorf("expected cache to have grown beyond its max size, but it has %d entries", cache.Count())
+	}
+	if cache.Stats().EvictedKeys != 0 {
+		t.Error("expected no entries to have been evicted")
+	}
+}
+
+func TestCache_PinWhenAllEntriesArePinnedAndNewEntriesAreRejected(t *testing.T) {
+	cache := NewCache().WithMaxSize(2).WithRejectNewEntriesWhenFullyPinned(true)
+	cache.Set("1", 1)
+	cache.Set("2", 2)
+	cache.Pin("1")
+	cache.Pin("2")
+	cache.Set("3", 3)
+	if cache.Count() != 2 {
+		t.Errorf("expected cache to have rejected the new entry, but it has %d entries", cache.Count())
+	}
+	if _, ok := cache.Get("3"); ok {
+		t.Error("expected new entry to have been rejected")
+	}
+}
+
+func TestCache_PinWithMaxMemoryUsage(t *testing.T) {
+	cache := NewCache().WithMaxSize(NoMaxSize).WithMaxMemoryUsage(Kilobyte)
+	cache.Set("pinned", strings.Repeat("0", 512))
+	cache.Pin("pinned")
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprintf("%d", i), strings.Repeat("0", 128))
+	}
+	if _, ok := cache.Get("pinned"); !ok {
+		t.Error("expected pinned key to have survived the churn")
+	}
+	// Adding an entry that by itself exceeds the max memory usage must not loop forever
+	cache.Set("big", strings.Repeat("0", 2*Kilobyte))
+	if _, ok := cache.Get("pinned"); !ok {
+		t.Error("expected pinned key to have survived")
+	}
+}
+
+func TestCache_WithReturnCopies(t *testing.T) {
+	cache := NewCache().WithReturnCopies(true)
+	cache.Set("slice", []int{1, 2, 3})
+	cache.Set("bytes", []byte("value"))
+	cache.Set("map", map[string][]string{"key": {"a", "b"}})
+	slice, _ := cache.Get("slice")
+	slice.([]int)[0] = 100
+	bytes, _ := cache.Get("bytes")
+	bytes.([]byte)[0] = 'V'
+	m, _ := cache.Get("map")
+	m.(map[string][]string)["key"][0] = "modified"
+	m.(map[string][]string)["new-key"] = nil
+	if value, _ := cache.Get("slice"); value.([]int)[0] != 1 {
+		t.Error("expected cached slice to be unaffected, got", value)
+	}
+	if value, _ := cache.Get("bytes"); string(value.([]byte)) != "value" {
+		t.Error("expected cached bytes to be unaffected, got", value)
+	}
+	if value, _ := cache.Get("map"); len(value.(map[string][]string)) != 1 || value.(map[string][]string)["key"][0] != "a" {
+		t.Error("expected cached map to be unaffected, got", value)
+	}
+}
+
+func TestCache_WithReturnCopiesDisabled(t *testing.T) {
+	cache := NewCache()
+	cache.Set("slice", []int{1, 2, 3})
+	slice, _ := cache.Get("slice")
+	slice.([]int)[0] = 100
+	if value, _ := cache.Get("slice"); value.([]int)[0] != 100 {
+		t.Error("expected cached slice to share its backing array with the returned slice, got", value)
+	}
+}
+
+func TestCache_EvictionCandidates(t *testing.T) {
+	scenarios := []struct {
+		policy             EvictionPolicy
+		expectedCandidates []string
+	}{
+		{policy: FirstInFirstOut, expectedCandidates: []string{"1", "2", "3"}},
+		{policy: LeastRecentlyUsed, expectedCandidates: []string{"2", "3", "4"}},
+		{policy: MostRecentlyUsed, expectedCandidates: []string{"4", "3", "2"}},
+	}
+	for _, scenario := range scenarios {
+		t.Run(string(scenario.policy), func(t *testing.T) {
+			cache := NewCache().WithMaxSize(4).WithEvictionPolicy(scenario.policy)
+			cache.Set("1", "value")
+			cache.Set("2", "value")
+			cache.Set("3", "value")
+			cache.Set("4", "value")
+			cache.Get("1")
+			candidates := cache.EvictionCandidates(3)
+			if len(candidates) != len(scenario.expectedCandidates) {
+				t.Fatalf("expected %v, got %v", scenario.expectedCandidates, candidates)
+			}
+			for i, candidate := range candidates {
+				if candidate != scenario.expectedCandidates[i] {
+					t.Fatalf("expected %v, got %v", scenario.expectedCandidates, candidates)
+				}
+			}
+			// Make sure that the candidates are evicted in the same order as they were previewed
+			cache.mutex.Lock()
+			defer cache.mutex.Unlock()
+			for _, candidate := range candidates {
+				if evictionCandidate := cache.evictionCandidate(); evictionCandidate.Key != candidate {
+					t.Fatalf("expected %s to be the next entry evicted, got %s", candidate, evictionCandidate.Key)
+				}
+				cache.evict()
+			}
+		})
+	}
+}
+
+func TestCache_EvictionCandidatesIncludesHeadLast(t *testing.T) {
+	for _, policy := range []EvictionPolicy{FirstInFirstOut, LeastRecentlyUsed, MostRecentlyUsed, Random} {
+		t.Run(string(policy), func(t *testing.T) {
+			cache := NewCache().WithEvictionPolicy(policy)
+			cache.Set("1", "value")
+			cache.Set("2", "value")
+			cache.Set("3", "value")
+			cache.Pin("2")
+			candidates := cache.EvictionCandidates(10)
+			if len(candidates) != 2 || candidates[1] != "3" {
+				t.Errorf("expected the candidates to be 1 and then the head, 3, got %v", candidates)
+			}
+			if len(cache.EvictionCandidates(0)) != 0 {
+				t.Error("expected no candidates")
+			}
+		})
+	}
+}
+
+func TestCache_EvictionCandidatesWithPinnedEntry(t *testing.T) {
+	cache := NewCache()
+	cache.Set("1", "value")
+	cache.Set("2", "value")
+	cache.Pin("1")
+	if candidates := cache.EvictionCandidates(5); len(candidates) != 1 || candidates[0] != "2" {
+		t.Errorf("expected only 2 to be a candidate, got %v", candidates)
+	}
+}
+
+func TestCache_CanEvict(t *testing.T) {
+	if NewCache().WithMaxSize(NoMaxSize).CanEvict() {
+		t.Error("expected unbounded cache to not be able to evict")
+	}
+	if !NewCache().WithMaxSize(10).CanEvict() {
+		t.Error("expected cache with a max size to be able to evict")
+	}
+	if !NewCache().WithMaxSize(NoMaxSize).WithMaxMemoryUsage(Kilobyte).CanEvict() {
+		t.Error("expected cache with a max memory usage to be able to evict")
+	}
+}
+
+func TestCache_IsFull(t *testing.T) {
+	cache := NewCache().WithMaxSize(2)
+	cache.Set("1", "value")
+	if cache.IsFull() {
+		t.Error("expected cache to not be full")
+	}
+	cache.Set("2", "value")
+	if !cache.IsFull() {
+		t.Error("expected cache to be full")
+	}
+	unboundedCache := NewCache().WithMaxSize(NoMaxSize)
+	for i := 0; i < 100; i++ {
+		unboundedCache.Set(fmt.Sprintf("%d", i), "value")
+	}
+	if unboundedCache.IsFull() {
+		t.Error("expected unbounded cache to never be full")
+	}
+}
+
+func TestCache_WithEvictionBatchRatio(t *testing.T) {
+	cache := NewCache().WithMaxSize(10).WithEvictionBatchRatio(0.3)
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprintf("%d", i), "value")
+	}
+	cache.Set("new", "value")
+	if cache.Count() != 7 {
+		t.Errorf("expected cache to have been evicted down to 7 entries, got %d", cache.Count())
+	}
+	if _, ok := cache.Get("new"); !ok {
+		t.Error("expected the new entry to not have been evicted")
+	}
+	for i := 0; i < 4; i++ {
+		if _, ok := cache.Get(fmt.Sprintf("%d", i)); ok {
+			t.Errorf("expected key %d to have been evicted", i)
+		}
+	}
+	if cache.Stats().EvictedKeys != 4 {
+		t.Error("expected 4 keys to have been evicted, got", cache.Stats().EvictedKeys)
+	}
+}
+
+func TestCache_WithEvictionBatchRatioOfOne(t *testing.T) {
+	cache := NewCache().WithMaxSize(10).WithEvictionBatchRatio(1)
+	for i := 0; i < 11; i++ {
+		cache.Set(fmt.Sprintf("%d", i), "value")
+	}
+	if cache.Count() != 1 {
+		t.Errorf("expected only the new entry to be left, got %d entries", cache.Count())
+	}
+	if _, ok := cache.Get("10"); !ok {
+		t.Error("expected the new entry to not have been evicted")
+	}
+}
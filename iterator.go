@@ -0,0 +1,80 @@
+package gocache
+
+// CacheIterator is an iterator over the entries of a Cache
+//
+// The keys are captured when the iterator is created, but the values are only retrieved as Next is called, and the
+// cache is only locked for the duration of each retrieval. This lets the caller control the pace of the iteration,
+// but it also means that the iteration is weakly consistent:
+// - keys created after the iterator was created will not be returned
+// - keys deleted or expired after the iterator was created will be skipped
+// - values returned reflect the state of the cache at the time Next is called, not when the iterator was created
+type CacheIterator struct {
+	cache *Cache
+	keys  []string
+	index int
+}
+
+// Iterator returns a CacheIterator over all entries that have not expired
+//
+// Like GetKeysByPattern, iterating over the entries does not count as accessing them, meaning that it does not affect
+// the order in which entries are evicted if the eviction policy is LeastRecentlyUsed.
+func (cache *Cache) Iterator() *CacheIterator {
+	cache.mutex.RLock()
+	keys := make([]string, 0, len(cache.entries))
+	for key, entry := range cache.entries {
+		if !entry.Expired() {
+			keys = append(keys, key)
+		}
+	}
+	cache.mutex.RUnlock()
+	return &CacheIterator{cache: cache, keys: keys}
+}
+
+// Next returns the key and the value of the next entry
+//
+// Once there are no more entries to iterate over, the boolean returned will be false.
+// Like Get, a copy of the value is returned if the cache was configured with WithReturnCopies.
+func (iterator *CacheIterator) Next() (string, interface{}, bool) {
+	for iterator.index < len(iterator.keys) {
+		key := iterator.keys[iterator.index]
+		iterator.index++
+		iterator.cache.mutex.RLock()
+		entry, ok := iterator.cache.get(key)
+		if ok && !entry.Expired() {
+			value := entry.Value
+			iterator.cache.mutex.RUnlock()
+			if iterator.cache.options.ReturnCopies {
+				value = copyValue(value)
+			}
+			return key, value, true
+		}
+		iterator.cache.mutex.RUnlock()
+	}
+	return "", nil, false
+}
+
+// Range calls f for each entry that has not expired, until f returns false
+//
+// Unlike Iterator, no key is captured beforehand, which makes Range cheaper for large caches, but the cache is locked
+// for reads during the entire iteration. This means that f must not call any function of the cache that locks it for
+// writes, which includes Get and every function that modifies the cache, as it would deadlock, and that writes are
+// blocked until the iteration is over.
+//
+// Like Iterator, iterating over the entries does not count as accessing them, and the entries are not visited in any
+// particular order. Like Get, f is passed a copy of each value if the cache was configured with WithReturnCopies.
+func (cache *Cache) Range(f func(key string, value interface{}) bool) {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+	for key, entry := range cache.entries {
+		if entry.Expired() {
+			continue
+		}
+		value := entry.Value
+		if cache.options.ReturnCopies {
+			value = copyValue(value)
+		}
+		if !f(key, value) {
+			return
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package gocache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCache_Iterator(t *testing.T) {
+	cache := NewCache()
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), i)
+	}
+	collectedKeys := make(map[string]interface{})
+	iterator := cache.Iterator()
+	for {
+		key, value, ok := iterator.Next()
+		if !ok {
+			break
+		}
+		collectedKeys[key] = value
+	}
+	if len(collectedKeys) != 100 {
+		t.Errorf("expected 100 keys to have been collected, got %d", len(collectedKeys))
+	}
+	for i := 0; i < 100; i++ {
+		if value := collectedKeys[fmt.Sprintf("key%d", i)]; value != i {
+			t.Errorf("expected key%d to have value %d, got %v", i, i, value)
+		}
+	}
+}
+
+func TestCache_IteratorSkipsEntriesDeletedOrExpiredDuringIteration(t *testing.T) {
+	cache := NewCache()
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.SetWithTTL("key3", "value3", 5*time.Millisecond)
+	iterator := cache.Iterator()
+	cache.Delete("key1")
+	cache.Set("key4", "value4")
+	time.Sleep(10 * time.Millisecond)
+	key, value, ok := iterator.Next()
+	if !ok || key != "key2" || value != "value2" {
+		t.Errorf("expected key2 to be the only key returned, got %s", key)
+	}
+	if _, _, ok = iterator.Next(); ok {
+		t.Error("expected no more entries to be returned")
+	}
+}
+
+func TestCache_IteratorWithEmptyCache(t *testing.T) {
+	if _, _, ok := NewCache().Iterator().Next(); ok {
+		t.Error("expected no entries to be returned")
+	}
+}
+
+func TestCache_Range(t *testing.T) {
+	cache := NewCache()
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), i)
+	}
+	cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	collectedKeys := make(map[string]interface{})
+	cache.Range(func(key string, value interface{}) bool {
+		collectedKeys[key] = value
+		return true
+	})
+	if len(collectedKeys) != 100 {
+		t.Errorf("expected 100 keys to have been collected, got %d", len(collectedKeys))
+	}
+	if _, ok := collectedKeys["expired"]; ok {
+		t.Error("expected expired key to have been skipped")
+	}
+	for i := 0; i < 100; i++ {
+		if value := collectedKeys[fmt.Sprintf("key%d", i)]; value != i {
+			t.Errorf("expected key%d to have value %d, got %v", i, i, value)
+		}
+	}
+}
+
+func TestCache_RangeStopsWhenFunctionReturnsFalse(t *testing.T) {
+	cache := NewCache()
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), i)
+	}
+	numberOfCalls := 0
+	cache.Range(func(key string, value interface{}) bool {
+		numberOfCalls++
+		return numberOfCalls < 10
+	})
+	if numberOfCalls != 10 {
+		t.Error("expected the iteration to have stopped after 10 calls, got", numberOfCalls)
+	}
+}
+
+func TestCache_IteratorAndRangeWithReturnCopies(t *testing.T) {
+	cache := NewCache().WithReturnCopies(true)
+	cache.Set("key", []byte("value"))
+	_, value, _ := cache.Iterator().Next()
+	value.([]byte)[0] = 'V'
+	cache.Range(func(key string, value interface{}) bool {
+		value.([]byte)[1] = 'A'
+		return true
+	})
+	if value, _ := cache.Get("key"); string(value.([]byte)) != "value" {
+		t.Errorf("expected mutating the values returned by Iterator and Range to leave the cache untouched, got %s", value)
+	}
+}
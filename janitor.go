@@ -2,6 +2,7 @@ package gocache
 
 import (
 	"log"
+	"sync/atomic"
 	"time"
 )
 
@@ -32,6 +33,7 @@ func (cache *Cache) StartJanitor() error {
 		return ErrJanitorAlreadyRunning
 	}
 	cache.stopJanitor = make(chan bool)
+	atomic.AddInt32(&cache.backgroundWorkers, 1)
 	go func() {
 		// rather than starting from the tail on every run, we can try to start from the last traversed entry
 		var lastTraversedNode *Entry
@@ -70,8 +72,7 @@ func (cache *Cache) StartJanitor() error {
 							// Because delete will remove the previous reference from the entry, we need to store the
 							// previous reference before we delete it
 							previous = current.previous
-							cache.delete(current.Key)
-							cache.stats.ExpiredKeys++
+							cache.deleteExpired(current.Key)
 						}
 						if current == cache.head {
 							lastTraversedNode = nil
@@ -110,6 +111,7 @@ func (cache *Cache) StartJanitor() error {
 				}
 				cache.mutex.Unlock()
 			case <-cache.stopJanitor:
+				atomic.AddInt32(&cache.backgroundWorkers, -1)
 				cache.stopJanitor <- true
 				return
 			}
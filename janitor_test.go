@@ -48,10 +48,32 @@ func TestCache_StopJanitor(t *testing.T) {
 	cache.StopJanitor()
 }
 
+func TestCache_BackgroundWorkers(t *testing.T) {
+	cache := NewCache()
+	if cache.BackgroundWorkers() != 0 {
+		t.Error("expected no background workers, got", cache.BackgroundWorkers())
+	}
+	_ = cache.StartJanitor()
+	if cache.BackgroundWorkers() != 1 {
+		t.Error("expected the janitor to be running in the background, got", cache.BackgroundWorkers())
+	}
+	cache.StopJanitor()
+	if cache.BackgroundWorkers() != 0 {
+		t.Error("expected no background workers after stopping the janitor, got", cache.BackgroundWorkers())
+	}
+}
+
 func TestJanitor(t *testing.T) {
 	Debug = true
 	cache := NewCache().WithMaxSize(3 * JanitorMaxIterationsPerShift)
-	defer cache.Clear()
+	// The janitor reads Debug on every walk, so it must have stopped before Debug is reset
+	defer func() {
+		cache.StopJanitor()
+		if cache.BackgroundWorkers() != 0 {
+			t.Error("expected the janitor to have stopped, got", cache.BackgroundWorkers(), "background workers")
+		}
+		Debug = false
+	}()
 	for i := 0; i < 3*JanitorMaxIterationsPerShift; i++ {
 		if i < JanitorMaxIterationsPerShift && i%2 == 0 {
 			cache.SetWithTTL(fmt.Sprintf("%d", i), "value", time.Millisecond)
@@ -64,7 +86,6 @@ func TestJanitor(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer cache.StopJanitor()
 	time.Sleep(JanitorMinShiftBackOff * 4)
 	if cacheSize <= cache.Count() {
 		t.Error("The janitor should be deleting expired cache entries")
@@ -79,7 +100,6 @@ func TestJanitor(t *testing.T) {
 	if cacheSize <= cache.Count() {
 		t.Error("The janitor should be deleting expired cache entries")
 	}
-	Debug = false
 }
 
 func TestJanitorIsLoopingProperly(t *testing.T) {
@@ -115,3 +135,40 @@ func TestJanitorIsLoopingProperly(t *testing.T) {
 		t.Error("The janitor should've deleted 3 entries")
 	}
 }
+
+func TestJanitorWhenCacheIsClearedConcurrently(t *testing.T) {
+	cache := NewCache().WithMaxSize(NoMaxSize)
+	if err := cache.StartJanitor(); err != nil {
+		t.Fatal(err)
+	}
+	// Clearing the cache while the janitor is in the middle of a walk must not prevent it from walking the new entries
+	for i := 0; i < 10; i++ {
+		for j := 0; j < JanitorMaxIterationsPerShift; j++ {
+			cache.SetWithTTL(fmt.Sprintf("%d", j), "value", time.Millisecond)
+		}
+		time.Sleep(JanitorMinShiftBackOff / 2)
+		cache.Clear()
+	}
+	for j := 0; j < JanitorShiftTarget*2; j++ {
+		cache.SetWithTTL(fmt.Sprintf("%d", j), "value", time.Millisecond)
+	}
+	for start := time.Now(); cache.Count() > 0 && time.Since(start) < JanitorMaxShiftBackOff*10; {
+		time.Sleep(JanitorMinShiftBackOff)
+	}
+	if cache.Count() != 0 {
+		t.Errorf("expected the janitor to have deleted every expired entry, but %d entries are left", cache.Count())
+	}
+	stopped := make(chan struct{})
+	go func() {
+		cache.StopJanitor()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("expected the janitor to have stopped")
+	}
+	if cache.BackgroundWorkers() != 0 {
+		t.Error("expected no background workers after stopping the janitor, got", cache.BackgroundWorkers())
+	}
+}
@@ -0,0 +1,47 @@
+package gocache
+
+import "time"
+
+// AcquireLock sets the value of a key to the owner passed as parameter, but only if the key doesn't exist or has
+// expired, which makes it possible to use a cache entry as a lock that is released automatically after the TTL.
+//
+// Returns true if the lock was acquired, and false if it is already held, including when it is held by the same owner.
+func (cache *Cache) AcquireLock(key, owner string, ttl time.Duration) bool {
+	return cache.SetIfAbsentWithTTL(key, owner, ttl)
+}
+
+// ReleaseLock deletes a key, but only if its value is the owner passed as parameter
+//
+// Returns true if the lock was released, and false if it isn't held by the owner passed as parameter.
+func (cache *Cache) ReleaseLock(key, owner string) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if !cache.isLockOwner(key, owner) {
+		return false
+	}
+	return cache.delete(key)
+}
+
+// RefreshLock sets a new expiration time for a key, but only if its value is the owner passed as parameter
+//
+// Returns true if the lock was refreshed, and false if it isn't held by the owner passed as parameter.
+func (cache *Cache) RefreshLock(key, owner string, ttl time.Duration) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if !cache.isLockOwner(key, owner) {
+		return false
+	}
+	entry, _ := cache.get(key)
+	entry.expireIn(ttl)
+	return true
+}
+
+// isLockOwner returns whether the key passed as parameter exists, hasn't expired and has the owner as value
+func (cache *Cache) isLockOwner(key, owner string) bool {
+	entry, ok := cache.get(key)
+	if !ok || entry.Expired() {
+		return false
+	}
+	value, isString := entry.Value.(string)
+	return isString && value == owner
+}
@@ -0,0 +1,74 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache_AcquireLock(t *testing.T) {
+	cache := NewCache()
+	if !cache.AcquireLock("lock", "owner", time.Hour) {
+		t.Error("expected lock to have been acquired")
+	}
+	if cache.AcquireLock("lock", "other-owner", time.Hour) {
+		t.Error("expected lock to not be acquired because it is already held")
+	}
+	if cache.AcquireLock("lock", "owner", time.Hour) {
+		t.Error("expected lock to not be acquired because it is already held, even by the same owner")
+	}
+	if value, _ := cache.Get("lock"); value != "owner" {
+		t.Error("expected lock to be held by owner, got", value)
+	}
+}
+
+func TestCache_AcquireLockWhenLockHasExpired(t *testing.T) {
+	cache := NewCache()
+	cache.AcquireLock("lock", "owner", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	if !cache.AcquireLock("lock", "other-owner", time.Hour) {
+		t.Error("expected lock to have been acquired because the previous lock has expired")
+	}
+	if value, _ := cache.Get("lock"); value != "other-owner" {
+		t.Error("expected lock to be held by other-owner, got", value)
+	}
+}
+
+func TestCache_RefreshLock(t *testing.T) {
+	cache := NewCache()
+	cache.AcquireLock("lock", "owner", time.Minute)
+	if cache.RefreshLock("lock", "other-owner", time.Hour) {
+		t.Error("expected lock to not be refreshed by an owner that doesn't hold it")
+	}
+	if ttl, _ := cache.TTL("lock"); ttl > time.Minute {
+		t.Error("expected TTL to have been left untouched, got", ttl)
+	}
+	if !cache.RefreshLock("lock", "owner", time.Hour) {
+		t.Error("expected lock to have been refreshed")
+	}
+	if ttl, _ := cache.TTL("lock"); ttl <= time.Minute {
+		t.Error("expected TTL to have been extended, got", ttl)
+	}
+	if cache.RefreshLock("lock-that-does-not-exist", "owner", time.Hour) {
+		t.Error("expected lock that doesn't exist to not be refreshed")
+	}
+}
+
+func TestCache_ReleaseLock(t *testing.T) {
+	cache := NewCache()
+	cache.AcquireLock("lock", "owner", time.Hour)
+	if cache.ReleaseLock("lock", "other-owner") {
+		t.Error("expected lock to not be released by an owner that doesn't hold it")
+	}
+	if _, ok := cache.Get("lock"); !ok {
+		t.Error("expected lock to still be held")
+	}
+	if !cache.ReleaseLock("lock", "owner") {
+		t.Error("expected lock to have been released")
+	}
+	if _, ok := cache.Get("lock"); ok {
+		t.Error("expected lock to no longer exist")
+	}
+	if !cache.AcquireLock("lock", "other-owner", time.Hour) {
+		t.Error("expected lock to be acquirable after having been released")
+	}
+}
@@ -0,0 +1,78 @@
+package gocache
+
+import "sync/atomic"
+
+// WithAsyncOnEvict sets whether the function configured through WithOnEvict should be called by a goroutine running
+// in the background rather than by the goroutine that caused the eviction.
+//
+// When a single operation evicts a large number of entries, such as a large SetAll or ReadFromFile, that operation
+// would otherwise only return once the function has been called for every entry it evicted. Instead, the entries
+// evicted are queued, and a single worker calls the function for each of them in the order in which they were
+// evicted, which means that the operation returns without waiting for the function.
+//
+// The queue is never dropped, so every eviction is delivered at least once, but possibly after the operation that
+// caused it returned, and possibly after the key has been set again. The function may freely use the cache, since
+// it is called without the cache's lock held.
+//
+// Passing false stops the worker once the function has been called for every entry queued, after which the function
+// is called synchronously again. Note that this must not be done from the function itself.
+//
+// Defaults to false
+func (cache *Cache) WithAsyncOnEvict(asyncOnEvict bool) *Cache {
+	cache.stopAsyncOnEvictWorker()
+	if asyncOnEvict {
+		signal := make(chan bool, 1)
+		cache.mutex.Lock()
+		cache.onEvictSignal = signal
+		cache.mutex.Unlock()
+		cache.stopAsyncOnEvict = make(chan bool)
+		atomic.AddInt32(&cache.backgroundWorkers, 1)
+		go func() {
+			for {
+				select {
+				case <-signal:
+					cache.callOnEvictForQueuedEntries(false)
+				case <-cache.stopAsyncOnEvict:
+					cache.callOnEvictForQueuedEntries(true)
+					atomic.AddInt32(&cache.backgroundWorkers, -1)
+					cache.stopAsyncOnEvict <- true
+					return
+				}
+			}
+		}()
+	}
+	return cache
+}
+
+// stopAsyncOnEvictWorker stops the goroutine that calls the function configured through WithOnEvict, if it is running
+func (cache *Cache) stopAsyncOnEvictWorker() {
+	if cache.stopAsyncOnEvict != nil {
+		// Like StopJanitor, wait for the goroutine to reply before forgetting about the channel
+		cache.stopAsyncOnEvict <- true
+		<-cache.stopAsyncOnEvict
+		cache.stopAsyncOnEvict = nil
+	}
+}
+
+// callOnEvictForQueuedEntries calls the function configured through WithOnEvict for every entry queued by
+// unlockAndCallOnEvict, until the queue is empty
+//
+// If stopping is true, the entries evicted once the queue is empty are no longer queued, but passed to the function
+// synchronously instead.
+func (cache *Cache) callOnEvictForQueuedEntries(stopping bool) {
+	for {
+		cache.mutex.Lock()
+		queuedEntries := cache.onEvictQueue
+		cache.onEvictQueue = nil
+		if len(queuedEntries) == 0 && stopping {
+			cache.onEvictSignal = nil
+		}
+		cache.mutex.Unlock()
+		if len(queuedEntries) == 0 {
+			return
+		}
+		for _, entry := range queuedEntries {
+			cache.onEvict(entry.Key, entry.Value)
+		}
+	}
+}
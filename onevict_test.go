@@ -0,0 +1,53 @@
+package gocache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCache_WithAsyncOnEvict(t *testing.T) {
+	var (
+		mutex       sync.Mutex
+		evictedKeys []string
+	)
+	release := make(chan bool)
+	cache := NewCache().WithMaxSize(10)
+	cache.WithOnEvict(func(key string, value interface{}) {
+		<-release
+		// The cache must not be locked while the function is called
+		cache.Get(key)
+		mutex.Lock()
+		evictedKeys = append(evictedKeys, key)
+		mutex.Unlock()
+	}).WithAsyncOnEvict(true)
+	if cache.BackgroundWorkers() != 1 {
+		t.Error("expected the worker to be running in the background, got", cache.BackgroundWorkers(), "background workers")
+	}
+	// None of these must wait for the function, which is blocked until release is closed
+	for i := 0; i < 1000; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), i)
+	}
+	if cache.Count() != 10 {
+		t.Error("expected 10 entries, got", cache.Count())
+	}
+	close(release)
+	// Stopping the worker waits for every queued eviction to have been delivered
+	cache.WithAsyncOnEvict(false)
+	if cache.BackgroundWorkers() != 0 {
+		t.Error("expected the worker to have been stopped, got", cache.BackgroundWorkers(), "background workers")
+	}
+	if len(evictedKeys) != 990 {
+		t.Fatal("expected the function to have been called for each of the 990 entries evicted, got", len(evictedKeys))
+	}
+	for i, key := range evictedKeys {
+		if expectedKey := fmt.Sprintf("key%d", i); key != expectedKey {
+			t.Fatalf("expected evictions to be delivered in order, got %s at position %d instead of %s", key, i, expectedKey)
+		}
+	}
+	// Once stopped, the function is called synchronously again
+	cache.Set("key1000", 1000)
+	if len(evictedKeys) != 991 || evictedKeys[990] != "key990" {
+		t.Error("expected key990 to have been evicted synchronously, got", evictedKeys[990:])
+	}
+}
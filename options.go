@@ -0,0 +1,112 @@
+package gocache
+
+import "time"
+
+// Options is the configuration of a Cache, as an alternative to configuring a Cache using the WithX functions
+//
+// Unlike the WithX functions, every field is applied as is, including zero values, which means that an Options
+// created from scratch results in a cache with no maximum size. To only override some of the defaults, start from
+// DefaultOptions instead, e.g. by decoding a configuration file into the Options returned by DefaultOptions.
+type Options struct {
+	// MaxSize is the maximum amount of entries that can be in the cache at any given time.
+	// See Cache.WithMaxSize
+	MaxSize int
+
+	// MaxMemoryUsage is the maximum amount of memory in bytes that can be used by the cache at any given time.
+	// See Cache.WithMaxMemoryUsage
+	MaxMemoryUsage int
+
+	// EvictionPolicy is the eviction algorithm used when the cache is full.
+	// See Cache.WithEvictionPolicy
+	EvictionPolicy EvictionPolicy
+
+	// ForceNilInterfaceOnNilPointer determines whether nil pointers passed to Set-like functions are stored as nil.
+	// See Cache.WithForceNilInterfaceOnNilPointer
+	ForceNilInterfaceOnNilPointer bool
+
+	// RejectNewEntriesWhenFullyPinned determines whether new entries are rejected when every other entry is pinned.
+	// See Cache.WithRejectNewEntriesWhenFullyPinned
+	RejectNewEntriesWhenFullyPinned bool
+
+	// ReturnCopies determines whether Get-like functions return a deep copy of slices and maps.
+	// See Cache.WithReturnCopies
+	ReturnCopies bool
+
+	// EvictionBatchRatio is the fraction of MaxSize freed at once whenever an eviction is needed.
+	// See Cache.WithEvictionBatchRatio
+	EvictionBatchRatio float64
+
+	// InitialCapacity is the number of entries to preallocate space for.
+	// See Cache.WithInitialCapacity
+	InitialCapacity int
+
+	// SlidingExpiration determines whether accessing an entry extends its expiration time.
+	// See Cache.WithSlidingExpiration
+	SlidingExpiration bool
+
+	// DefaultTTL is the TTL used by the functions that don't take a TTL as parameter, such as Set.
+	// A TTL of 0 or lower means that there is no default TTL. See Cache.WithDefaultTTL
+	DefaultTTL time.Duration
+
+	// PersistenceCompression determines whether the files written by SaveToFile and SaveToFileAs are compressed.
+	// See Cache.WithPersistenceCompression
+	PersistenceCompression bool
+}
+
+// DefaultOptions returns the Options of a Cache created with NewCache
+func DefaultOptions() Options {
+	return Options{
+		MaxSize:                       DefaultMaxSize,
+		MaxMemoryUsage:                NoMaxMemoryUsage,
+		EvictionPolicy:                FirstInFirstOut,
+		ForceNilInterfaceOnNilPointer: true,
+	}
+}
+
+// NewCacheWithOptions creates a new Cache configured with the Options passed as parameter
+//
+// The same rules as the corresponding WithX functions apply to each field, e.g. if no eviction policy is specified,
+// FirstInFirstOut is used.
+func NewCacheWithOptions(options Options) *Cache {
+	// Like WithMaxSize, space for the max size of the cache is preallocated, unless an initial capacity is specified
+	capacity := options.InitialCapacity
+	if capacity <= 0 && options.MaxSize > 0 {
+		capacity = options.MaxSize
+	}
+	return newCache(options, capacity)
+}
+
+// newCache creates a new Cache configured with the Options passed as parameter, preallocating enough space for the
+// given number of entries
+func newCache(options Options, capacity int) *Cache {
+	options.normalize()
+	return &Cache{
+		options: options,
+		stats:   &Statistics{},
+		entries: make(map[string]*Entry, capacity),
+	}
+}
+
+// normalize replaces the values that are out of range by the closest value allowed, and the values that are unset
+// by their default, if the zero value isn't a valid value
+//
+// This is applied every time a field is set, whether it is through a WithX function or through NewCacheWithOptions.
+func (options *Options) normalize() {
+	if options.MaxSize < 0 {
+		options.MaxSize = NoMaxSize
+	}
+	if options.MaxMemoryUsage < 0 {
+		options.MaxMemoryUsage = NoMaxMemoryUsage
+	}
+	if options.EvictionPolicy == "" {
+		options.EvictionPolicy = FirstInFirstOut
+	}
+	if options.EvictionBatchRatio < 0 {
+		options.EvictionBatchRatio = 0
+	} else if options.EvictionBatchRatio > 1 {
+		options.EvictionBatchRatio = 1
+	}
+	if options.DefaultTTL <= 0 {
+		options.DefaultTTL = NoExpiration
+	}
+}
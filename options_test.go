@@ -0,0 +1,104 @@
+package gocache
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNewCacheWithOptions(t *testing.T) {
+	cache := NewCacheWithOptions(Options{
+		MaxSize:                         10,
+		MaxMemoryUsage:                  Megabyte,
+		EvictionPolicy:                  LeastRecentlyUsed,
+		ForceNilInterfaceOnNilPointer:   false,
+		RejectNewEntriesWhenFullyPinned: true,
+		ReturnCopies:                    true,
+		EvictionBatchRatio:              0.5,
+		PersistenceCompression:          true,
+		DefaultTTL:                      time.Minute,
+		SlidingExpiration:               true,
+	})
+	if cache.MaxSize() != 10 {
+		t.Error("expected MaxSize to be 10, got", cache.MaxSize())
+	}
+	if cache.MaxMemoryUsage() != Megabyte {
+		t.Error("expected MaxMemoryUsage to be 1MB, got", cache.MaxMemoryUsage())
+	}
+	if cache.EvictionPolicy() != LeastRecentlyUsed {
+		t.Error("expected EvictionPolicy to be LeastRecentlyUsed, got", cache.EvictionPolicy())
+	}
+	if cache.options.ForceNilInterfaceOnNilPointer {
+		t.Error("expected forceNilInterfaceOnNilPointer to be false")
+	}
+	if !cache.options.RejectNewEntriesWhenFullyPinned {
+		t.Error("expected rejectNewEntriesWhenFullyPinned to be true")
+	}
+	if !cache.options.ReturnCopies {
+		t.Error("expected returnCopies to be true")
+	}
+	if cache.options.EvictionBatchRatio != 0.5 {
+		t.Error("expected evictionBatchRatio to be 0.5, got", cache.options.EvictionBatchRatio)
+	}
+	if !cache.options.PersistenceCompression {
+		t.Error("expected persistenceCompression to be true")
+	}
+	if cache.options.DefaultTTL != time.Minute {
+		t.Error("expected defaultTTL to be 1m, got", cache.options.DefaultTTL)
+	}
+	if !cache.options.SlidingExpiration {
+		t.Error("expected slidingExpiration to be true")
+	}
+}
+
+func TestNewCacheWithOptionsWhenDecodedFromConfiguration(t *testing.T) {
+	options := DefaultOptions()
+	if err := json.Unmarshal([]byte(`{"MaxSize": 500, "EvictionPolicy": "LeastRecentlyUsed"}`), &options); err != nil {
+		t.Fatal(err)
+	}
+	cache := NewCacheWithOptions(options)
+	if cache.MaxSize() != 500 {
+		t.Error("expected MaxSize to be 500, got", cache.MaxSize())
+	}
+	if cache.EvictionPolicy() != LeastRecentlyUsed {
+		t.Error("expected EvictionPolicy to be LeastRecentlyUsed, got", cache.EvictionPolicy())
+	}
+	// Fields that weren't part of the configuration must have kept their default value
+	if cache.MaxMemoryUsage() != NoMaxMemoryUsage {
+		t.Error("expected MaxMemoryUsage to be NoMaxMemoryUsage, got", cache.MaxMemoryUsage())
+	}
+	if !cache.options.ForceNilInterfaceOnNilPointer {
+		t.Error("expected forceNilInterfaceOnNilPointer to be true")
+	}
+}
+
+func TestNewCacheWithOptionsWithDefaultOptions(t *testing.T) {
+	cache := NewCacheWithOptions(DefaultOptions())
+	defaultCache := NewCache()
+	if cache.options != defaultCache.options {
+		t.Errorf("expected a cache created with the default options to be configured like a cache created with NewCache, got %+v and %+v", cache.options, defaultCache.options)
+	}
+}
+
+func TestNewCacheWithOptionsIsConfiguredLikeWithFunctions(t *testing.T) {
+	cache := NewCacheWithOptions(Options{
+		MaxSize:            -1,
+		MaxMemoryUsage:     -1,
+		EvictionBatchRatio: 2,
+		DefaultTTL:         -5 * time.Second,
+	})
+	cacheConfiguredWithFunctions := NewCache().
+		WithMaxSize(-1).
+		WithMaxMemoryUsage(-1).
+		WithEvictionPolicy("").
+		WithForceNilInterfaceOnNilPointer(false).
+		WithEvictionBatchRatio(2).
+		WithDefaultTTL(-5 * time.Second)
+	if cache.options != cacheConfiguredWithFunctions.options {
+		t.Errorf("expected both caches to have the same configuration, got %+v and %+v", cache.options, cacheConfiguredWithFunctions.options)
+	}
+	if cache.MaxSize() != NoMaxSize || cache.MaxMemoryUsage() != NoMaxMemoryUsage || cache.EvictionPolicy() != FirstInFirstOut ||
+		cache.options.EvictionBatchRatio != 1 || cache.options.DefaultTTL != NoExpiration {
+		t.Errorf("expected the out of range values to have been replaced, got %+v", cache.options)
+	}
+}
@@ -1,42 +1,123 @@
 package gocache
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"encoding/gob"
+	"encoding/json"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"reflect"
 	"sort"
 	"time"
 
 	bolt "go.etcd.io/bbolt"
 )
 
+// SerializationFormat is the format used to store the content of the cache to a file
+type SerializationFormat int
+
+const (
+	// Gob stores the content of the cache in a bbolt database, with every entry encoded using the gob package.
+	// This is the format used by SaveToFile and ReadFromFile.
+	Gob SerializationFormat = iota
+
+	// JSON stores the content of the cache in a human-readable JSON document, which can be read by tools that are
+	// not written in Go. See SaveToFileAs for the limitations of this format.
+	JSON
+)
+
+// jsonSnapshotVersion is the version of the JSON document written by SaveToFileAs, which must be incremented
+// whenever a breaking change is made to jsonSnapshot or jsonEntry
+const jsonSnapshotVersion = 1
+
+// jsonSnapshot is the JSON document written by SaveToFileAs when using the JSON format
+type jsonSnapshot struct {
+	Version int         `json:"version"`
+	Entries []jsonEntry `json:"entries"`
+}
+
+// jsonEntry is the representation of an Entry in a jsonSnapshot
+type jsonEntry struct {
+	Key string `json:"key"`
+
+	// Type is the Go type of the value, if it is one of the types listed in jsonValueTypes. Otherwise, it is omitted
+	// and the value is decoded as whatever encoding/json decodes it into when the destination is an interface{}.
+	Type string `json:"type,omitempty"`
+
+	Value             json.RawMessage `json:"value"`
+	RelevantTimestamp time.Time       `json:"relevantTimestamp"`
+	Sequence          uint64          `json:"sequence"`
+	Expiration        int64           `json:"expiration"`
+	TTL               time.Duration   `json:"ttl,omitempty"`
+}
+
+// jsonValueTypes are the types of values that are restored with their exact type when reading a JSON snapshot,
+// indexed by their name
+var jsonValueTypes = make(map[string]reflect.Type)
+
+func init() {
+	for _, value := range []interface{}{"", []byte(nil), false, 0, int8(0), int16(0), int32(0), int64(0), uint(0), uint8(0), uint16(0), uint32(0), uint64(0), float32(0), float64(0)} {
+		jsonValueTypes[reflect.TypeOf(value).String()] = reflect.TypeOf(value)
+	}
+}
+
 // SaveToFile stores the content of the cache to a file so that it can be read using
 // the ReadFromFile function
+//
+// The file contains a snapshot of the cache as it was at a single point in time: the entries are copied while holding
+// the lock, and are only encoded and written to the file once the lock has been released, which means that writes
+// are only blocked for the duration of the copy, not for the entire save.
+// Note that only the entries are copied, not their values. Values must therefore not be mutated while the cache is
+// being saved, which is already the case if the values stored are never mutated after being set.
+//
+// If the cache was configured using WithPersistenceCompression, the file is compressed using gzip.
 func (cache *Cache) SaveToFile(path string) error {
-	db, err := bolt.Open(path, os.ModePerm, nil)
+	compressed, err := isCompressedFile(path)
 	if err != nil {
 		return err
 	}
-	start := time.Now()
-	cache.mutex.RLock()
-	bulkEntries := make([]*Entry, len(cache.entries))
-	i := 0
-	for _, v := range cache.entries {
-		bulkEntries[i] = v
-		i++
+	if cache.options.PersistenceCompression {
+		// bbolt can only work with an actual file, so the database is written to a temporary file first, and then
+		// compressed into the destination file
+		temporaryFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+		if err != nil {
+			return err
+		}
+		temporaryFile.Close()
+		defer os.Remove(temporaryFile.Name())
+		if err = cache.saveToBoltFile(temporaryFile.Name()); err != nil {
+			return err
+		}
+		return compressFile(temporaryFile.Name(), path)
 	}
-	cache.mutex.RUnlock()
-	if Debug {
-		log.Printf("unlocked after %s", time.Since(start))
+	if compressed {
+		// The file was written with compression enabled, so it can't be opened by bbolt and must be replaced instead
+		if err = os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return cache.saveToBoltFile(path)
+}
+
+// saveToBoltFile stores the content of the cache to a bbolt database, without compression
+func (cache *Cache) saveToBoltFile(path string) error {
+	db, err := bolt.Open(path, os.ModePerm, nil)
+	if err != nil {
+		return err
 	}
+	bulkEntries := cache.copyEntries()
 	err = db.Update(func(tx *bolt.Tx) error {
 		_ = tx.DeleteBucket([]byte("entries"))
 		bucket, err := tx.CreateBucket([]byte("entries"))
 		if err != nil {
 			return err
 		}
-		for _, bulkEntry := range bulkEntries {
+		for i := range bulkEntries {
+			bulkEntry := &bulkEntries[i]
 			buffer := bytes.Buffer{}
 			err = gob.NewEncoder(&buffer).Encode(bulkEntry)
 			if err != nil {
@@ -55,6 +136,104 @@ func (cache *Cache) SaveToFile(path string) error {
 	return db.Close()
 }
 
+// SaveToFileAs stores the content of the cache to a file using the format passed as parameter, so that it can be
+// read using the ReadFromFileAs function with the same format
+//
+// Using Gob is the same as using SaveToFile.
+//
+// Using JSON writes a versioned JSON document that can be read by tools that are not written in Go, at the cost of
+// losing the type of some values. Strings, []byte, booleans and numbers are restored with their exact type, but
+// any other value is restored as whatever encoding/json produces when decoding into an interface{}: structs and maps
+// become map[string]interface{}, slices become []interface{} and the numbers they contain become float64.
+// Values that cannot be encoded to JSON, such as channels and functions, are skipped.
+func (cache *Cache) SaveToFileAs(path string, format SerializationFormat) error {
+	switch format {
+	case Gob:
+		return cache.SaveToFile(path)
+	case JSON:
+		return cache.saveToJSONFile(path)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// saveToJSONFile stores the content of the cache to a file using the JSON format
+func (cache *Cache) saveToJSONFile(path string) error {
+	bulkEntries := cache.copyEntries()
+	snapshot := jsonSnapshot{Version: jsonSnapshotVersion, Entries: make([]jsonEntry, 0, len(bulkEntries))}
+	for i := range bulkEntries {
+		value, err := json.Marshal(bulkEntries[i].Value)
+		if err != nil {
+			// Failed to encode the value, so we'll skip it, just like SaveToFile does.
+			continue
+		}
+		entry := jsonEntry{
+			Key:               bulkEntries[i].Key,
+			Value:             value,
+			RelevantTimestamp: bulkEntries[i].RelevantTimestamp,
+			Sequence:          bulkEntries[i].Sequence,
+			Expiration:        bulkEntries[i].Expiration,
+			TTL:               bulkEntries[i].TTL,
+		}
+		if bulkEntries[i].Value != nil {
+			if _, ok := jsonValueTypes[reflect.TypeOf(bulkEntries[i].Value).String()]; ok {
+				entry.Type = reflect.TypeOf(bulkEntries[i].Value).String()
+			}
+		}
+		snapshot.Entries = append(snapshot.Entries, entry)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	var writer io.Writer = file
+	var gzipWriter *gzip.Writer
+	if cache.options.PersistenceCompression {
+		gzipWriter = gzip.NewWriter(file)
+		writer = gzipWriter
+	}
+	bufferedWriter := bufio.NewWriter(writer)
+	encoder := json.NewEncoder(bufferedWriter)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(snapshot); err != nil {
+		file.Close()
+		return err
+	}
+	if err = bufferedWriter.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if gzipWriter != nil {
+		if err = gzipWriter.Close(); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
+
+// copyEntries returns a copy of every entry in the cache, without their references to other entries
+//
+// The lock is only held for the duration of the copy, which means that the entries returned are a snapshot of the
+// cache as it was at a single point in time.
+func (cache *Cache) copyEntries() []Entry {
+	start := time.Now()
+	cache.mutex.RLock()
+	bulkEntries := make([]Entry, len(cache.entries))
+	i := 0
+	for _, v := range cache.entries {
+		bulkEntries[i] = *v
+		// The references to other entries are not persisted, and they may change once the lock is released
+		bulkEntries[i].next, bulkEntries[i].previous = nil, nil
+		i++
+	}
+	cache.mutex.RUnlock()
+	if Debug {
+		log.Printf("unlocked after %s", time.Since(start))
+	}
+	return bulkEntries
+}
+
 // ReadFromFile populates the cache using a file created using cache.SaveToFile(path)
 //
 // Note that if the number of entries retrieved from the file exceed the configured maxSize,
@@ -62,14 +241,42 @@ func (cache *Cache) SaveToFile(path string) error {
 // This function returns the number of entries evicted, and because this function only reads
 // from a file and does not modify it, you can safely retry this function after configuring
 // the cache with the appropriate maxSize, should you desire to.
+//
+// Files compressed using gzip, which is the case if the cache used to save the file was configured using
+// WithPersistenceCompression, are detected and decompressed automatically.
+//
+// Every entry of the cache, including the ones that were already in it, is given a new sequence number reflecting its
+// position, which means that the iterations of Scan in progress may skip or repeat keys.
 func (cache *Cache) ReadFromFile(path string) (int, error) {
+	compressed, err := isCompressedFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if compressed {
+		// bbolt can only work with an actual file, so the file is decompressed into a temporary file first
+		temporaryFile, err := os.CreateTemp("", "gocache-*.tmp")
+		if err != nil {
+			return 0, err
+		}
+		temporaryFile.Close()
+		defer os.Remove(temporaryFile.Name())
+		if err = decompressFile(path, temporaryFile.Name()); err != nil {
+			return 0, err
+		}
+		return cache.readFromBoltFile(temporaryFile.Name())
+	}
+	return cache.readFromBoltFile(path)
+}
+
+// readFromBoltFile populates the cache using a bbolt database that isn't compressed
+func (cache *Cache) readFromBoltFile(path string) (int, error) {
 	db, err := bolt.Open(path, os.ModePerm, nil)
 	if err != nil {
 		return 0, err
 	}
 	defer db.Close()
 	cache.mutex.Lock()
-	defer cache.mutex.Unlock()
+	defer cache.unlockAndCallOnEvict()
 	err = db.View(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte("entries"))
 		// If the bucket doesn't exist, there's nothing to read, so we'll return right now
@@ -94,7 +301,8 @@ func (cache *Cache) ReadFromFile(path string) (int, error) {
 				// See [Persistence - Limitations](https://github.com/TwinProduction/gocache#limitations)
 				return err
 			}
-			cache.entries[string(k)] = &entry
+			entry.Key = string(k)
+			cache.addEntryReadFromFile(&entry)
 			buffer.Reset()
 			return nil
 		})
@@ -103,19 +311,129 @@ func (cache *Cache) ReadFromFile(path string) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	return cache.relinkAndEvict(), nil
+}
+
+// ReadFromFileAs populates the cache using a file created using cache.SaveToFileAs(path, format)
+//
+// Using Gob is the same as using ReadFromFile. The same rules as ReadFromFile apply regardless of the format,
+// including the number of entries evicted returned.
+func (cache *Cache) ReadFromFileAs(path string, format SerializationFormat) (int, error) {
+	switch format {
+	case Gob:
+		return cache.ReadFromFile(path)
+	case JSON:
+		return cache.readFromJSONFile(path)
+	default:
+		return 0, ErrUnsupportedFormat
+	}
+}
+
+// readFromJSONFile populates the cache using a file created using the JSON format
+func (cache *Cache) readFromJSONFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	var decoder *json.Decoder
+	if header, _ := reader.Peek(len(gzipMagicHeader)); bytes.Equal(header, gzipMagicHeader) {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return 0, err
+		}
+		defer gzipReader.Close()
+		decoder = json.NewDecoder(gzipReader)
+	} else {
+		decoder = json.NewDecoder(reader)
+	}
+	var snapshot jsonSnapshot
+	if err = decoder.Decode(&snapshot); err != nil {
+		return 0, err
+	}
+	if snapshot.Version != jsonSnapshotVersion {
+		return 0, ErrUnsupportedFormat
+	}
+	entries := make([]*Entry, 0, len(snapshot.Entries))
+	for _, jsonEntry := range snapshot.Entries {
+		var value interface{}
+		if valueType, ok := jsonValueTypes[jsonEntry.Type]; ok {
+			pointer := reflect.New(valueType)
+			if err = json.Unmarshal(jsonEntry.Value, pointer.Interface()); err != nil {
+				return 0, err
+			}
+			value = pointer.Elem().Interface()
+		} else if err = json.Unmarshal(jsonEntry.Value, &value); err != nil {
+			return 0, err
+		}
+		entries = append(entries, &Entry{
+			Key:               jsonEntry.Key,
+			Value:             value,
+			RelevantTimestamp: jsonEntry.RelevantTimestamp,
+			Sequence:          jsonEntry.Sequence,
+			Expiration:        jsonEntry.Expiration,
+			TTL:               jsonEntry.TTL,
+		})
+	}
+	cache.mutex.Lock()
+	defer cache.unlockAndCallOnEvict()
+	for _, entry := range entries {
+		cache.addEntryReadFromFile(entry)
+	}
+	return cache.relinkAndEvict(), nil
+}
+
+// addEntryReadFromFile adds an entry that has been read from a file to the cache, replacing the entry with the same
+// key, if any
+//
+// Like updating an entry through set, the entry replaced is unlinked and no longer counts towards the memory usage,
+// whereas the new entry is only linked and counted by relinkAndEvict, once every entry has been read.
+// The caller is responsible for locking the cache.
+func (cache *Cache) addEntryReadFromFile(entry *Entry) {
+	if existingEntry, ok := cache.get(entry.Key); ok {
+		cache.removeFromMemoryUsage(existingEntry)
+		cache.removeExistingEntryReferences(existingEntry)
+	}
+	cache.entries[entry.Key] = entry
+}
+
+// relinkAndEvict links every entry of the cache from head to tail after they've been read from a file, and evicts
+// entries if the cache has more entries than it is allowed to
+//
+// The caller is responsible for locking the cache.
+// Returns the number of entries evicted.
+func (cache *Cache) relinkAndEvict() int {
 	// Because pointers don't get stored in the file, we need to relink everything from head to tail
 	var entries []*Entry
 	for _, v := range cache.entries {
 		entries = append(entries, v)
 	}
-	// Sort the slice of entries from oldest to newest
+	// Sort the slice of entries from oldest to newest.
+	// The sequence number is updated every time the RelevantTimestamp is, which means that it reflects the exact order
+	// of the entries regardless of the eviction policy: the insertion order under FirstInFirstOut, and the recency under
+	// LeastRecentlyUsed. Unlike the timestamp, it is immune to the resolution of the clock and to the clock going
+	// backward, so it is used as the ordering key. However, entries saved before sequence numbers were introduced all
+	// have a sequence number of 0, in which case the timestamp is the only option left.
+	useSequence := true
+	for _, entry := range entries {
+		if entry.Sequence == 0 {
+			useSequence = false
+			break
+		}
+	}
 	sort.Slice(entries, func(i, j int) bool {
+		if useSequence || entries[i].RelevantTimestamp.Equal(entries[j].RelevantTimestamp) {
+			return entries[i].Sequence < entries[j].Sequence
+		}
 		return entries[i].RelevantTimestamp.Before(entries[j].RelevantTimestamp)
 	})
 	// Relink the nodes from tail to head
 	var previous *Entry
 	for i := range entries {
 		current := entries[i]
+		// The entries that were already in the cache may still reference the entries they were linked to before
+		current.next, current.previous = nil, nil
 		if previous == nil {
 			cache.tail = current
 			cache.head = current
@@ -125,30 +443,108 @@ func (cache *Cache) ReadFromFile(path string) (int, error) {
 			cache.head = current
 		}
 		previous = entries[i]
-		if cache.maxMemoryUsage != NoMaxMemoryUsage {
-			cache.memoryUsage += current.SizeInBytes()
+		// The entries that were already in the cache already count towards the memory usage
+		if current.sizeInBytes == 0 {
+			cache.addToMemoryUsage(current)
 		}
 	}
+	// Entries saved before sequence numbers were introduced all have a sequence number of 0, and the sequence numbers
+	// of entries read into a cache that already had entries may collide with the existing ones, so the entries are
+	// renumbered in the order in which they were just linked. Scan relies on the sequence numbers being unique and
+	// increasing from the tail to the head, so the cursors of the iterations in progress are forgotten as well.
+	for i, entry := range entries {
+		entry.Sequence = uint64(i + 1)
+	}
+	cache.sequence = uint64(len(entries))
+	cache.scanCursors = nil
 	// If the cache doesn't have a maxSize/maxMemoryUsage, then there's no point checking if we need to evict
 	// an entry, so we'll just return now
-	if cache.maxSize == NoMaxSize && cache.maxMemoryUsage == NoMaxMemoryUsage {
-		return 0, nil
+	if cache.options.MaxSize == NoMaxSize && cache.options.MaxMemoryUsage == NoMaxMemoryUsage {
+		return 0
 	}
 	// Evict what needs to be evicted
 	numberOfEvictions := 0
 	// If there's a maxSize and the cache has more entries than the maxSize, evict
-	if cache.maxSize != NoMaxSize && len(cache.entries) > cache.maxSize {
-		for len(cache.entries) > cache.maxSize {
+	if cache.options.MaxSize != NoMaxSize && len(cache.entries) > cache.options.MaxSize {
+		for len(cache.entries) > cache.options.MaxSize && cache.evict() {
 			numberOfEvictions++
-			cache.evict()
 		}
 	}
 	// If there's a maxMemoryUsage and the memoryUsage is above the maxMemoryUsage, evict
-	if cache.maxMemoryUsage != NoMaxMemoryUsage && cache.memoryUsage > cache.maxMemoryUsage {
-		for cache.memoryUsage > cache.maxMemoryUsage && len(cache.entries) > 0 {
+	if cache.options.MaxMemoryUsage != NoMaxMemoryUsage && cache.memoryUsage > cache.options.MaxMemoryUsage {
+		for cache.memoryUsage > cache.options.MaxMemoryUsage && len(cache.entries) > 0 && cache.evict() {
 			numberOfEvictions++
-			cache.evict()
 		}
 	}
-	return numberOfEvictions, nil
+	return numberOfEvictions
+}
+
+// gzipMagicHeader is the sequence of bytes every file compressed using gzip starts with
+var gzipMagicHeader = []byte{0x1f, 0x8b}
+
+// isCompressedFile returns whether the file at the given path is compressed using gzip
+//
+// A file that doesn't exist is not considered as compressed.
+func isCompressedFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+	header := make([]byte, len(gzipMagicHeader))
+	if _, err = io.ReadFull(file, header); err != nil {
+		// The file is too small to be compressed
+		return false, nil
+	}
+	return bytes.Equal(header, gzipMagicHeader), nil
+}
+
+// compressFile compresses the file at the source path using gzip and writes the result to the destination path
+func compressFile(sourcePath, destinationPath string) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	destination, err := os.Create(destinationPath)
+	if err != nil {
+		return err
+	}
+	gzipWriter := gzip.NewWriter(destination)
+	if _, err = io.Copy(gzipWriter, source); err != nil {
+		destination.Close()
+		return err
+	}
+	if err = gzipWriter.Close(); err != nil {
+		destination.Close()
+		return err
+	}
+	return destination.Close()
+}
+
+// decompressFile decompresses the file at the source path, which must be compressed using gzip, and writes the result
+// to the destination path
+func decompressFile(sourcePath, destinationPath string) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	gzipReader, err := gzip.NewReader(source)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+	destination, err := os.Create(destinationPath)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(destination, gzipReader); err != nil {
+		destination.Close()
+		return err
+	}
+	return destination.Close()
 }
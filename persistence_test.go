@@ -1,8 +1,11 @@
 package gocache
 
 import (
+	"bytes"
 	"encoding/gob"
 	"fmt"
+	"os"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -51,6 +54,213 @@ func TestCache_SaveToFile(t *testing.T) {
 	}
 }
 
+func TestCache_ReadFromFileWithIdenticalTimestamps(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	cache := NewCache()
+	for n := 0; n < 100; n++ {
+		cache.Set(strconv.Itoa(n), n)
+	}
+	// Simulate every entry having been created within the resolution of the clock
+	now := time.Now()
+	for _, entry := range cache.entries {
+		entry.RelevantTimestamp = now
+	}
+	if err := cache.SaveToFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	newCache := NewCache().WithMaxSize(50)
+	numberOfEntriesEvicted, err := newCache.ReadFromFile(file)
+	if err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	if numberOfEntriesEvicted != 50 {
+		t.Error("expected 50 entries to have been evicted, but got", numberOfEntriesEvicted)
+	}
+	// The oldest entries must have been evicted, and the remaining entries must be in the order they were inserted
+	i := 50
+	for entry := newCache.tail; entry != nil; entry = entry.previous {
+		if entry.Key != strconv.Itoa(i) {
+			t.Fatalf("expected key %d, got %s", i, entry.Key)
+		}
+		i++
+	}
+	// Entries updated after being loaded must be considered newer than the entries that were loaded
+	newCache.Set("50", 50)
+	newCache.Set("new", "value")
+	if newCache.tail.Key != "52" {
+		t.Errorf("expected tail to be 52, got %s", newCache.tail.Key)
+	}
+	if newCache.head.next.Sequence >= newCache.head.Sequence || newCache.head.next.Sequence <= 99 {
+		t.Error("expected entries updated after being loaded to have a greater sequence number")
+	}
+}
+
+func TestCache_ReadFromFileWithoutSequenceNumbers(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	cache := NewCache()
+	for n := 0; n < 100; n++ {
+		cache.Set(strconv.Itoa(n), n)
+	}
+	// Simulate a file saved before sequence numbers were introduced
+	for _, entry := range cache.entries {
+		entry.Sequence = 0
+	}
+	if err := cache.SaveToFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	newCache := NewCache()
+	if _, err := newCache.ReadFromFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	if err := newCache.DebugVerify(); err != nil {
+		t.Fatal("expected the entries to have been renumbered, got", err)
+	}
+	if newCache.tail.Sequence != 1 || newCache.head.Sequence != 100 {
+		t.Errorf("expected sequence numbers from 1 to 100, got %d to %d", newCache.tail.Sequence, newCache.head.Sequence)
+	}
+	newCache.Set("new", "value")
+	if newCache.head.Sequence != 101 {
+		t.Error("expected entries set after the file was read to have a greater sequence number, got", newCache.head.Sequence)
+	}
+}
+
+func TestCache_ReadFromFileIntoPopulatedCache(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	cache := NewCache()
+	for n := 0; n < 100; n++ {
+		cache.Set(fmt.Sprintf("saved-%d", n), n)
+	}
+	if err := cache.SaveToFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	// The sequence numbers of the entries already in the cache are the same as the ones in the file
+	newCache := NewCache()
+	for n := 0; n < 100; n++ {
+		newCache.Set(fmt.Sprintf("existing-%d", n), n)
+	}
+	if _, err := newCache.ReadFromFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	if newCache.Count() != 200 {
+		t.Error("expected 200 entries, got", newCache.Count())
+	}
+	if err := newCache.DebugVerify(); err != nil {
+		t.Fatal("expected the cache to be consistent after merging the entries of the file, got", err)
+	}
+}
+
+func TestCache_ReadFromFileWithFirstInFirstOutPreservesInsertionOrder(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	cache := NewCache().WithEvictionPolicy(FirstInFirstOut)
+	for n := 0; n < 1000; n++ {
+		cache.Set(strconv.Itoa(n), n)
+	}
+	if err := cache.SaveToFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	newCache := NewCache().WithEvictionPolicy(FirstInFirstOut).WithMaxSize(1000)
+	if _, err := newCache.ReadFromFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	for n := 0; n < 1000; n++ {
+		newCache.Set(fmt.Sprintf("new-%d", n), n)
+		if _, ok := newCache.get(strconv.Itoa(n)); ok {
+			t.Fatalf("expected key %d to have been evicted after inserting %d new keys", n, n+1)
+		}
+		if n < 999 {
+			if _, ok := newCache.get(strconv.Itoa(n + 1)); !ok {
+				t.Fatalf("expected key %d to not have been evicted yet", n+1)
+			}
+		}
+	}
+}
+
+func TestCache_ReadFromFileWithLeastRecentlyUsedPreservesRecencyOrder(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	cache := NewCache().WithEvictionPolicy(LeastRecentlyUsed)
+	for n := 0; n < 10; n++ {
+		cache.Set(strconv.Itoa(n), n)
+	}
+	for _, key := range []string{"3", "0", "7", "3"} {
+		cache.Get(key)
+	}
+	// Simulate the clock having gone backward between the creation of 9 and the access of 0
+	entry, _ := cache.get("0")
+	entry.RelevantTimestamp = entry.RelevantTimestamp.Add(-time.Hour)
+	if err := cache.SaveToFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	newCache := NewCache().WithEvictionPolicy(LeastRecentlyUsed).WithMaxSize(10)
+	if _, err := newCache.ReadFromFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	// The entries must be evicted from the least recently used to the most recently used
+	expectedEvictionOrder := []string{"1", "2", "4", "5", "6", "8", "9", "0", "7", "3"}
+	var evictedKeys []string
+	newCache.WithOnEvict(func(key string, _ interface{}) {
+		evictedKeys = append(evictedKeys, key)
+	})
+	for n := 0; n < 10; n++ {
+		newCache.Set(fmt.Sprintf("new-%d", n), n)
+	}
+	if !reflect.DeepEqual(evictedKeys, expectedEvictionOrder) {
+		t.Errorf("expected the entries to have been evicted in the order %v, got %v", expectedEvictionOrder, evictedKeys)
+	}
+}
+
+func TestCache_SaveToFileWithConcurrentWrites(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	cache := NewCache().WithMaxSize(NoMaxSize)
+	const NumberOfEntries = 10000
+	for n := 0; n < NumberOfEntries; n++ {
+		cache.Set(strconv.Itoa(n), fmt.Sprintf("%d-0", n))
+	}
+	done := make(chan error)
+	go func() {
+		done <- cache.SaveToFile(file)
+	}()
+	numberOfWritesDuringSave := 0
+	func() {
+		for n := 0; ; n = (n + 1) % NumberOfEntries {
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal("shouldn't have returned an error, but got:", err.Error())
+				}
+				return
+			default:
+				cache.SetWithTTL(strconv.Itoa(n), fmt.Sprintf("%d-1", n), time.Hour)
+				numberOfWritesDuringSave++
+			}
+		}
+	}()
+	if numberOfWritesDuringSave == 0 {
+		t.Error("expected writers to not have been blocked for the entire save")
+	}
+	newCache := NewCache().WithMaxSize(NoMaxSize)
+	if _, err := newCache.ReadFromFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	if newCache.Count() != NumberOfEntries {
+		t.Fatalf("expected %d entries, got %d", NumberOfEntries, newCache.Count())
+	}
+	// Every entry must have been saved either entirely before or entirely after being updated
+	for key, entry := range newCache.entries {
+		switch entry.Value {
+		case key + "-0":
+			if entry.Expiration != NoExpiration {
+				t.Fatalf("expected entry %s with its original value to have no expiration", key)
+			}
+		case key + "-1":
+			if entry.Expiration == NoExpiration {
+				t.Fatalf("expected entry %s with its updated value to have an expiration", key)
+			}
+		default:
+			t.Fatalf("unexpected value %v for entry %s", entry.Value, key)
+		}
+	}
+}
+
 func TestCache_SaveToFileStruct(t *testing.T) {
 	file := t.TempDir() + "/" + TestCacheFile
 	cache := NewCache()
@@ -133,6 +343,173 @@ func TestCache_ReadFromFile(t *testing.T) {
 	cache.Set("eviction-test", 1)
 }
 
+func TestCache_SaveToFileAsJSON(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	cache := NewCache()
+	cache.Set("string", "value")
+	cache.Set("bytes", []byte("value"))
+	cache.Set("bool", true)
+	cache.Set("int", 123)
+	cache.Set("int64", int64(-1<<62))
+	cache.Set("uint64", uint64(1<<63))
+	cache.Set("float32", float32(1.5))
+	cache.Set("nil", nil)
+	cache.Set("slice", []int{1, 2})
+	cache.Set("struct", struct{ A string }{A: "test"})
+	cache.Set("channel", make(chan int))
+	cache.SetWithTTL("ttl", "value", time.Hour)
+	if err := cache.SaveToFileAs(file, JSON); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	newCache := NewCache()
+	numberOfEntriesEvicted, err := newCache.ReadFromFileAs(file, JSON)
+	if err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	if numberOfEntriesEvicted != 0 {
+		t.Error("expected 0 entries to have been evicted, but got", numberOfEntriesEvicted)
+	}
+	if newCache.Count() != cache.Count()-1 {
+		t.Errorf("expected every entry but the channel to have been restored, got %d entries", newCache.Count())
+	}
+	scenarios := map[string]interface{}{
+		"string":  "value",
+		"bool":    true,
+		"int":     123,
+		"int64":   int64(-1 << 62),
+		"uint64":  uint64(1 << 63),
+		"float32": float32(1.5),
+		"nil":     nil,
+	}
+	for key, expectedValue := range scenarios {
+		if value, _ := newCache.Get(key); value != expectedValue {
+			t.Errorf("[%s] expected %v (%T), got %v (%T)", key, expectedValue, expectedValue, value, value)
+		}
+	}
+	if value, _ := newCache.Get("bytes"); !bytes.Equal(value.([]byte), []byte("value")) {
+		t.Errorf("expected value, got %v", value)
+	}
+	if value, _ := newCache.Get("slice"); !reflect.DeepEqual(value, []interface{}{float64(1), float64(2)}) {
+		t.Errorf("expected slices to be restored as []interface{}, got %v (%T)", value, value)
+	}
+	if value, _ := newCache.Get("struct"); !reflect.DeepEqual(value, map[string]interface{}{"A": "test"}) {
+		t.Errorf("expected structs to be restored as map[string]interface{}, got %v (%T)", value, value)
+	}
+	if ttl, _ := newCache.TTL("ttl"); ttl <= 59*time.Minute {
+		t.Error("expected the expiration time to have been restored, got", ttl)
+	}
+	if cache.head.Key != newCache.head.Key || cache.tail.Key != newCache.tail.Key {
+		t.Error("expected the order of the entries to have been preserved")
+	}
+}
+
+func TestCache_ReadFromFileAsJSONIntoPopulatedCache(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	cache := NewCache()
+	for n := 0; n < 50; n++ {
+		cache.Set(strconv.Itoa(n), fmt.Sprintf("saved-%d", n))
+	}
+	if err := cache.SaveToFileAs(file, JSON); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	newCache := NewCache().WithMaxMemoryUsage(Megabyte)
+	// Half of the keys are also in the file, with a value of a different size
+	for n := 25; n < 75; n++ {
+		newCache.Set(strconv.Itoa(n), fmt.Sprintf("existing-value-%d", n))
+	}
+	if _, err := newCache.ReadFromFileAs(file, JSON); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	if newCache.Count() != 75 {
+		t.Error("expected 75 entries, got", newCache.Count())
+	}
+	if err := newCache.DebugVerify(); err != nil {
+		t.Fatal("expected the cache to be consistent after reading the file, got", err)
+	}
+	if value, _ := newCache.Get("30"); value != "saved-30" {
+		t.Error("expected the entries of the file to have replaced the existing ones, got", value)
+	}
+}
+
+func TestCache_SaveToFileWithPersistenceCompression(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	cache := NewCache()
+	for n := 0; n < 100; n++ {
+		cache.Set(strconv.Itoa(n), fmt.Sprintf("v%d", n))
+	}
+	if err := cache.SaveToFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	uncompressedFileInfo, _ := os.Stat(file)
+	// Overwrite the uncompressed file with a compressed one
+	if err := cache.WithPersistenceCompression(true).SaveToFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	if compressed, _ := isCompressedFile(file); !compressed {
+		t.Fatal("expected the file to have been compressed")
+	}
+	if compressedFileInfo, _ := os.Stat(file); compressedFileInfo.Size() >= uncompressedFileInfo.Size() {
+		t.Errorf("expected the compressed file to be smaller than %d bytes, got %d bytes", uncompressedFileInfo.Size(), compressedFileInfo.Size())
+	}
+	// The cache reading the file doesn't need to be configured with compression
+	newCache := NewCache()
+	if _, err := newCache.ReadFromFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	if newCache.Count() != 100 {
+		t.Error("expected newCache to have 100 entries, but got", newCache.Count())
+	}
+	if value, _ := newCache.Get("42"); value != "v42" {
+		t.Error("expected v42, got", value)
+	}
+	// Overwrite the compressed file with an uncompressed one
+	if err := cache.WithPersistenceCompression(false).SaveToFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	if compressed, _ := isCompressedFile(file); compressed {
+		t.Error("expected the file to no longer be compressed")
+	}
+	if _, err := NewCache().ReadFromFile(file); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+}
+
+func TestCache_SaveToFileAsJSONWithPersistenceCompression(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	cache := NewCache().WithPersistenceCompression(true)
+	cache.Set("key", "value")
+	if err := cache.SaveToFileAs(file, JSON); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	if compressed, _ := isCompressedFile(file); !compressed {
+		t.Fatal("expected the file to have been compressed")
+	}
+	newCache := NewCache()
+	if _, err := newCache.ReadFromFileAs(file, JSON); err != nil {
+		t.Fatal("shouldn't have returned an error, but got:", err.Error())
+	}
+	if value, _ := newCache.Get("key"); value != "value" {
+		t.Error("expected value, got", value)
+	}
+}
+
+func TestCache_ReadFromFileAsJSONWithUnsupportedVersion(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	if err := os.WriteFile(file, []byte(`{"version": 999, "entries": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCache().ReadFromFileAs(file, JSON); err != ErrUnsupportedFormat {
+		t.Errorf("expected %v, got %v", ErrUnsupportedFormat, err)
+	}
+}
+
+func TestCache_SaveToFileAsWithUnsupportedFormat(t *testing.T) {
+	file := t.TempDir() + "/" + TestCacheFile
+	if err := NewCache().SaveToFileAs(file, SerializationFormat(999)); err != ErrUnsupportedFormat {
+		t.Errorf("expected %v, got %v", ErrUnsupportedFormat, err)
+	}
+}
+
 func TestCache_ReadFromFileWithMaxMemoryUsageAndMaxSizeEvictions(t *testing.T) {
 	Debug = true
 	file := t.TempDir() + "/" + TestCacheFile
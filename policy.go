@@ -30,4 +30,27 @@ var (
 	// If a cache entry 4 was then created, because the Cache.MaxSize is 3, the tail (1) would then be evicted:
 	//     4 (head) -> 3 -> 2 (tail)
 	FirstInFirstOut EvictionPolicy = "FirstInFirstOut"
+
+	// MostRecentlyUsed is an eviction policy that, like LeastRecentlyUsed, causes the most recently accessed cache
+	// entry to be moved to the head of the cache, but that evicts the entry closest to the head rather than the tail,
+	// other than the entry that was just created or updated. This is useful for workloads in which the entries that
+	// were accessed the most recently are the least likely to be accessed again, such as repeated sequential scans.
+	//
+	// For instance, creating a Cache with a Cache.MaxSize of 3 and creating the entries 1, 2 and 3 in that order would
+	// put 3 at the head and 1 at the tail:
+	//     3 (head) -> 2 -> 1 (tail)
+	// If the cache entry 1 was then accessed, 1 would become the head:
+	//     1 (head) -> 3 -> 2 (tail)
+	// If a cache entry 4 was then created, because the Cache.MaxSize is 3, the entry after the head (1) would then be
+	// evicted:
+	//     4 (head) -> 3 -> 2 (tail)
+	MostRecentlyUsed EvictionPolicy = "MostRecentlyUsed"
+
+	// Random is an eviction policy that causes a random cache entry to be evicted, other than the entry that was just
+	// created or updated.
+	//
+	// Like with FirstInFirstOut, accessing a cache entry does not change its position, which makes retrieving entries
+	// cheaper than with LeastRecentlyUsed. The entry evicted is picked by iterating over the entries, which Go does in
+	// a randomized order, so while every entry can be evicted, the distribution is not perfectly uniform.
+	Random EvictionPolicy = "Random"
 )
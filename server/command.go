@@ -0,0 +1,191 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/redcon"
+)
+
+// command is a command supported by the server
+type command struct {
+	// handler is the function responsible for handling the command
+	handler func(server *Server, cmd redcon.Command, conn redcon.Conn)
+
+	// arity is the number of arguments expected by the command, including the name of the command itself.
+	// A negative arity means that the command expects at least -arity arguments.
+	arity int
+
+	// flags are the flags of the command, as described by COMMAND INFO
+	flags []string
+
+	// firstKey is the position of the first key in the arguments, or 0 if the command takes no keys
+	firstKey int
+
+	// lastKey is the position of the last key in the arguments, or -1 if the keys are unbounded
+	lastKey int
+
+	// step is the number of arguments between each key
+	step int
+
+	// summary is a short description of the command, as described by COMMAND DOCS
+	summary string
+}
+
+// commands is the dispatch table of the server, indexed by the uppercase name of each command
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		"APPEND":      {handler: (*Server).append, arity: 3, flags: []string{"write", "denyoom", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Append a value to a key"},
+		"AUTH":        {handler: (*Server).auth, arity: -2, flags: []string{"noscript", "loading", "stale", "fast", "no_auth"}, summary: "Authenticate to the server"},
+		"CLIENT":      {handler: (*Server).client, arity: -2, flags: []string{"admin", "noscript", "random", "loading", "stale"}, summary: "Manage the connection of the client"},
+		"COMMAND":     {handler: (*Server).command, arity: -1, flags: []string{"random", "loading", "stale"}, summary: "Get details about the commands supported by the server"},
+		"CONFIG":      {handler: (*Server).config, arity: -2, flags: []string{"admin", "loading", "stale"}, summary: "Manage the configuration of the server"},
+		"COPY":        {handler: (*Server).copyKey, arity: -3, flags: []string{"write", "denyoom"}, firstKey: 1, lastKey: 2, step: 1, summary: "Copy a key"},
+		"DBSIZE":      {handler: (*Server).dbSize, arity: 1, flags: []string{"readonly", "fast"}, summary: "Get the number of keys"},
+		"DEBUG":       {handler: (*Server).debug, arity: -2, flags: []string{"admin", "noscript", "loading", "stale"}, summary: "Debug the server"},
+		"DECR":        {handler: (*Server).decr, arity: 2, flags: []string{"write", "denyoom", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Decrement the integer value of a key by one"},
+		"DECRBY":      {handler: (*Server).decrby, arity: 3, flags: []string{"write", "denyoom", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Decrement the integer value of a key by the given amount"},
+		"DEL":         {handler: (*Server).del, arity: -2, flags: []string{"write"}, firstKey: 1, lastKey: -1, step: 1, summary: "Delete one or more keys"},
+		"ECHO":        {handler: (*Server).echo, arity: 2, flags: []string{"fast"}, summary: "Echo the given string"},
+		"EXISTS":      {handler: (*Server).exists, arity: -2, flags: []string{"readonly", "fast"}, firstKey: 1, lastKey: -1, step: 1, summary: "Determine how many of the given keys exist"},
+		"EXPIRE":      {handler: (*Server).expire, arity: 3, flags: []string{"write", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Set a key's time to live in seconds"},
+		"EXPIREAT":    {handler: (*Server).expireAt, arity: 3, flags: []string{"write", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Set the expiration time of a key as a unix timestamp in seconds"},
+		"FLUSHALL":    {handler: (*Server).flushAll, arity: -1, flags: []string{"write"}, summary: "Remove all keys from all databases"},
+		"FLUSHDB":     {handler: (*Server).flushDb, arity: -1, flags: []string{"write"}, summary: "Remove all keys from the current database"},
+		"GET":         {handler: (*Server).get, arity: 2, flags: []string{"readonly", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Get the value of a key"},
+		"GETDEL":      {handler: (*Server).getdel, arity: 2, flags: []string{"write", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Get the value of a key and delete the key"},
+		"GETSET":      {handler: (*Server).getset, arity: 3, flags: []string{"write", "denyoom", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Set the value of a key and return its old value"},
+		"HELLO":       {handler: (*Server).hello, arity: -1, flags: []string{"noscript", "loading", "stale", "fast", "no_auth"}, summary: "Handshake with the server"},
+		"INCR":        {handler: (*Server).incr, arity: 2, flags: []string{"write", "denyoom", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Increment the integer value of a key by one"},
+		"INCRBY":      {handler: (*Server).incrby, arity: 3, flags: []string{"write", "denyoom", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Increment the integer value of a key by the given amount"},
+		"INFO":        {handler: (*Server).info, arity: -1, flags: []string{"random", "loading", "stale"}, summary: "Get information and statistics about the server"},
+		"KEYS":        {handler: (*Server).keys, arity: 2, flags: []string{"readonly", "sort_for_script"}, summary: "Find all keys matching the given pattern"},
+		"MGET":        {handler: (*Server).mget, arity: -2, flags: []string{"readonly", "fast"}, firstKey: 1, lastKey: -1, step: 1, summary: "Get the values of all the given keys"},
+		"MSET":        {handler: (*Server).mset, arity: -3, flags: []string{"write", "denyoom"}, firstKey: 1, lastKey: -1, step: 2, summary: "Set multiple keys to multiple values"},
+		"MSETNX":      {handler: (*Server).msetnx, arity: -3, flags: []string{"write", "denyoom"}, firstKey: 1, lastKey: -1, step: 2, summary: "Set multiple keys to multiple values, only if none of the keys exist"},
+		"OBJECT":      {handler: (*Server).object, arity: -2, flags: []string{"readonly", "random"}, firstKey: 2, lastKey: 2, step: 1, summary: "Inspect the internals of the value stored at a key"},
+		"PERSIST":     {handler: (*Server).persist, arity: 2, flags: []string{"write", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Remove the expiration time of a key"},
+		"PEXPIRE":     {handler: (*Server).pexpire, arity: 3, flags: []string{"write", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Set a key's time to live in milliseconds"},
+		"PING":        {handler: (*Server).ping, arity: -1, flags: []string{"stale", "fast"}, summary: "Ping the server"},
+		"PTTL":        {handler: (*Server).pttl, arity: 2, flags: []string{"readonly", "random", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Get the time to live of a key in milliseconds"},
+		"PUBLISH":     {handler: (*Server).publish, arity: 3, flags: []string{"pubsub", "loading", "stale", "fast"}, summary: "Post a message to a channel"},
+		"QUIT":        {handler: (*Server).quit, arity: 1, flags: []string{"loading", "stale", "fast", "no_auth"}, summary: "Close the connection"},
+		"RANDOMKEY":   {handler: (*Server).randomKey, arity: 1, flags: []string{"readonly", "random"}, summary: "Return a random key"},
+		"RENAME":      {handler: (*Server).rename, arity: 3, flags: []string{"write"}, firstKey: 1, lastKey: 2, step: 1, summary: "Rename a key"},
+		"RENAMENX":    {handler: (*Server).renamenx, arity: 3, flags: []string{"write", "fast"}, firstKey: 1, lastKey: 2, step: 1, summary: "Rename a key, only if the new key does not exist"},
+		"ROLE":        {handler: (*Server).role, arity: 1, flags: []string{"noscript", "loading", "stale", "fast"}, summary: "Get the role of the server in the context of replication"},
+		"SCAN":        {handler: (*Server).scan, arity: -2, flags: []string{"readonly", "random"}, summary: "Iterate over the keys"},
+		"SELECT":      {handler: (*Server).selectDatabase, arity: 2, flags: []string{"loading", "stale", "fast"}, summary: "Change the selected database for the current connection"},
+		"SET":         {handler: (*Server).set, arity: -3, flags: []string{"write", "denyoom"}, firstKey: 1, lastKey: 1, step: 1, summary: "Set the value of a key"},
+		"SETEX":       {handler: (*Server).setex, arity: 4, flags: []string{"write", "denyoom"}, firstKey: 1, lastKey: 1, step: 1, summary: "Set the value and the expiration in seconds of a key"},
+		"SETNX":       {handler: (*Server).setnx, arity: 3, flags: []string{"write", "denyoom", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Set the value of a key, only if the key does not exist"},
+		"STRLEN":      {handler: (*Server).strlen, arity: 2, flags: []string{"readonly", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Get the length of the value stored in a key"},
+		"SUBSCRIBE":   {handler: (*Server).subscribe, arity: -2, flags: []string{"pubsub", "noscript", "loading", "stale"}, summary: "Listen for messages published to the given channels"},
+		"TOUCH":       {handler: (*Server).touch, arity: -2, flags: []string{"readonly", "fast"}, firstKey: 1, lastKey: -1, step: 1, summary: "Alter the last access time of one or more keys"},
+		"TTL":         {handler: (*Server).ttl, arity: 2, flags: []string{"readonly", "random", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Get the time to live of a key in seconds"},
+		"TYPE":        {handler: (*Server).typeOf, arity: 2, flags: []string{"readonly", "fast"}, firstKey: 1, lastKey: 1, step: 1, summary: "Determine the type of the value stored at a key"},
+		"UNLINK":      {handler: (*Server).unlink, arity: -2, flags: []string{"write", "fast"}, firstKey: 1, lastKey: -1, step: 1, summary: "Delete one or more keys"},
+		"UNSUBSCRIBE": {handler: (*Server).unsubscribe, arity: -1, flags: []string{"pubsub", "noscript", "loading", "stale"}, summary: "Stop listening for messages posted to the given channels"},
+	}
+}
+
+// hasFlag returns whether the command has the flag passed as parameter
+func (c *command) hasFlag(flag string) bool {
+	for _, f := range c.flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// commandNames returns the uppercase names of all supported commands in alphabetical order
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// command is used to retrieve details about the commands supported by the server
+//
+// Supported forms are COMMAND, COMMAND COUNT, COMMAND LIST, COMMAND INFO [command ...] and COMMAND DOCS [command ...].
+// Clients often call these during their handshake, so they must never fail for a supported form.
+func (server *Server) command(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) == 1 {
+		names := commandNames()
+		conn.WriteArray(len(names))
+		for _, name := range names {
+			writeCommandInfo(conn, name, commands[name])
+		}
+		return
+	}
+	names := make([]string, 0, len(cmd.Args)-2)
+	for _, arg := range cmd.Args[2:] {
+		names = append(names, strings.ToUpper(string(arg)))
+	}
+	if len(names) == 0 {
+		names = commandNames()
+	}
+	switch strings.ToUpper(string(cmd.Args[1])) {
+	case "COUNT", "LIST":
+		if len(cmd.Args) != 2 {
+			conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s|%s' command", string(cmd.Args[0]), string(cmd.Args[1])))
+			return
+		}
+		if strings.ToUpper(string(cmd.Args[1])) == "COUNT" {
+			conn.WriteInt(len(commands))
+			return
+		}
+		conn.WriteArray(len(names))
+		for _, name := range names {
+			conn.WriteBulkString(strings.ToLower(name))
+		}
+	case "INFO":
+		conn.WriteArray(len(names))
+		for _, name := range names {
+			if c, exists := commands[name]; exists {
+				writeCommandInfo(conn, name, c)
+			} else {
+				conn.WriteNull()
+			}
+		}
+	case "DOCS":
+		// Unlike COMMAND INFO, unknown commands are omitted rather than returned as null
+		var documentedNames []string
+		for _, name := range names {
+			if _, exists := commands[name]; exists {
+				documentedNames = append(documentedNames, name)
+			}
+		}
+		// With RESP2, the map of command names to their documentation is sent as a flat array of key/value pairs
+		conn.WriteArray(len(documentedNames) * 2)
+		for _, name := range documentedNames {
+			conn.WriteBulkString(strings.ToLower(name))
+			conn.WriteArray(2)
+			conn.WriteBulkString("summary")
+			conn.WriteBulkString(commands[name].summary)
+		}
+	default:
+		conn.WriteError(fmt.Sprintf("ERR unknown subcommand '%s'. Try COMMAND HELP.", string(cmd.Args[1])))
+	}
+}
+
+// writeCommandInfo writes the details of a command in the format used by COMMAND INFO
+func writeCommandInfo(conn redcon.Conn, name string, c *command) {
+	conn.WriteArray(6)
+	conn.WriteBulkString(strings.ToLower(name))
+	conn.WriteInt(c.arity)
+	conn.WriteArray(len(c.flags))
+	for _, flag := range c.flags {
+		conn.WriteString(flag)
+	}
+	conn.WriteInt(c.firstKey)
+	conn.WriteInt(c.lastKey)
+	conn.WriteInt(c.step)
+}
@@ -0,0 +1,109 @@
+// +build !race
+
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCOMMAND(t *testing.T) {
+	commandsInfo, err := client.Command().Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commandsInfo) != len(commands) {
+		t.Errorf("expected %d commands, got %d", len(commands), len(commandsInfo))
+	}
+	getInfo, exists := commandsInfo["get"]
+	if !exists {
+		t.Fatal("expected GET to be part of the commands returned")
+	}
+	if getInfo.Arity != 2 || !getInfo.ReadOnly || getInfo.FirstKeyPos != 1 {
+		t.Errorf("unexpected info for GET: %+v", getInfo)
+	}
+}
+
+func TestCOMMANDINFO(t *testing.T) {
+	output, err := client.Do("COMMAND", "INFO", "GET").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commandsInfo, ok := output.([]interface{})
+	if !ok || len(commandsInfo) != 1 {
+		t.Fatalf("expected an array with 1 element, got %v", output)
+	}
+	getInfo, ok := commandsInfo[0].([]interface{})
+	if !ok || len(getInfo) != 6 {
+		t.Fatalf("expected an array with 6 elements, got %v", commandsInfo[0])
+	}
+	if getInfo[0] != "get" {
+		t.Errorf("expected name to be get, got %v", getInfo[0])
+	}
+	if getInfo[1] != int64(2) {
+		t.Errorf("expected arity to be 2, got %v", getInfo[1])
+	}
+}
+
+func TestCOMMANDINFOWithUnknownCommand(t *testing.T) {
+	output, err := client.Do("COMMAND", "INFO", "INVALID_COMMAND").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commandsInfo, ok := output.([]interface{}); !ok || len(commandsInfo) != 1 || commandsInfo[0] != nil {
+		t.Errorf("expected an array with a single nil element, got %v", output)
+	}
+}
+
+func TestCOMMANDDOCS(t *testing.T) {
+	output, err := client.Do("COMMAND", "DOCS").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs, ok := output.([]interface{}); !ok || len(docs) != len(commands)*2 {
+		t.Errorf("expected an array with %d elements, got %v", len(commands)*2, output)
+	}
+	output, err = client.Do("COMMAND", "DOCS", "SET").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs, ok := output.([]interface{}); !ok || len(docs) != 2 || docs[0] != "set" {
+		t.Errorf("expected the documentation of SET, got %v", output)
+	}
+}
+
+func TestCOMMANDCOUNT(t *testing.T) {
+	count, err := client.Do("COMMAND", "COUNT").Int64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != int64(len(commands)) {
+		t.Errorf("expected %d, got %d", len(commands), count)
+	}
+	if err := client.Do("COMMAND", "COUNT", "GET").Err(); err == nil || !strings.Contains(err.Error(), "wrong number of arguments") {
+		t.Error("expected wrong number of arguments error, got", err)
+	}
+}
+
+func TestCOMMANDLIST(t *testing.T) {
+	output, err := client.Do("COMMAND", "LIST").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := output.([]interface{})
+	if len(names) != len(commands) {
+		t.Fatalf("expected %d command names, got %d", len(commands), len(names))
+	}
+	for _, name := range names {
+		if _, exists := commands[strings.ToUpper(name.(string))]; !exists {
+			t.Error("expected command to exist in the dispatch table, got", name)
+		}
+	}
+}
+
+func TestCOMMANDWithUnknownSubcommand(t *testing.T) {
+	c := client.Do("COMMAND", "INVALID_SUBCOMMAND")
+	if c.Err() == nil || !strings.Contains(c.Err().Error(), "unknown subcommand") {
+		t.Error("Expected server to return an error")
+	}
+}
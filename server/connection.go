@@ -0,0 +1,38 @@
+package server
+
+import "github.com/tidwall/redcon"
+
+// connection is the state associated with a client connection
+type connection struct {
+	// id is the unique identifier of the connection, as reported by HELLO
+	id int64
+
+	// numberOfPendingReplies is the number of replies written to the connection's buffer since the last flush
+	numberOfPendingReplies int
+
+	// authenticated is whether the client has successfully authenticated using AUTH
+	authenticated bool
+
+	// database is the index of the database selected by the client using SELECT
+	database int
+
+	// protocolVersion is the version of RESP negotiated by the client using HELLO
+	protocolVersion int
+
+	// trackingRedirect is the id of the connection to which the invalidation messages of the keys read by the client
+	// are sent, which is only set once the client has run CLIENT TRACKING ON
+	trackingRedirect int64
+
+	// subscriber is the state of the connection as a subscriber, which is only set once the client has run SUBSCRIBE
+	subscriber *subscriber
+}
+
+// connectionOf returns the state associated with a client connection, creating it if necessary
+func connectionOf(conn redcon.Conn) *connection {
+	if c, ok := conn.Context().(*connection); ok {
+		return c
+	}
+	c := &connection{protocolVersion: 2}
+	conn.SetContext(c)
+	return c
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/TwinProduction/gocache"
+)
+
+var (
+	ErrSyntax            = errors.New("syntax error")
+	ErrNoSuchKey         = errors.New("no such key")
+	ErrNotFloat          = errors.New("value is not a valid float")
+	ErrDBIndexOutOfRange = errors.New("DB index is out of range")
+)
+
+// toRESPError converts an error to the message of the error to send to the client, which is the same message as the
+// one Redis would send for the same error
+func toRESPError(err error) string {
+	switch err {
+	case gocache.ErrWrongType:
+		return "WRONGTYPE Operation against a key holding the wrong kind of value"
+	case gocache.ErrNotInteger:
+		return "ERR value is not an integer or out of range"
+	case gocache.ErrKeyDoesNotExist, ErrNoSuchKey:
+		return "ERR no such key"
+	default:
+		return "ERR " + err.Error()
+	}
+}
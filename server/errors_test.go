@@ -0,0 +1,25 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TwinProduction/gocache"
+)
+
+func TestToRESPError(t *testing.T) {
+	scenarios := map[error]string{
+		gocache.ErrWrongType:       "WRONGTYPE Operation against a key holding the wrong kind of value",
+		gocache.ErrNotInteger:      "ERR value is not an integer or out of range",
+		gocache.ErrKeyDoesNotExist: "ERR no such key",
+		ErrNoSuchKey:               "ERR no such key",
+		ErrSyntax:                  "ERR syntax error",
+		ErrNotFloat:                "ERR value is not a valid float",
+		errors.New("some error"):   "ERR some error",
+	}
+	for err, expected := range scenarios {
+		if actual := toRESPError(err); actual != expected {
+			t.Errorf("expected %s to be mapped to %q, got %q", err, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tidwall/redcon"
+)
+
+// commandsAllowedInSubscriberMode are the commands that a connection subscribed to at least one channel may run
+var commandsAllowedInSubscriberMode = map[string]bool{
+	"SUBSCRIBE":   true,
+	"UNSUBSCRIBE": true,
+	"PING":        true,
+	"QUIT":        true,
+}
+
+// pubSub keeps track of the channels that each connection is subscribed to
+//
+// A connection that runs SUBSCRIBE is detached from redcon, since messages must be sent to it at any time rather than
+// only in reply to its commands, and its commands are read by a goroutine of its own until it is closed.
+// This means that connections that never run SUBSCRIBE are not affected in any way.
+type pubSub struct {
+	mutex sync.RWMutex
+
+	// channels are the subscribers of each channel, indexed by channel name
+	channels map[string]map[*subscriber]bool
+
+	// subscribers are all detached connections, including the ones that are no longer subscribed to any channel
+	subscribers map[*subscriber]bool
+}
+
+// subscriber is a connection that has been detached in order to receive the messages published to its channels
+type subscriber struct {
+	// id is the unique identifier of the connection, which is what CLIENT TRACKING uses to designate the subscriber
+	// to which invalidation messages must be sent
+	id int64
+
+	// mutex must be held while writing to conn, since PUBLISH writes to it from the connection of the publisher
+	mutex sync.Mutex
+	conn  redcon.DetachedConn
+
+	// channels are the channels the subscriber is subscribed to
+	channels map[string]bool
+}
+
+// subscribe subscribes a subscriber to a channel and returns the number of channels the subscriber is subscribed to
+func (ps *pubSub) subscribe(s *subscriber, channel string) int {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	if ps.channels == nil {
+		ps.channels = make(map[string]map[*subscriber]bool)
+	}
+	if ps.channels[channel] == nil {
+		ps.channels[channel] = make(map[*subscriber]bool)
+	}
+	ps.channels[channel][s] = true
+	s.channels[channel] = true
+	return len(s.channels)
+}
+
+// unsubscribe unsubscribes a subscriber from a channel and returns the number of channels the subscriber is still
+// subscribed to
+func (ps *pubSub) unsubscribe(s *subscriber, channel string) int {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	delete(ps.channels[channel], s)
+	if len(ps.channels[channel]) == 0 {
+		delete(ps.channels, channel)
+	}
+	delete(s.channels, channel)
+	return len(s.channels)
+}
+
+// numberOfChannels returns the number of channels a subscriber is subscribed to
+func (ps *pubSub) numberOfChannels(s *subscriber) int {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return len(s.channels)
+}
+
+// channelsOf returns the channels a subscriber is subscribed to
+func (ps *pubSub) channelsOf(s *subscriber) []string {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	channels := make([]string, 0, len(s.channels))
+	for channel := range s.channels {
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+// subscribersOf returns the subscribers of a channel
+func (ps *pubSub) subscribersOf(channel string) []*subscriber {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	subscribers := make([]*subscriber, 0, len(ps.channels[channel]))
+	for s := range ps.channels[channel] {
+		subscribers = append(subscribers, s)
+	}
+	return subscribers
+}
+
+// hasSubscriber returns whether a detached connection has the id passed as parameter
+func (ps *pubSub) hasSubscriber(id int64) bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	for s := range ps.subscribers {
+		if s.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// add registers a detached connection, so that it can be closed when the server is stopped
+func (ps *pubSub) add(s *subscriber) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	if ps.subscribers == nil {
+		ps.subscribers = make(map[*subscriber]bool)
+	}
+	ps.subscribers[s] = true
+}
+
+// remove unsubscribes a subscriber from all of its channels and forgets about it
+func (ps *pubSub) remove(s *subscriber) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	for channel := range s.channels {
+		delete(ps.channels[channel], s)
+		if len(ps.channels[channel]) == 0 {
+			delete(ps.channels, channel)
+		}
+	}
+	s.channels = make(map[string]bool)
+	delete(ps.subscribers, s)
+}
+
+// closeAll closes every detached connection
+func (ps *pubSub) closeAll() {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	for s := range ps.subscribers {
+		_ = s.conn.Close()
+	}
+}
+
+// isInSubscriberMode returns whether the connection is subscribed to at least one channel, in which case it may only
+// run the commands in commandsAllowedInSubscriberMode
+func (server *Server) isInSubscriberMode(conn redcon.Conn) bool {
+	s := connectionOf(conn).subscriber
+	return s != nil && server.pubSub.numberOfChannels(s) > 0
+}
+
+// subscribe is used to subscribe the client to one or more channels
+//
+// The first time a connection subscribes to a channel, it is detached from redcon, and its commands are handled by
+// serveSubscriber from then on.
+func (server *Server) subscribe(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) < 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	c := connectionOf(conn)
+	if c.subscriber == nil {
+		c.subscriber = &subscriber{id: c.id, conn: conn.Detach(), channels: make(map[string]bool)}
+		// Once subscribed, messages may be published to the connection before the replies below have been sent
+		c.subscriber.mutex.Lock()
+		server.pubSub.add(c.subscriber)
+		// redcon considers that a detached connection is closed
+		atomic.AddInt64(&server.numberOfConnections, 1)
+		conn = c.subscriber.conn
+		defer func() {
+			// The replies must be sent manually, since redcon no longer takes care of this connection
+			_ = c.subscriber.conn.Flush()
+			c.subscriber.mutex.Unlock()
+			go server.serveSubscriber(c.subscriber)
+		}()
+	}
+	for _, channel := range cmd.Args[1:] {
+		conn.WriteArray(3)
+		conn.WriteBulkString("subscribe")
+		conn.WriteBulk(channel)
+		conn.WriteInt(server.pubSub.subscribe(c.subscriber, string(channel)))
+	}
+}
+
+// unsubscribe is used to unsubscribe the client from the given channels, or from all channels if none are given
+func (server *Server) unsubscribe(cmd redcon.Command, conn redcon.Conn) {
+	var channels []string
+	s := connectionOf(conn).subscriber
+	if len(cmd.Args) > 1 {
+		for _, channel := range cmd.Args[1:] {
+			channels = append(channels, string(channel))
+		}
+	} else if s != nil {
+		channels = server.pubSub.channelsOf(s)
+	}
+	if len(channels) == 0 {
+		// Like Redis, a reply is sent even if the client wasn't subscribed to any channel
+		conn.WriteArray(3)
+		conn.WriteBulkString("unsubscribe")
+		conn.WriteNull()
+		conn.WriteInt(0)
+		return
+	}
+	for _, channel := range channels {
+		numberOfChannels := 0
+		if s != nil {
+			numberOfChannels = server.pubSub.unsubscribe(s, channel)
+		}
+		conn.WriteArray(3)
+		conn.WriteBulkString("unsubscribe")
+		conn.WriteBulkString(channel)
+		conn.WriteInt(numberOfChannels)
+	}
+}
+
+// publish is used to send a message to every client subscribed to a channel
+//
+// Returns the number of clients that received the message.
+//
+// Since the message is sent to each subscriber before replying, a subscriber that doesn't read the messages sent to it
+// would otherwise block the publisher as soon as the buffers of its connection are full. Instead, a subscriber that
+// takes longer than subscriberWriteTimeout to accept a message is disconnected.
+func (server *Server) publish(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	channel := string(cmd.Args[1])
+	numberOfReceivers := 0
+	for _, s := range server.pubSub.subscribersOf(channel) {
+		if server.sendMessage(s, channel, func(conn redcon.Conn) { conn.WriteBulk(cmd.Args[2]) }) {
+			numberOfReceivers++
+		}
+	}
+	conn.WriteInt(numberOfReceivers)
+}
+
+// sendMessage sends a message published to a channel to a subscriber, and returns whether it was sent successfully
+//
+// writePayload is responsible for writing the content of the message. A subscriber that takes longer than
+// subscriberWriteTimeout to accept the message is disconnected.
+func (server *Server) sendMessage(s *subscriber, channel string, writePayload func(conn redcon.Conn)) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.conn.WriteArray(3)
+	s.conn.WriteBulkString("message")
+	s.conn.WriteBulkString(channel)
+	writePayload(s.conn)
+	_ = s.conn.NetConn().SetWriteDeadline(time.Now().Add(subscriberWriteTimeout))
+	if err := s.conn.Flush(); err != nil {
+		// The message may have been partially sent, so the connection can no longer be used.
+		// serveSubscriber takes care of the rest once it fails to read from the connection.
+		server.pubSub.remove(s)
+		_ = s.conn.Close()
+		return false
+	}
+	_ = s.conn.NetConn().SetWriteDeadline(time.Time{})
+	return true
+}
+
+// serveSubscriber handles the commands of a detached connection until it is closed
+func (server *Server) serveSubscriber(s *subscriber) {
+	defer func() {
+		server.pubSub.remove(s)
+		_ = s.conn.Close()
+		atomic.AddInt64(&server.numberOfConnections, -1)
+	}()
+	for {
+		cmd, err := s.conn.ReadCommand()
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		server.handleCommand(s.conn, cmd)
+		err = s.conn.Flush()
+		s.mutex.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeSubscriberModeError writes the error returned when a connection in subscriber mode runs a command that isn't
+// allowed in that mode
+func writeSubscriberModeError(cmd redcon.Command, conn redcon.Conn) {
+	conn.WriteError(fmt.Sprintf("ERR Can't execute '%s': only SUBSCRIBE / UNSUBSCRIBE / PING / QUIT are allowed in this context", strings.ToLower(string(cmd.Args[0]))))
+}
@@ -0,0 +1,153 @@
+// +build !race
+
+package server
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TwinProduction/gocache"
+	"github.com/go-redis/redis"
+)
+
+func TestSUBSCRIBEAndPUBLISH(t *testing.T) {
+	pubSub := client.Subscribe("channel", "other-channel")
+	defer pubSub.Close()
+	for i := 0; i < 2; i++ {
+		if _, err := pubSub.ReceiveTimeout(time.Second); err != nil {
+			t.Fatal("expected subscription confirmation, got", err)
+		}
+	}
+	if receivers, err := client.Publish("channel", "hello").Result(); err != nil || receivers != 1 {
+		t.Errorf("expected 1 receiver, got %d and %v", receivers, err)
+	}
+	message, err := pubSub.ReceiveMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Channel != "channel" || message.Payload != "hello" {
+		t.Errorf("expected hello on channel, got %s on %s", message.Payload, message.Channel)
+	}
+	if err := pubSub.Ping(); err != nil {
+		t.Error("expected PING to be allowed in subscriber mode, got", err)
+	}
+	if receivers, err := client.Publish("channel-without-subscribers", "hello").Result(); err != nil || receivers != 0 {
+		t.Errorf("expected 0 receivers, got %d and %v", receivers, err)
+	}
+	if err := pubSub.Unsubscribe("channel"); err != nil {
+		t.Fatal(err)
+	}
+	for client.Publish("channel", "hello").Val() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if receivers := client.Publish("other-channel", "hello").Val(); receivers != 1 {
+		t.Error("expected to still be subscribed to other-channel, got", receivers)
+	}
+}
+
+func TestSUBSCRIBEWithCommandNotAllowedInSubscriberMode(t *testing.T) {
+	conn, err := net.Dial("tcp", "localhost:16162")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	conn.Write([]byte("SUBSCRIBE channel\r\nGET key\r\nUNSUBSCRIBE\r\nSET key value\r\n"))
+	var replies []string
+	for len(replies) < 14 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		replies = append(replies, strings.TrimSpace(line))
+	}
+	defer server.Cache.Clear()
+	if !strings.HasPrefix(replies[6], "-ERR Can't execute 'get'") {
+		t.Error("expected GET to be rejected in subscriber mode, got", replies[6])
+	}
+	// Once unsubscribed from every channel, the connection must leave subscriber mode
+	if replies[13] != "+OK" {
+		t.Error("expected SET to be allowed after unsubscribing from every channel, got", replies[13])
+	}
+}
+
+func TestUNSUBSCRIBEWithoutSubscription(t *testing.T) {
+	output, err := client.Do("UNSUBSCRIBE").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply := output.([]interface{}); len(reply) != 3 || reply[0] != "unsubscribe" || reply[1] != nil || reply[2] != int64(0) {
+		t.Error("expected unsubscribe reply with no channel, got", reply)
+	}
+}
+
+func TestServer_StopClosesSubscribers(t *testing.T) {
+	serverWithSubscribers := NewServer(gocache.NewCache()).WithPort(16172)
+	go serverWithSubscribers.Start()
+	subscriberClient := redis.NewClient(&redis.Options{Addr: "localhost:16172"})
+	defer subscriberClient.Close()
+	for subscriberClient.Ping().Err() != nil {
+		time.Sleep(time.Millisecond)
+	}
+	pubSub := subscriberClient.Subscribe("channel")
+	defer pubSub.Close()
+	if _, err := pubSub.ReceiveTimeout(time.Second); err != nil {
+		t.Fatal("expected subscription confirmation, got", err)
+	}
+	serverWithSubscribers.Stop()
+	if _, err := pubSub.ReceiveTimeout(time.Second); err == nil || strings.Contains(err.Error(), "timeout") {
+		t.Error("expected the connection of the subscriber to have been closed, got", err)
+	}
+}
+
+func TestPUBLISHWhenSubscriberDoesNotReadMessages(t *testing.T) {
+	pubSubServer := NewServer(gocache.NewCache()).WithPort(16176)
+	go pubSubServer.Start()
+	defer pubSubServer.Stop()
+	publisherClient := redis.NewClient(&redis.Options{Addr: "localhost:16176", ReadTimeout: 5 * time.Second})
+	defer publisherClient.Close()
+	for deadline := time.Now().Add(5 * time.Second); publisherClient.Ping().Err() != nil; {
+		if time.Now().After(deadline) {
+			t.Fatal("server did not start in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	conn, err := net.Dial("tcp", "localhost:16176")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("SUBSCRIBE channel\r\n"))
+	// Wait for the subscription to be confirmed, after which nothing is read from the connection anymore
+	reader := bufio.NewReader(conn)
+	for i := 0; i < 6; i++ {
+		if _, err := reader.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The messages must be much larger than what the socket buffers can hold
+	message := strings.Repeat("x", 1<<20)
+	for i := 0; ; i++ {
+		start := time.Now()
+		numberOfReceivers, err := publisherClient.Publish("channel", message).Result()
+		if err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+		if time.Since(start) > 2*subscriberWriteTimeout {
+			t.Fatal("expected PUBLISH to not be blocked by a subscriber that isn't reading its messages, took", time.Since(start))
+		}
+		if numberOfReceivers == 0 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("expected the subscriber to have been disconnected")
+		}
+	}
+	// The subscriber must no longer be subscribed once disconnected
+	if numberOfReceivers, err := publisherClient.Publish("channel", "message").Result(); err != nil || numberOfReceivers != 0 {
+		t.Errorf("expected no receiver, got %d and %v", numberOfReceivers, err)
+	}
+}
@@ -2,12 +2,15 @@ package server
 
 import (
 	"bytes"
+	"crypto/subtle"
 	"fmt"
 	"log"
 	"os"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/TwinProduction/gocache"
@@ -17,6 +20,20 @@ import (
 const (
 	// DefaultServerPort is the default port for the server
 	DefaultServerPort = 6379
+
+	// keysReplyFlushInterval is the number of keys written by KEYS between each flush of the connection's buffer
+	keysReplyFlushInterval = 1000
+
+	// keysReplyChunkSize is the number of keys copied by KEYS each time the database is locked
+	keysReplyChunkSize = 1000
+
+	// subscriberWriteTimeout is the maximum duration of sending a message published to a subscriber, past which the
+	// subscriber is considered too slow and its connection is closed
+	subscriberWriteTimeout = time.Second
+
+	// redisVersion is the version of Redis reported by HELLO, which some clients use to determine which commands are
+	// supported. 6.0.0 is the first version of Redis that supports HELLO.
+	redisVersion = "6.0.0"
 )
 
 // Server is a cache server using gocache as cache and RESP (Redis bindings) as server
@@ -33,8 +50,64 @@ type Server struct {
 	// AutoSaveFile is the file in which the cache will be persisted every AutoSaveInterval
 	AutoSaveFile string
 
+	// AutoSaveOnChangesCount is the number of write commands that, if processed within AutoSaveOnChangesWindow,
+	// causes the server to save the Cache to AutoSaveFile
+	//
+	// Disabled if set to 0
+	AutoSaveOnChangesCount int
+
+	// AutoSaveOnChangesWindow is the window within which AutoSaveOnChangesCount write commands must be processed to
+	// cause the server to save the Cache to AutoSaveFile
+	AutoSaveOnChangesWindow time.Duration
+
+	// MaxPipelineDepth is the maximum number of replies that can be buffered for a single connection before they are
+	// sent to the client. Once that number is reached, the server stops processing the connection's commands until
+	// the client has read the pending replies.
+	//
+	// Disabled if set to 0
+	MaxPipelineDepth int
+
+	// MaxKeysReply is the maximum number of keys that can be returned by a single KEYS command.
+	// Once that number is reached, the remaining keys are omitted from the reply.
+	//
+	// Disabled if set to 0
+	MaxKeysReply int
+
+	// ScanHardLimit is the maximum number of keys that can be returned by a single SCAN command, regardless of the
+	// COUNT requested by the client.
+	//
+	// Disabled if set to 0
+	ScanHardLimit int
+
+	// CommandTimeout is the maximum duration of a single command. If a command takes longer than that to complete,
+	// the server replies with an error instead. Write commands are never subject to it.
+	//
+	// Disabled if set to 0
+	CommandTimeout time.Duration
+
+	// Password is the password that clients must send using AUTH before being allowed to run any other command.
+	//
+	// Disabled if empty
+	Password string
+
+	// Databases are the logical databases that clients can switch between using SELECT. The first database must be
+	// Cache, which is the database selected by default.
+	//
+	// If empty, Cache is the only database
+	Databases []*gocache.Cache
+
 	startTime           time.Time
-	numberOfConnections int
+	numberOfConnections int64
+	lastConnectionID    int64
+
+	// changesMutex is the lock for the fields used to determine whether the cache should be saved due to changes
+	changesMutex       sync.Mutex
+	numberOfChanges    int
+	changesWindowStart time.Time
+	savingOnChanges    bool
+
+	pubSub   pubSub
+	tracking tracking
 
 	running     bool
 	cacheServer *redcon.Server
@@ -58,6 +131,92 @@ func (server *Server) WithAutoSave(interval time.Duration, file string) *Server
 	return server
 }
 
+// WithAutoSaveOnChanges configures the server to save the cache to the AutoSaveFile whenever at least changeCount
+// write commands are processed within the given window, similarly to the save points of Redis.
+// This can be used in addition to, or instead of, the fixed interval configured through WithAutoSave.
+//
+// Note that the file must still be configured through WithAutoSave. To only save on changes, pass an interval of 0.
+//
+// Disabled if changeCount is set to 0
+func (server *Server) WithAutoSaveOnChanges(changeCount int, within time.Duration) *Server {
+	server.AutoSaveOnChangesCount = changeCount
+	server.AutoSaveOnChangesWindow = within
+	return server
+}
+
+// WithMaxPipelineDepth sets the maximum number of replies that can be buffered for a single connection
+// before the server waits for the client to read them, which bounds the memory used by clients that send
+// a very large number of commands without reading the replies.
+//
+// Disabled if set to 0
+func (server *Server) WithMaxPipelineDepth(maxPipelineDepth int) *Server {
+	server.MaxPipelineDepth = maxPipelineDepth
+	return server
+}
+
+// WithMaxKeysReply sets the maximum number of keys that can be returned by a single KEYS command, which bounds the
+// size of the reply for caches with a very large number of keys.
+//
+// Disabled if set to 0
+func (server *Server) WithMaxKeysReply(maxKeysReply int) *Server {
+	server.MaxKeysReply = maxKeysReply
+	return server
+}
+
+// WithScanHardLimit sets the maximum number of keys that can be returned by a single SCAN command, regardless of the
+// COUNT requested by the client, which prevents a client from forcing the server to build a very large reply.
+// Keys beyond the limit can still be retrieved by subsequent SCAN commands using the cursor returned.
+//
+// Disabled if set to 0
+func (server *Server) WithScanHardLimit(scanHardLimit int) *Server {
+	server.ScanHardLimit = scanHardLimit
+	return server
+}
+
+// WithCommandTimeout sets the maximum duration of a single command, past which the server replies with an error
+// rather than letting the connection hang. Note that the command itself is not interrupted, only its reply is
+// discarded, which is why write commands are always allowed to complete and are never subject to the timeout.
+//
+// Disabled if set to 0
+func (server *Server) WithCommandTimeout(commandTimeout time.Duration) *Server {
+	server.CommandTimeout = commandTimeout
+	return server
+}
+
+// WithPassword sets the password that clients must send using AUTH before being allowed to run any other command.
+// Authentication is tracked per connection, which means that each connection must authenticate on its own.
+//
+// Note that the password is sent in plain text, so this is only meant to gate access on a trusted network.
+//
+// Disabled if set to an empty string
+func (server *Server) WithPassword(password string) *Server {
+	server.Password = password
+	return server
+}
+
+// WithDatabases sets the number of logical databases that clients can switch between using SELECT, each of which is
+// backed by its own gocache.Cache. Cache is used as the first database, and the other databases are created with the
+// same MaxSize, MaxMemoryUsage and EvictionPolicy as Cache.
+//
+// Note that only the first database is persisted when using WithAutoSave.
+//
+// Defaults to 1
+func (server *Server) WithDatabases(numberOfDatabases int) *Server {
+	if numberOfDatabases <= 1 {
+		server.Databases = nil
+		return server
+	}
+	server.Databases = make([]*gocache.Cache, numberOfDatabases)
+	server.Databases[0] = server.Cache
+	for i := 1; i < numberOfDatabases; i++ {
+		server.Databases[i] = gocache.NewCache().
+			WithMaxSize(server.Cache.MaxSize()).
+			WithMaxMemoryUsage(server.Cache.MaxMemoryUsage()).
+			WithEvictionPolicy(server.Cache.EvictionPolicy())
+	}
+	return server
+}
+
 // WithPort sets the port of the server
 func (server *Server) WithPort(port int) *Server {
 	server.Port = port
@@ -68,74 +227,48 @@ func (server *Server) WithPort(port int) *Server {
 //
 // This is a blocking function, therefore, you are expected to run this on a goroutine
 func (server *Server) Start() error {
-	if server.AutoSaveInterval != 0 {
+	if server.isAutoSaveEnabled() {
 		err := server.loadAutoSaveFileIfExists()
 		if err != nil {
 			return fmt.Errorf("ran into the following error while attempting to load the auto save file in memory: %s", err.Error())
 		}
-		go server.autoSave()
+		if server.AutoSaveInterval != 0 {
+			go server.autoSave()
+		}
 	}
-	if err := server.Cache.StartJanitor(); err != nil {
-		return err
+	for _, database := range server.databases() {
+		if err := database.StartJanitor(); err != nil {
+			return err
+		}
+	}
+	for index, database := range server.databases() {
+		// The cache remains usable even if some of the keys could not be loaded, so this doesn't prevent the server
+		// from starting
+		if err := database.Warmup(); err != nil {
+			log.Printf("error while warming up database %d: %s", index, err.Error())
+		}
 	}
 	address := fmt.Sprintf(":%d", server.Port)
 	server.cacheServer = redcon.NewServer(address,
-		func(conn redcon.Conn, cmd redcon.Command) {
-			switch strings.ToUpper(string(cmd.Args[0])) {
-			case "GET":
-				server.get(cmd, conn)
-			case "SET":
-				server.set(cmd, conn)
-			case "DEL":
-				server.del(cmd, conn)
-			case "EXISTS":
-				server.exists(cmd, conn)
-			case "MGET":
-				server.mget(cmd, conn)
-			case "MSET":
-				server.mset(cmd, conn)
-			case "SCAN":
-				server.scan(cmd, conn)
-			case "TTL":
-				server.ttl(cmd, conn)
-			case "EXPIRE":
-				server.expire(cmd, conn)
-			case "SETEX":
-				server.setex(cmd, conn)
-			case "FLUSHDB":
-				server.flushDb(cmd, conn)
-			case "INFO":
-				server.info(cmd, conn)
-			case "PING":
-				conn.WriteString("PONG")
-			case "QUIT":
-				conn.WriteString("OK")
-				conn.Close()
-			case "ECHO":
-				if len(cmd.Args) != 2 {
-					conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
-					return
-				}
-				conn.WriteBulk(cmd.Args[1])
-			default:
-				conn.WriteError(fmt.Sprintf("ERR unknown command '%s'", string(cmd.Args[0])))
-			}
-		},
+		server.handleCommand,
 		func(conn redcon.Conn) bool {
-			server.numberOfConnections += 1
+			atomic.AddInt64(&server.numberOfConnections, 1)
+			connectionOf(conn).id = atomic.AddInt64(&server.lastConnectionID, 1)
 			return true
 		},
 		func(conn redcon.Conn, err error) {
-			server.numberOfConnections -= 1
+			atomic.AddInt64(&server.numberOfConnections, -1)
 		},
 	)
 	server.startTime = time.Now()
 	server.running = true
 	log.Printf("Listening on %s", address)
 	err := server.cacheServer.ListenAndServe()
-	server.Cache.StopJanitor()
+	for _, database := range server.databases() {
+		database.StopJanitor()
+	}
 	server.running = false
-	if server.AutoSaveInterval != 0 {
+	if server.isAutoSaveEnabled() {
 		log.Printf("Saving to %s before closing...", server.AutoSaveFile)
 		start := time.Now()
 		if err := server.Cache.SaveToFile(server.AutoSaveFile); err != nil {
@@ -152,15 +285,207 @@ func (server *Server) Stop() error {
 		// If the cache server is nil, there's nothing to stop.
 		return nil
 	}
+	// Connections subscribed to channels are detached from the cache server, so they must be closed separately
+	server.pubSub.closeAll()
 	return server.cacheServer.Close()
 }
 
+// handleCommand dispatches a command to its handler
+func (server *Server) handleCommand(conn redcon.Conn, cmd redcon.Command) {
+	name := strings.ToUpper(string(cmd.Args[0]))
+	c, exists := commands[name]
+	if len(server.Password) > 0 && (!exists || !c.hasFlag("no_auth")) && !connectionOf(conn).authenticated {
+		conn.WriteError("NOAUTH Authentication required.")
+		return
+	}
+	if !exists {
+		conn.WriteError(fmt.Sprintf("ERR unknown command '%s'", string(cmd.Args[0])))
+		return
+	}
+	if !commandsAllowedInSubscriberMode[name] && server.isInSubscriberMode(conn) {
+		writeSubscriberModeError(cmd, conn)
+		return
+	}
+	if connectionOf(conn).trackingRedirect != 0 && c.hasFlag("readonly") {
+		server.track(c, cmd, conn)
+	}
+	if server.isHandledWithTimeout(c, name) {
+		server.handleWithTimeout(c, cmd, conn)
+	} else {
+		c.handler(server, cmd, conn)
+	}
+	if server.AutoSaveOnChangesCount > 0 && c.hasFlag("write") {
+		server.recordChange()
+	}
+	if server.MaxPipelineDepth > 0 {
+		server.applyPipelineBackPressure(conn)
+	}
+}
+
+// databases returns all logical databases of the server, starting with Cache
+func (server *Server) databases() []*gocache.Cache {
+	if len(server.Databases) == 0 {
+		return []*gocache.Cache{server.Cache}
+	}
+	return server.Databases
+}
+
+// cacheOf returns the database selected by a client connection
+func (server *Server) cacheOf(conn redcon.Conn) *gocache.Cache {
+	if database := connectionOf(conn).database; database > 0 {
+		return server.Databases[database]
+	}
+	return server.Cache
+}
+
+// applyPipelineBackPressure flushes the replies buffered for a connection once MaxPipelineDepth replies are pending.
+// Because flushing blocks until the client has read enough of the replies, this also stops the server from reading
+// more commands from that connection in the meantime.
+func (server *Server) applyPipelineBackPressure(conn redcon.Conn) {
+	c := connectionOf(conn)
+	if len(conn.PeekPipeline()) == 0 {
+		// This was the last command of the pipeline, and redcon flushes the replies on its own once it's done
+		// processing a pipeline
+		c.numberOfPendingReplies = 0
+		return
+	}
+	c.numberOfPendingReplies++
+	if c.numberOfPendingReplies >= server.MaxPipelineDepth {
+		if writer := redcon.BaseWriter(conn); writer != nil {
+			_ = writer.Flush()
+		}
+		c.numberOfPendingReplies = 0
+	}
+}
+
+func (server *Server) auth(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 2 && len(cmd.Args) != 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	if len(server.Password) == 0 {
+		conn.WriteError("ERR AUTH called without any password configured")
+		return
+	}
+	// AUTH username password is also accepted for compatibility with clients using ACLs
+	username := "default"
+	if len(cmd.Args) == 3 {
+		username = string(cmd.Args[1])
+	}
+	if !server.isValidPassword(username, cmd.Args[len(cmd.Args)-1]) {
+		conn.WriteError("ERR invalid password")
+		return
+	}
+	connectionOf(conn).authenticated = true
+	conn.WriteString("OK")
+}
+
+// isValidPassword returns whether the credentials passed as parameter match the password of the server
+//
+// Since ACLs are not supported, the only user that exists is the default user.
+func (server *Server) isValidPassword(username string, password []byte) bool {
+	return username == "default" && subtle.ConstantTimeCompare(password, []byte(server.Password)) == 1
+}
+
+// hello is used to negotiate the protocol version of the connection and retrieve information about the server
+//
+// Supported forms are HELLO [protover [AUTH username password] [SETNAME clientname]], but only RESP2 is supported,
+// which means that HELLO 3 is rejected and clients are expected to fall back to RESP2.
+func (server *Server) hello(cmd redcon.Command, conn redcon.Conn) {
+	c := connectionOf(conn)
+	if len(cmd.Args) > 1 {
+		protocolVersion, err := strconv.Atoi(string(cmd.Args[1]))
+		if err != nil {
+			conn.WriteError("ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if protocolVersion != 2 {
+			conn.WriteError("NOPROTO unsupported protocol version")
+			return
+		}
+		for index := 2; index < len(cmd.Args); index++ {
+			switch strings.ToUpper(string(cmd.Args[index])) {
+			case "AUTH":
+				if index+2 >= len(cmd.Args) {
+					conn.WriteError(toRESPError(ErrSyntax))
+					return
+				}
+				if len(server.Password) == 0 {
+					conn.WriteError("ERR AUTH called without any password configured")
+					return
+				}
+				if !server.isValidPassword(string(cmd.Args[index+1]), cmd.Args[index+2]) {
+					conn.WriteError("WRONGPASS invalid username-password pair")
+					return
+				}
+				c.authenticated = true
+				index += 2
+			case "SETNAME":
+				// CLIENT SETNAME is not supported, so the name of the client is accepted but not used for anything
+				if index+1 >= len(cmd.Args) {
+					conn.WriteError(toRESPError(ErrSyntax))
+					return
+				}
+				index++
+			default:
+				conn.WriteError(toRESPError(ErrSyntax))
+				return
+			}
+		}
+		c.protocolVersion = protocolVersion
+	}
+	if len(server.Password) > 0 && !c.authenticated {
+		conn.WriteError("NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time")
+		return
+	}
+	// With RESP2, the map describing the server is sent as a flat array of key/value pairs
+	conn.WriteArray(14)
+	conn.WriteBulkString("server")
+	conn.WriteBulkString("gocache")
+	conn.WriteBulkString("version")
+	conn.WriteBulkString(redisVersion)
+	conn.WriteBulkString("proto")
+	conn.WriteInt(2)
+	conn.WriteBulkString("id")
+	conn.WriteInt64(c.id)
+	conn.WriteBulkString("mode")
+	conn.WriteBulkString("standalone")
+	conn.WriteBulkString("role")
+	conn.WriteBulkString("master")
+	conn.WriteBulkString("modules")
+	conn.WriteArray(0)
+}
+
+func (server *Server) ping(_ redcon.Command, conn redcon.Conn) {
+	if server.isInSubscriberMode(conn) {
+		// In subscriber mode, replies must be arrays, so that they can be told apart from messages
+		conn.WriteArray(2)
+		conn.WriteBulkString("pong")
+		conn.WriteBulkString("")
+		return
+	}
+	conn.WriteString("PONG")
+}
+
+func (server *Server) quit(_ redcon.Command, conn redcon.Conn) {
+	conn.WriteString("OK")
+	conn.Close()
+}
+
+func (server *Server) echo(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	conn.WriteBulk(cmd.Args[1])
+}
+
 func (server *Server) get(cmd redcon.Command, conn redcon.Conn) {
 	if len(cmd.Args) != 2 {
 		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
 		return
 	}
-	val, ok := server.Cache.Get(string(cmd.Args[1]))
+	val, ok := server.cacheOf(conn).Get(string(cmd.Args[1]))
 	if !ok {
 		conn.WriteNull()
 	} else {
@@ -168,33 +493,127 @@ func (server *Server) get(cmd redcon.Command, conn redcon.Conn) {
 	}
 }
 
+// set is used to set the value of a key
+// Only the EX, PX and KEEPTTL options are supported.
 func (server *Server) set(cmd redcon.Command, conn redcon.Conn) {
-	numberOfArguments := len(cmd.Args)
-	if numberOfArguments != 3 && numberOfArguments != 5 && numberOfArguments != 6 {
+	if len(cmd.Args) < 3 {
 		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
 		return
 	}
-	if numberOfArguments == 3 {
-		server.Cache.Set(string(cmd.Args[1]), string(cmd.Args[2]))
-	} else {
-		unit, err := strconv.Atoi(string(cmd.Args[4]))
+	var ttl time.Duration
+	hasTTL, keepTTL := false, false
+	for index := 3; index < len(cmd.Args); index++ {
+		option := strings.ToUpper(string(cmd.Args[index]))
+		if option == "KEEPTTL" && !hasTTL {
+			keepTTL = true
+			continue
+		}
+		if (option != "EX" && option != "PX") || hasTTL || keepTTL || index+1 == len(cmd.Args) {
+			conn.WriteError(toRESPError(ErrSyntax))
+			return
+		}
+		index++
+		unit, err := strconv.Atoi(string(cmd.Args[index]))
 		if err != nil {
-			conn.WriteError("ERR value is not an integer or out of range")
+			conn.WriteError(toRESPError(gocache.ErrNotInteger))
 			return
 		}
-		option := strings.ToUpper(string(cmd.Args[3]))
 		if option == "EX" {
-			server.Cache.SetWithTTL(string(cmd.Args[1]), cmd.Args[2], time.Duration(unit)*time.Second)
-		} else if option == "PX" {
-			server.Cache.SetWithTTL(string(cmd.Args[1]), cmd.Args[2], time.Duration(unit)*time.Millisecond)
+			ttl = time.Duration(unit) * time.Second
 		} else {
-			conn.WriteError("ERR syntax error")
-			return
+			ttl = time.Duration(unit) * time.Millisecond
 		}
+		hasTTL = true
+	}
+	// The arguments of a command are only valid until the handler returns, so the value must be copied,
+	// which converting it to a string does
+	if hasTTL {
+		server.cacheOf(conn).SetWithTTL(string(cmd.Args[1]), string(cmd.Args[2]), ttl)
+	} else if keepTTL {
+		server.cacheOf(conn).SetKeepTTL(string(cmd.Args[1]), string(cmd.Args[2]))
+	} else {
+		server.cacheOf(conn).Set(string(cmd.Args[1]), string(cmd.Args[2]))
 	}
 	conn.WriteString("OK")
 }
 
+func (server *Server) getset(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	oldValue, existed := server.cacheOf(conn).GetSet(string(cmd.Args[1]), string(cmd.Args[2]))
+	if !existed {
+		conn.WriteNull()
+	} else {
+		conn.WriteAny(oldValue)
+	}
+}
+
+func (server *Server) append(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	length, err := server.cacheOf(conn).Append(string(cmd.Args[1]), string(cmd.Args[2]))
+	if err != nil {
+		conn.WriteError(toRESPError(err))
+		return
+	}
+	conn.WriteInt(length)
+}
+
+func (server *Server) strlen(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	length, err := server.cacheOf(conn).StrLen(string(cmd.Args[1]))
+	if err != nil {
+		conn.WriteError(toRESPError(err))
+		return
+	}
+	conn.WriteInt(length)
+}
+
+func (server *Server) randomKey(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 1 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	key, ok := server.cacheOf(conn).RandomKey()
+	if !ok {
+		conn.WriteNull()
+	} else {
+		conn.WriteBulkString(key)
+	}
+}
+
+func (server *Server) getdel(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	value, existed := server.cacheOf(conn).GetAndDelete(string(cmd.Args[1]))
+	if !existed {
+		conn.WriteNull()
+	} else {
+		conn.WriteAny(value)
+	}
+}
+
+func (server *Server) setnx(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	if server.cacheOf(conn).SetIfAbsent(string(cmd.Args[1]), string(cmd.Args[2])) {
+		conn.WriteInt(1)
+	} else {
+		conn.WriteInt(0)
+	}
+}
+
 func (server *Server) setex(cmd redcon.Command, conn redcon.Conn) {
 	if len(cmd.Args) != 4 {
 		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
@@ -202,10 +621,10 @@ func (server *Server) setex(cmd redcon.Command, conn redcon.Conn) {
 	}
 	unit, err := strconv.Atoi(string(cmd.Args[2]))
 	if err != nil {
-		conn.WriteError("ERR value is not an integer or out of range")
+		conn.WriteError(toRESPError(gocache.ErrNotInteger))
 		return
 	}
-	server.Cache.SetWithTTL(string(cmd.Args[1]), string(cmd.Args[3]), time.Duration(unit)*time.Second)
+	server.cacheOf(conn).SetWithTTL(string(cmd.Args[1]), string(cmd.Args[3]), time.Duration(unit)*time.Second)
 	conn.WriteString("OK")
 }
 
@@ -219,7 +638,7 @@ func (server *Server) del(cmd redcon.Command, conn redcon.Conn) {
 		if index == 0 {
 			continue
 		}
-		ok := server.Cache.Delete(string(cmd.Args[index]))
+		ok := server.cacheOf(conn).Delete(string(cmd.Args[index]))
 		if ok {
 			numberOfKeysDeleted++
 		}
@@ -227,22 +646,44 @@ func (server *Server) del(cmd redcon.Command, conn redcon.Conn) {
 	conn.WriteInt(numberOfKeysDeleted)
 }
 
+// unlink is used to delete one or more keys
+//
+// In Redis, UNLINK differs from DEL by reclaiming the memory of the values in a separate thread, but since there's no
+// such thing in gocache, it behaves exactly like DEL.
+func (server *Server) unlink(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) < 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	keys := make([]string, 0, len(cmd.Args)-1)
+	for _, arg := range cmd.Args[1:] {
+		keys = append(keys, string(arg))
+	}
+	conn.WriteInt(server.cacheOf(conn).DeleteAll(keys))
+}
+
 func (server *Server) exists(cmd redcon.Command, conn redcon.Conn) {
 	if len(cmd.Args) < 2 {
 		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
 		return
 	}
-	numberOfExistingKeys := 0
-	for index := range cmd.Args {
-		if index == 0 {
-			continue
-		}
-		_, ok := server.Cache.Get(string(cmd.Args[index]))
-		if ok {
-			numberOfExistingKeys++
-		}
+	var keys []string
+	for _, key := range cmd.Args[1:] {
+		keys = append(keys, string(key))
+	}
+	conn.WriteInt(len(server.cacheOf(conn).GetKeysThatExist(keys)))
+}
+
+func (server *Server) touch(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) < 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	keys := make([]string, 0, len(cmd.Args)-1)
+	for _, arg := range cmd.Args[1:] {
+		keys = append(keys, string(arg))
 	}
-	conn.WriteInt(numberOfExistingKeys)
+	conn.WriteInt(server.cacheOf(conn).Touch(keys))
 }
 
 func (server *Server) mget(cmd redcon.Command, conn redcon.Conn) {
@@ -257,11 +698,9 @@ func (server *Server) mget(cmd redcon.Command, conn redcon.Conn) {
 		}
 		keys = append(keys, string(cmd.Args[index]))
 	}
-	keyValues := server.Cache.GetByKeys(keys)
-	if len(keyValues) != len(keys) {
-		conn.WriteError(fmt.Sprintf("ERR internal error, expected %d keys, got %d instead", len(keys), len(keyValues)))
-	}
-	conn.WriteArray(len(keyValues))
+	// Keys that do not exist are omitted from keyValues, in which case nil is written
+	keyValues := server.cacheOf(conn).GetExisting(keys)
+	conn.WriteArray(len(keys))
 	for _, key := range keys {
 		conn.WriteAny(keyValues[key])
 	}
@@ -283,75 +722,150 @@ func (server *Server) mset(cmd redcon.Command, conn redcon.Conn) {
 			newEntries[key] = value
 		}
 	}
-	server.Cache.SetAll(newEntries)
+	server.cacheOf(conn).SetAll(newEntries)
 	conn.WriteString("OK")
 }
 
-// scan is used to search keys by pattern
-// At the moment, the cursor is ignored.
+// msetnx is used to set multiple keys to multiple values, but only if none of the keys already exist
+func (server *Server) msetnx(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) < 3 || len(cmd.Args)%2 == 0 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	newEntries := make(map[string]interface{}, (len(cmd.Args)-1)/2)
+	for index := 1; index < len(cmd.Args); index += 2 {
+		newEntries[string(cmd.Args[index])] = string(cmd.Args[index+1])
+	}
+	if server.cacheOf(conn).SetAllIfAbsent(newEntries) {
+		conn.WriteInt(1)
+	} else {
+		conn.WriteInt(0)
+	}
+}
+
+// scan is used to iterate over the keys matching a pattern, COUNT keys at a time
+//
+// See gocache.Cache.Scan for the guarantees provided by the cursor. Like in Redis, the TYPE option is applied after
+// the keys have been retrieved, which means that a SCAN command using it may return fewer than COUNT keys, or even
+// none at all, even though the iteration isn't over.
 func (server *Server) scan(cmd redcon.Command, conn redcon.Conn) {
 	numberOfArguments := len(cmd.Args)
-	if numberOfArguments != 2 && numberOfArguments != 4 && numberOfArguments != 6 {
+	if numberOfArguments < 2 || numberOfArguments > 8 || numberOfArguments%2 != 0 {
 		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
 		return
 	}
-	// XXX: The cursor is currently ignored, but we'll still validate it
-	_, err := strconv.Atoi(string(cmd.Args[1]))
+	cursor, err := strconv.ParseUint(string(cmd.Args[1]), 10, 64)
 	if err != nil {
-		conn.WriteError("ERR value is not an integer or out of range")
+		conn.WriteError(toRESPError(gocache.ErrNotInteger))
 		return
 	}
-	var keys []string
-	if numberOfArguments == 2 {
-		keys = server.Cache.GetKeysByPattern("*", 10)
-	} else {
-		var (
-			count              = 10
-			pattern            = "*"
-			isConfiguringCount = false
-			isConfiguringMatch = false
-		)
-		for index := range cmd.Args {
-			if index < 2 {
-				continue
+	var (
+		count     = 10
+		pattern   = "*"
+		valueType string
+	)
+	for index := 2; index < numberOfArguments; index += 2 {
+		switch strings.ToUpper(string(cmd.Args[index])) {
+		case "MATCH":
+			pattern = string(cmd.Args[index+1])
+		case "COUNT":
+			count, err = strconv.Atoi(string(cmd.Args[index+1]))
+			if err != nil {
+				conn.WriteError(toRESPError(gocache.ErrNotInteger))
+				return
 			}
-			switch strings.ToUpper(string(cmd.Args[index])) {
-			case "MATCH":
-				isConfiguringCount = false
-				isConfiguringMatch = true
-			case "COUNT":
-				isConfiguringCount = true
-				isConfiguringMatch = false
-			default:
-				if isConfiguringCount {
-					isConfiguringCount = false
-					count, err = strconv.Atoi(string(cmd.Args[index]))
-					if err != nil {
-						conn.WriteError("ERR value is not an integer or out of range")
-						return
-					}
-				} else if isConfiguringMatch {
-					isConfiguringMatch = false
-					pattern = string(cmd.Args[index])
-				} else {
-					conn.WriteError("ERR syntax error")
-					return
-				}
+			if count < 1 {
+				conn.WriteError(toRESPError(ErrSyntax))
+				return
 			}
+		case "TYPE":
+			valueType = string(cmd.Args[index+1])
+		default:
+			conn.WriteError(toRESPError(ErrSyntax))
+			return
 		}
-		keys = server.Cache.GetKeysByPattern(pattern, count)
+	}
+	if server.ScanHardLimit > 0 && count > server.ScanHardLimit {
+		count = server.ScanHardLimit
+	}
+	cache := server.cacheOf(conn)
+	keys, nextCursor := cache.Scan(cursor, pattern, count)
+	if len(valueType) > 0 {
+		keysOfType := keys[:0]
+		for _, key := range keys {
+			if strings.EqualFold(cache.Type(key), valueType) {
+				keysOfType = append(keysOfType, key)
+			}
+		}
+		keys = keysOfType
 	}
 	conn.WriteArray(2)
-	// The first value is the cursor used in the previous call. Since we don't support cursors at the moment, we'll
-	// hardcode this to 0.
-	// This is to prevent automated libraries from looping forever:
-	//     An iteration starts when the cursor is set to 0, and terminates when the cursor returned by the server is 0.
-	//                                                                        reference: https://redis.io/commands/scan
-	conn.WriteAny(0)
+	conn.WriteBulkString(strconv.FormatUint(nextCursor, 10))
 	conn.WriteArray(len(keys))
 	for _, key := range keys {
-		conn.WriteAny(key)
+		conn.WriteBulkString(key)
+	}
+}
+
+// keys is used to retrieve all keys matching a pattern
+//
+// Like in Redis, this is O(n), as every key has to be compared with the pattern. The pattern is matched the same way
+// as the MATCH option of SCAN, and expired keys are excluded.
+// Because the number of keys must be written before the keys themselves, they are gathered first, but the database
+// is only locked while each chunk of keys is copied using the same cursor as SCAN, so that a large number of keys
+// doesn't block the other clients. The database is never locked while the reply is written, and the reply is flushed
+// to the client as it is written rather than buffered as a whole.
+// Keys that are created or deleted while the keys are being gathered may or may not be returned, like with SCAN.
+// If MaxKeysReply is set, no more than MaxKeysReply keys are returned.
+func (server *Server) keys(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	var (
+		cache   = server.cacheOf(conn)
+		pattern = string(cmd.Args[1])
+		keys    []string
+		cursor  uint64
+	)
+	// A key that is updated while the keys are being gathered moves back to the head, so it may be visited twice
+	seen := make(map[string]bool)
+	for {
+		var chunk []string
+		chunk, cursor = cache.Scan(cursor, pattern, keysReplyChunkSize)
+		for _, key := range chunk {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		if cursor == 0 || (server.MaxKeysReply > 0 && len(keys) >= server.MaxKeysReply) {
+			break
+		}
+	}
+	if server.MaxKeysReply > 0 && len(keys) > server.MaxKeysReply {
+		keys = keys[:server.MaxKeysReply]
+	}
+	writer := redcon.BaseWriter(conn)
+	conn.WriteArray(len(keys))
+	for index, key := range keys {
+		conn.WriteBulkString(key)
+		if writer != nil && (index+1)%keysReplyFlushInterval == 0 {
+			_ = writer.Flush()
+		}
+	}
+}
+
+// typeOf is used to retrieve the type of the value of a key
+//
+// Values set through the server are always strings, but values set directly through the cache may be of any type,
+// in which case their Go type is returned.
+func (server *Server) typeOf(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
 	}
+	conn.WriteString(server.cacheOf(conn).Type(string(cmd.Args[1])))
 }
 
 func (server *Server) ttl(cmd redcon.Command, conn redcon.Conn) {
@@ -359,18 +873,44 @@ func (server *Server) ttl(cmd redcon.Command, conn redcon.Conn) {
 		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
 		return
 	}
-	ttl, err := server.Cache.TTL(string(cmd.Args[1]))
+	ttl, ok := server.getTTL(string(cmd.Args[1]), conn)
+	if ok {
+		conn.WriteInt(int(ttl.Seconds()))
+	}
+}
+
+// pttl is used to retrieve the time to live of a key in milliseconds
+//
+// The time to live is rounded to the nearest millisecond, which means that a key expiring in less than a second
+// still reports how many milliseconds it has left rather than 0.
+func (server *Server) pttl(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	ttl, ok := server.getTTL(string(cmd.Args[1]), conn)
+	if ok {
+		conn.WriteInt64(ttl.Round(time.Millisecond).Milliseconds())
+	}
+}
+
+// getTTL retrieves the time to live of a key
+//
+// If the key doesn't exist or has no expiration time, the same reply as Redis is written, which is -2 and -1
+// respectively, and the boolean returned is false.
+func (server *Server) getTTL(key string, conn redcon.Conn) (time.Duration, bool) {
+	ttl, err := server.cacheOf(conn).TTL(key)
 	if err != nil {
 		if err == gocache.ErrKeyDoesNotExist {
 			conn.WriteInt(-2)
 		} else if err == gocache.ErrKeyHasNoExpiration {
 			conn.WriteInt(-1)
 		} else {
-			conn.WriteError(fmt.Sprintf("ERR %s", err.Error()))
+			conn.WriteError(toRESPError(err))
 		}
-		return
+		return 0, false
 	}
-	conn.WriteInt(int(ttl.Seconds()))
+	return ttl, true
 }
 
 func (server *Server) expire(cmd redcon.Command, conn redcon.Conn) {
@@ -381,10 +921,10 @@ func (server *Server) expire(cmd redcon.Command, conn redcon.Conn) {
 	key := string(cmd.Args[1])
 	seconds, err := strconv.Atoi(string(cmd.Args[2]))
 	if err != nil {
-		conn.WriteError("ERR value is not an integer or out of range")
+		conn.WriteError(toRESPError(gocache.ErrNotInteger))
 		return
 	}
-	updatedSuccessfully := server.Cache.Expire(key, time.Second*time.Duration(seconds))
+	updatedSuccessfully := server.cacheOf(conn).Expire(key, time.Second*time.Duration(seconds))
 	if updatedSuccessfully {
 		conn.WriteInt(1)
 	} else {
@@ -392,6 +932,172 @@ func (server *Server) expire(cmd redcon.Command, conn redcon.Conn) {
 	}
 }
 
+func (server *Server) pexpire(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	milliseconds, err := strconv.Atoi(string(cmd.Args[2]))
+	if err != nil {
+		conn.WriteError(toRESPError(gocache.ErrNotInteger))
+		return
+	}
+	if server.cacheOf(conn).Expire(string(cmd.Args[1]), time.Millisecond*time.Duration(milliseconds)) {
+		conn.WriteInt(1)
+	} else {
+		conn.WriteInt(0)
+	}
+}
+
+func (server *Server) expireAt(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	timestamp, err := strconv.ParseInt(string(cmd.Args[2]), 10, 64)
+	if err != nil {
+		conn.WriteError(toRESPError(gocache.ErrNotInteger))
+		return
+	}
+	if server.cacheOf(conn).ExpireAt(string(cmd.Args[1]), time.Unix(timestamp, 0)) {
+		conn.WriteInt(1)
+	} else {
+		conn.WriteInt(0)
+	}
+}
+
+func (server *Server) incr(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	number, err := server.cacheOf(conn).Increment(string(cmd.Args[1]), 1)
+	writeCounterValue(number, err, conn)
+}
+
+func (server *Server) decr(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	number, err := server.cacheOf(conn).Decrement(string(cmd.Args[1]), 1)
+	writeCounterValue(number, err, conn)
+}
+
+func (server *Server) incrby(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	increment, err := strconv.ParseInt(string(cmd.Args[2]), 10, 64)
+	if err != nil {
+		conn.WriteError(toRESPError(gocache.ErrNotInteger))
+		return
+	}
+	number, err := server.cacheOf(conn).Increment(string(cmd.Args[1]), increment)
+	writeCounterValue(number, err, conn)
+}
+
+func (server *Server) decrby(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	decrement, err := strconv.ParseInt(string(cmd.Args[2]), 10, 64)
+	if err != nil {
+		conn.WriteError(toRESPError(gocache.ErrNotInteger))
+		return
+	}
+	number, err := server.cacheOf(conn).Decrement(string(cmd.Args[1]), decrement)
+	writeCounterValue(number, err, conn)
+}
+
+// writeCounterValue replies with the value resulting from incrementing or decrementing a key
+func writeCounterValue(number int64, err error, conn redcon.Conn) {
+	if err != nil {
+		// Like Redis, any value that isn't an integer is reported as such, regardless of its type
+		conn.WriteError(toRESPError(gocache.ErrNotInteger))
+		return
+	}
+	conn.WriteInt64(number)
+}
+
+func (server *Server) persist(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	if server.cacheOf(conn).Persist(string(cmd.Args[1])) {
+		conn.WriteInt(1)
+	} else {
+		conn.WriteInt(0)
+	}
+}
+
+func (server *Server) rename(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	if err := server.cacheOf(conn).Rename(string(cmd.Args[1]), string(cmd.Args[2])); err != nil {
+		conn.WriteError(toRESPError(err))
+		return
+	}
+	conn.WriteString("OK")
+}
+
+func (server *Server) renamenx(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	renamed, err := server.cacheOf(conn).RenameNX(string(cmd.Args[1]), string(cmd.Args[2]))
+	if err != nil {
+		conn.WriteError(toRESPError(err))
+		return
+	}
+	if renamed {
+		conn.WriteInt(1)
+	} else {
+		conn.WriteInt(0)
+	}
+}
+
+// copyKey is used to copy the value and the expiration time of a key to another key
+// Only the REPLACE option is supported, since copying a key to another database would not be atomic.
+func (server *Server) copyKey(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 3 && len(cmd.Args) != 4 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	replace := false
+	if len(cmd.Args) == 4 {
+		if strings.ToUpper(string(cmd.Args[3])) != "REPLACE" {
+			conn.WriteError(toRESPError(ErrSyntax))
+			return
+		}
+		replace = true
+	}
+	if string(cmd.Args[1]) == string(cmd.Args[2]) {
+		conn.WriteError("ERR source and destination objects are the same")
+		return
+	}
+	if server.cacheOf(conn).Copy(string(cmd.Args[1]), string(cmd.Args[2]), replace) {
+		conn.WriteInt(1)
+	} else {
+		conn.WriteInt(0)
+	}
+}
+
+// role is used to retrieve the role of the server in the context of replication
+// Since replication is not supported, the server is always a master with no replicas.
+func (server *Server) role(_ redcon.Command, conn redcon.Conn) {
+	conn.WriteArray(3)
+	conn.WriteBulkString("master")
+	conn.WriteInt(0)
+	conn.WriteArray(0)
+}
+
 func (server *Server) info(cmd redcon.Command, conn redcon.Conn) {
 	if len(cmd.Args) > 2 {
 		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
@@ -413,13 +1119,13 @@ func (server *Server) info(cmd redcon.Command, conn redcon.Conn) {
 	}
 	if section == "ALL" || section == "CLIENTS" {
 		buffer.WriteString("# Clients\n")
-		buffer.WriteString(fmt.Sprintf("connected_clients:%d\n", server.numberOfConnections))
+		buffer.WriteString(fmt.Sprintf("connected_clients:%d\n", atomic.LoadInt64(&server.numberOfConnections)))
 		buffer.WriteString("\n")
 	}
 	if section == "ALL" || section == "STATS" {
-		stats := server.Cache.Stats()
+		stats := server.cacheOf(conn).Stats()
 		buffer.WriteString("# Stats\n")
-		buffer.WriteString(fmt.Sprintf("current_keys:%d\n", server.Cache.Count()))
+		buffer.WriteString(fmt.Sprintf("current_keys:%d\n", server.cacheOf(conn).Count()))
 		buffer.WriteString(fmt.Sprintf("evicted_keys:%d\n", stats.EvictedKeys))
 		buffer.WriteString(fmt.Sprintf("expired_keys:%d\n", stats.ExpiredKeys))
 		buffer.WriteString(fmt.Sprintf("keyspace_hits:%d\n", stats.Hits))
@@ -432,8 +1138,8 @@ func (server *Server) info(cmd redcon.Command, conn redcon.Conn) {
 		buffer.WriteString("# Memory\n")
 		buffer.WriteString(fmt.Sprintf("used_memory:%d\n", m.HeapSys))
 		buffer.WriteString(fmt.Sprintf("used_memory_human:%dM\n", m.HeapSys/1024/1024))
-		buffer.WriteString(fmt.Sprintf("used_memory_dataset:%d\n", server.Cache.MemoryUsage()))
-		buffer.WriteString(fmt.Sprintf("used_memory_dataset_human:%dM\n", server.Cache.MemoryUsage()/1024/1024))
+		buffer.WriteString(fmt.Sprintf("used_memory_dataset:%d\n", server.cacheOf(conn).MemoryUsage()))
+		buffer.WriteString(fmt.Sprintf("used_memory_dataset_human:%dM\n", server.cacheOf(conn).MemoryUsage()/1024/1024))
 		buffer.WriteString("\n")
 	}
 	if section == "ALL" || section == "REPLICATION" {
@@ -444,8 +1150,155 @@ func (server *Server) info(cmd redcon.Command, conn redcon.Conn) {
 	conn.WriteBulkString(fmt.Sprintf("%s\n", strings.TrimSpace(buffer.String())))
 }
 
+// object is used to inspect the internals of the value stored at a given key
+// Only the REFCOUNT, IDLETIME and FREQ subcommands are supported, none of which count as accessing the key.
+// Because there is no LFU eviction policy, FREQ returns the number of times the key was accessed.
+func (server *Server) object(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) < 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	switch strings.ToUpper(string(cmd.Args[1])) {
+	case "REFCOUNT":
+		if len(cmd.Args) != 3 {
+			conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s|%s' command", string(cmd.Args[0]), string(cmd.Args[1])))
+			return
+		}
+		if _, ok := server.cacheOf(conn).Peek(string(cmd.Args[2])); !ok {
+			conn.WriteError(toRESPError(ErrNoSuchKey))
+			return
+		}
+		// Values are never shared between keys, so each value is only referenced once
+		conn.WriteInt(1)
+	case "IDLETIME":
+		if len(cmd.Args) != 3 {
+			conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s|%s' command", string(cmd.Args[0]), string(cmd.Args[1])))
+			return
+		}
+		idleTime, err := server.cacheOf(conn).IdleTime(string(cmd.Args[2]))
+		if err != nil {
+			conn.WriteError(toRESPError(ErrNoSuchKey))
+			return
+		}
+		conn.WriteInt64(int64(idleTime / time.Second))
+	case "FREQ":
+		if len(cmd.Args) != 3 {
+			conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s|%s' command", string(cmd.Args[0]), string(cmd.Args[1])))
+			return
+		}
+		frequency, err := server.cacheOf(conn).Frequency(string(cmd.Args[2]))
+		if err != nil {
+			conn.WriteError(toRESPError(ErrNoSuchKey))
+			return
+		}
+		conn.WriteUint64(frequency)
+	default:
+		conn.WriteError(fmt.Sprintf("ERR unknown subcommand '%s'. Try OBJECT HELP.", string(cmd.Args[1])))
+	}
+}
+
+// config is used to manage the configuration of the server
+// Only the RESETSTAT subcommand is supported.
+func (server *Server) config(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) < 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	switch strings.ToUpper(string(cmd.Args[1])) {
+	case "RESETSTAT":
+		if len(cmd.Args) != 2 {
+			conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s|%s' command", string(cmd.Args[0]), string(cmd.Args[1])))
+			return
+		}
+		for _, database := range server.databases() {
+			database.ResetStatistics()
+		}
+		conn.WriteString("OK")
+	default:
+		conn.WriteError(fmt.Sprintf("ERR unknown subcommand '%s'. Try CONFIG HELP.", string(cmd.Args[1])))
+	}
+}
+
+// debug is used for debugging the server
+// Only the SLEEP and VERIFY subcommands are supported, but CHANGE-REPL-ID is accepted as a no-op, since some tools use
+// it when setting up replication.
+// VERIFY checks the consistency of every database (see gocache.Cache.DebugVerify) and returns the first discrepancy
+// found as an error.
+func (server *Server) debug(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) < 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	switch strings.ToUpper(string(cmd.Args[1])) {
+	case "SLEEP":
+		if len(cmd.Args) != 3 {
+			conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s|%s' command", string(cmd.Args[0]), string(cmd.Args[1])))
+			return
+		}
+		seconds, err := strconv.ParseFloat(string(cmd.Args[2]), 64)
+		if err != nil {
+			conn.WriteError(toRESPError(ErrNotFloat))
+			return
+		}
+		time.Sleep(time.Duration(seconds * float64(time.Second)))
+		conn.WriteString("OK")
+	case "VERIFY":
+		if len(cmd.Args) != 2 {
+			conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s|%s' command", string(cmd.Args[0]), string(cmd.Args[1])))
+			return
+		}
+		for index, cache := range server.databases() {
+			if err := cache.DebugVerify(); err != nil {
+				conn.WriteError(fmt.Sprintf("ERR database %d is inconsistent: %s", index, err.Error()))
+				return
+			}
+		}
+		conn.WriteString("OK")
+	case "CHANGE-REPL-ID":
+		// Replication is not supported, so there's no replication ID to change
+		conn.WriteString("OK")
+	default:
+		conn.WriteError(fmt.Sprintf("ERR unknown subcommand '%s'. Try DEBUG HELP.", string(cmd.Args[1])))
+	}
+}
+
+// dbSize is used to retrieve the number of keys in the database selected by the client
+//
+// Only keys that have not expired are counted, even if expired keys have not been deleted yet, which is O(n).
+func (server *Server) dbSize(_ redcon.Command, conn redcon.Conn) {
+	conn.WriteInt(server.cacheOf(conn).CountLive())
+}
+
+// flushDb is used to delete all keys of the database selected by the client
 func (server *Server) flushDb(_ redcon.Command, conn redcon.Conn) {
-	server.Cache.Clear()
+	server.cacheOf(conn).Clear()
+	conn.WriteString("OK")
+}
+
+// flushAll is used to delete all keys of every database
+func (server *Server) flushAll(_ redcon.Command, conn redcon.Conn) {
+	for _, database := range server.databases() {
+		database.Clear()
+	}
+	conn.WriteString("OK")
+}
+
+// selectDatabase is used to change the database selected by the client, which is tracked per connection
+func (server *Server) selectDatabase(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", string(cmd.Args[0])))
+		return
+	}
+	database, err := strconv.Atoi(string(cmd.Args[1]))
+	if err != nil {
+		conn.WriteError(toRESPError(gocache.ErrNotInteger))
+		return
+	}
+	if database < 0 || database >= len(server.databases()) {
+		conn.WriteError(toRESPError(ErrDBIndexOutOfRange))
+		return
+	}
+	connectionOf(conn).database = database
 	conn.WriteString("OK")
 }
 
@@ -468,6 +1321,43 @@ func (server *Server) loadAutoSaveFileIfExists() error {
 	return nil
 }
 
+// isAutoSaveEnabled returns whether the cache is automatically saved, whether it be at a fixed interval or on changes
+func (server *Server) isAutoSaveEnabled() bool {
+	return server.AutoSaveFile != "" && (server.AutoSaveInterval != 0 || server.AutoSaveOnChangesCount > 0)
+}
+
+// recordChange records that a write command has been processed, and saves the cache to AutoSaveFile in the
+// background if AutoSaveOnChangesCount write commands have been processed within AutoSaveOnChangesWindow
+func (server *Server) recordChange() {
+	server.changesMutex.Lock()
+	defer server.changesMutex.Unlock()
+	now := time.Now()
+	if now.Sub(server.changesWindowStart) > server.AutoSaveOnChangesWindow {
+		// The window has passed, so the changes recorded so far no longer count
+		server.changesWindowStart = now
+		server.numberOfChanges = 0
+	}
+	server.numberOfChanges++
+	if server.numberOfChanges < server.AutoSaveOnChangesCount || server.savingOnChanges {
+		return
+	}
+	server.numberOfChanges = 0
+	server.changesWindowStart = now
+	server.savingOnChanges = true
+	go func() {
+		start := time.Now()
+		log.Printf("Persisting data to %s after %d changes...", server.AutoSaveFile, server.AutoSaveOnChangesCount)
+		if err := server.Cache.SaveToFile(server.AutoSaveFile); err != nil {
+			log.Printf("error while autosaving: %s", err.Error())
+		} else {
+			log.Printf("Persisted data to %s successfully in %s", server.AutoSaveFile, time.Since(start))
+		}
+		server.changesMutex.Lock()
+		server.savingOnChanges = false
+		server.changesMutex.Unlock()
+	}()
+}
+
 // autoSave persists the cache to AutoSaveFile every AutoSaveInterval
 func (server *Server) autoSave() {
 	for {
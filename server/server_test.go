@@ -4,7 +4,12 @@ package server
 
 import (
 	"fmt"
+	"net"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -24,6 +29,10 @@ func init() {
 		Addr: "localhost:16162",
 		DB:   0,
 	})
+	// Wait for the server to be ready before running the tests
+	for client.Ping().Err() != nil {
+		time.Sleep(time.Millisecond)
+	}
 }
 
 func TestParityClientSetCacheGet(t *testing.T) {
@@ -151,6 +160,62 @@ func TestSETWithEX(t *testing.T) {
 	}
 }
 
+func TestSETWithBinaryValue(t *testing.T) {
+	defer server.Cache.Clear()
+	binaryValue := string([]byte{'a', 0, '\r', '\n', 0xff, 0, '$', '-', '1', '\r', '\n', 'z'})
+	client.Set("key", binaryValue, 0)
+	client.Set("key-with-ttl", binaryValue, 10*time.Second)
+	// Send another command of the same length to make sure that the values stored don't share memory with the buffer
+	// used to read the commands
+	client.Set("other-key", strings.Repeat("x", len(binaryValue)), 10*time.Second)
+	for _, key := range []string{"key", "key-with-ttl"} {
+		value, err := client.Get(key).Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(value) != binaryValue {
+			t.Errorf("[%s] expected %q, got %q", key, binaryValue, value)
+		}
+	}
+}
+
+func TestSETWithKEEPTTL(t *testing.T) {
+	defer server.Cache.Clear()
+	client.Set("key", "v", 10*time.Second)
+	if err := client.Do("SET", "key", "updated", "KEEPTTL").Err(); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if value, _ := client.Get("key").Result(); value != "updated" {
+		t.Errorf("expected: %s, but got: %s", "updated", value)
+	}
+	ttl, _ := server.Cache.TTL("key")
+	if ttl.Seconds() < 8 || ttl.Seconds() > 10 {
+		t.Error("expected the TTL of ~10s to have been kept, got", ttl)
+	}
+	// Without KEEPTTL, the TTL is cleared
+	client.Set("key", "updated-again", 0)
+	if _, err := server.Cache.TTL("key"); err != gocache.ErrKeyHasNoExpiration {
+		t.Error("expected the key to no longer have an expiration, got", err)
+	}
+}
+
+func TestSETWithConflictingOptions(t *testing.T) {
+	defer server.Cache.Clear()
+	for _, args := range [][]interface{}{
+		{"SET", "key", "value", "EX", "10", "KEEPTTL"},
+		{"SET", "key", "value", "KEEPTTL", "PX", "10"},
+		{"SET", "key", "value", "EX", "10", "PX", "10"},
+		{"SET", "key", "value", "EX"},
+	} {
+		if err := client.Do(args...).Err(); err == nil || !strings.Contains(err.Error(), "syntax error") {
+			t.Errorf("%v: expected syntax error, got %v", args, err)
+		}
+	}
+	if server.Cache.Count() != 0 {
+		t.Error("expected nothing to have been set")
+	}
+}
+
 func TestSETWithSyntaxError(t *testing.T) {
 	c := client.Do("SET", "key", "value", "invalid-argument", "123")
 	if !strings.Contains(c.Err().Error(), "syntax error") {
@@ -191,6 +256,21 @@ func TestDELWithInvalidNumberOfArgs(t *testing.T) {
 	}
 }
 
+func TestUNLINK(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("k1", "v1")
+	server.Cache.Set("k2", "v2")
+	if deleted, err := client.Unlink("k1", "k2", "key-that-does-not-exist").Result(); err != nil || deleted != 2 {
+		t.Errorf("expected 2 keys to have been deleted, got %d and %v", deleted, err)
+	}
+	if server.Cache.Count() != 0 {
+		t.Error("keys should've been deleted")
+	}
+	if err := client.Do("UNLINK").Err(); err == nil || !strings.Contains(err.Error(), "wrong number of arguments") {
+		t.Error("expected wrong number of arguments error, got", err)
+	}
+}
+
 func TestMGET(t *testing.T) {
 	defer server.Cache.Clear()
 	server.Cache.Set("k1", "v1")
@@ -224,6 +304,18 @@ func TestMGETWithOneKeyThatDoesNotExist(t *testing.T) {
 	}
 }
 
+func TestMGETWithDuplicateKeys(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("k1", "v1")
+	values, err := client.MGet("k1", "k2", "k1").Result()
+	if err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if !reflect.DeepEqual(values, []interface{}{"v1", nil, "v1"}) {
+		t.Error("expected [v1 <nil> v1], got", values)
+	}
+}
+
 func TestMGETWithInvalidNumberOfArgs(t *testing.T) {
 	c := client.Do("MGET")
 	if !strings.Contains(c.Err().Error(), "wrong number of arguments") {
@@ -249,6 +341,25 @@ func TestMSETWithInvalidNumberOfArgs(t *testing.T) {
 	}
 }
 
+func TestMSETNX(t *testing.T) {
+	defer server.Cache.Clear()
+	if set, err := client.MSetNX("k1", "v1", "k2", "v2").Result(); err != nil || !set {
+		t.Errorf("expected keys to have been set, got %v and %v", set, err)
+	}
+	if set, err := client.MSetNX("k3", "v3", "k2", "new-value").Result(); err != nil || set {
+		t.Errorf("expected keys to not have been set, since k2 already exists, got %v and %v", set, err)
+	}
+	if _, ok := server.Cache.Get("k3"); ok {
+		t.Error("k3 shouldn't have existed")
+	}
+	if value, _ := server.Cache.Get("k2"); value != "v2" {
+		t.Error("expected k2 to have been left untouched, got", value)
+	}
+	if err := client.Do("MSETNX", "k1", "v1", "k2").Err(); err == nil || !strings.Contains(err.Error(), "wrong number of arguments") {
+		t.Error("expected wrong number of arguments error, got", err)
+	}
+}
+
 func TestEXPIRE(t *testing.T) {
 	defer server.Cache.Clear()
 	client.Set("key", "value", 0)
@@ -288,6 +399,193 @@ func TestEXPIREWithInvalidExpireTime(t *testing.T) {
 	}
 }
 
+func TestINCRAndDECR(t *testing.T) {
+	defer server.Cache.Clear()
+	if value, err := client.Incr("counter").Result(); err != nil || value != 1 {
+		t.Errorf("expected a missing key to be considered as 0, got %d and %v", value, err)
+	}
+	if value, err := client.IncrBy("counter", 10).Result(); err != nil || value != 11 {
+		t.Errorf("expected 11, got %d and %v", value, err)
+	}
+	if value, err := client.Decr("counter").Result(); err != nil || value != 10 {
+		t.Errorf("expected 10, got %d and %v", value, err)
+	}
+	if value, err := client.DecrBy("counter", 15).Result(); err != nil || value != -5 {
+		t.Errorf("expected -5, got %d and %v", value, err)
+	}
+	if value, err := client.Get("counter").Result(); err != nil || value != "-5" {
+		t.Errorf("expected -5, got %s and %v", value, err)
+	}
+	client.Set("string-counter", "41", 0)
+	if value, err := client.Incr("string-counter").Result(); err != nil || value != 42 {
+		t.Errorf("expected 42, got %d and %v", value, err)
+	}
+	if value, err := client.Get("string-counter").Result(); err != nil || value != "42" {
+		t.Errorf("expected the value to still be retrievable as a string, got %s and %v", value, err)
+	}
+}
+
+func TestINCRWithInvalidValue(t *testing.T) {
+	defer server.Cache.Clear()
+	client.Set("key", "not-a-number", 0)
+	for _, c := range []*redis.IntCmd{client.Incr("key"), client.DecrBy("key", 1)} {
+		if c.Err() == nil || c.Err().Error() != "ERR value is not an integer or out of range" {
+			t.Error("Expected server to return an error, got", c.Err())
+		}
+	}
+	if value, _ := client.Get("key").Result(); value != "not-a-number" {
+		t.Error("expected value to have been left untouched, got", value)
+	}
+	c := client.Do("INCRBY", "counter", "not-a-number")
+	if c.Err() == nil || c.Err().Error() != "ERR value is not an integer or out of range" {
+		t.Error("Expected server to return an error, got", c.Err())
+	}
+}
+
+func TestSETNX(t *testing.T) {
+	defer server.Cache.Clear()
+	if set, err := client.SetNX("key", "value", 0).Result(); err != nil || !set {
+		t.Errorf("expected the key to have been set, got %v and %v", set, err)
+	}
+	if set, err := client.SetNX("key", "other-value", 0).Result(); err != nil || set {
+		t.Errorf("expected the key to not have been set, got %v and %v", set, err)
+	}
+	if value, _ := client.Get("key").Result(); value != "value" {
+		t.Error("expected the existing value to not have been replaced, got", value)
+	}
+}
+
+func TestGETSET(t *testing.T) {
+	defer server.Cache.Clear()
+	if _, err := client.GetSet("key", "value").Result(); err != redis.Nil {
+		t.Error("expected nil, since the key didn't exist, got", err)
+	}
+	client.Expire("key", time.Hour)
+	if oldValue, err := client.GetSet("key", "new-value").Result(); err != nil || oldValue != "value" {
+		t.Errorf("expected the old value to be returned, got %s and %v", oldValue, err)
+	}
+	if value, _ := client.Get("key").Result(); value != "new-value" {
+		t.Error("expected new-value, got", value)
+	}
+	if ttl, _ := client.TTL("key").Result(); ttl != -time.Second {
+		t.Error("expected the expiration time of the key to have been cleared, got", ttl)
+	}
+}
+
+func TestAPPEND(t *testing.T) {
+	defer server.Cache.Clear()
+	if length, err := client.Append("key", "hello").Result(); err != nil || length != 5 {
+		t.Errorf("expected 5, got %d and %v", length, err)
+	}
+	if length, err := client.Append("key", " world").Result(); err != nil || length != 11 {
+		t.Errorf("expected 11, got %d and %v", length, err)
+	}
+	if value, _ := client.Get("key").Result(); value != "hello world" {
+		t.Error("expected hello world, got", value)
+	}
+	server.Cache.Set("slice", []string{"a"})
+	if _, err := client.Append("slice", "b").Result(); err == nil || !strings.HasPrefix(err.Error(), "WRONGTYPE") {
+		t.Error("expected a WRONGTYPE error, got", err)
+	}
+}
+
+func TestSTRLEN(t *testing.T) {
+	defer server.Cache.Clear()
+	client.Set("key", "hello", 0)
+	if length, err := client.StrLen("key").Result(); err != nil || length != 5 {
+		t.Errorf("expected 5, got %d and %v", length, err)
+	}
+	if length, err := client.StrLen("key-that-does-not-exist").Result(); err != nil || length != 0 {
+		t.Errorf("expected 0, got %d and %v", length, err)
+	}
+	server.Cache.Set("slice", []string{"a"})
+	if _, err := client.StrLen("slice").Result(); err == nil || !strings.HasPrefix(err.Error(), "WRONGTYPE") {
+		t.Error("expected a WRONGTYPE error, got", err)
+	}
+}
+
+func TestRANDOMKEY(t *testing.T) {
+	defer server.Cache.Clear()
+	if _, err := client.RandomKey().Result(); err != redis.Nil {
+		t.Error("expected nil, since the cache is empty, got", err)
+	}
+	client.Set("key", "value", 0)
+	if key, err := client.RandomKey().Result(); err != nil || key != "key" {
+		t.Errorf("expected key, got %s and %v", key, err)
+	}
+}
+
+func TestGETDEL(t *testing.T) {
+	defer server.Cache.Clear()
+	client.Set("key", "value", 0)
+	if value, err := client.Do("GETDEL", "key").Result(); err != nil || value != "value" {
+		t.Errorf("expected the value to be returned, got %v and %v", value, err)
+	}
+	if server.Cache.Count() != 0 {
+		t.Error("expected the key to have been deleted")
+	}
+	if _, err := client.Do("GETDEL", "key").Result(); err != redis.Nil {
+		t.Error("expected nil, since the key no longer exists, got", err)
+	}
+}
+
+func TestTOUCH(t *testing.T) {
+	defer server.Cache.Clear()
+	client.Set("key1", "value", 0)
+	client.Set("key2", "value", 0)
+	if numberOfKeysTouched, err := client.Touch("key1", "key2", "key3").Result(); err != nil || numberOfKeysTouched != 2 {
+		t.Errorf("expected 2 keys to have been touched, got %d and %v", numberOfKeysTouched, err)
+	}
+}
+
+func TestPERSIST(t *testing.T) {
+	defer server.Cache.Clear()
+	client.Set("key", "value", time.Hour)
+	if persisted, err := client.Persist("key").Result(); err != nil || !persisted {
+		t.Errorf("expected the expiration time to have been removed, got %v and %v", persisted, err)
+	}
+	if ttl, _ := client.TTL("key").Result(); ttl != -time.Second {
+		t.Error("expected the key to no longer have an expiration time, got", ttl)
+	}
+	if persisted, err := client.Persist("key").Result(); err != nil || persisted {
+		t.Errorf("expected nothing to have changed, got %v and %v", persisted, err)
+	}
+	if persisted, err := client.Persist("key-that-does-not-exist").Result(); err != nil || persisted {
+		t.Errorf("expected nothing to have changed, got %v and %v", persisted, err)
+	}
+}
+
+func TestPEXPIRE(t *testing.T) {
+	defer server.Cache.Clear()
+	client.Set("key", "value", 0)
+	if updated, err := client.PExpire("key", 1500*time.Millisecond).Result(); err != nil || !updated {
+		t.Errorf("expected the expiration time to have been set, got %v and %v", updated, err)
+	}
+	if ttl, _ := server.Cache.TTL("key"); ttl <= time.Second || ttl > 1500*time.Millisecond {
+		t.Error("expected TTL of ~1500ms, got", ttl)
+	}
+	if updated, _ := client.PExpire("key-that-does-not-exist", time.Second).Result(); updated {
+		t.Error("should've returned false, because the key does not exist")
+	}
+}
+
+func TestEXPIREAT(t *testing.T) {
+	defer server.Cache.Clear()
+	client.Set("key", "value", 0)
+	if updated, err := client.ExpireAt("key", time.Now().Add(time.Hour)).Result(); err != nil || !updated {
+		t.Errorf("expected the expiration time to have been set, got %v and %v", updated, err)
+	}
+	if ttl, _ := server.Cache.TTL("key"); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Error("expected TTL of ~1h, got", ttl)
+	}
+	if updated, _ := client.ExpireAt("key", time.Now().Add(-time.Hour)).Result(); !updated {
+		t.Error("should've returned true, because the key exists")
+	}
+	if _, ok := server.Cache.Get("key"); ok {
+		t.Error("key should've expired, since its expiration time is in the past")
+	}
+}
+
 func TestSETEX(t *testing.T) {
 	defer server.Cache.Clear()
 	// SETEX doesn't exist in the library, see https://github.com/go-redis/redis/pull/1546
@@ -326,6 +624,14 @@ func TestEXISTS(t *testing.T) {
 	}
 }
 
+func TestEXISTSWithDuplicateKeys(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("k1", nil)
+	if output := client.Exists("k1", "k1", "key-that-does-not-exist").Val(); output != 2 {
+		t.Error("Expected k1 to be counted twice, got", output)
+	}
+}
+
 func TestEXISTSWithInvalidNumberOfArgs(t *testing.T) {
 	c := client.Do("exists")
 	if !strings.Contains(c.Err().Error(), "wrong number of arguments") {
@@ -345,6 +651,112 @@ func TestFLUSHDB(t *testing.T) {
 	}
 }
 
+func TestFLUSHALL(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("key", "value")
+	if err := client.FlushAll().Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if server.Cache.Count() != 0 {
+		t.Error("cache should've been cleared")
+	}
+}
+
+func TestSELECTWithIndexOutOfRange(t *testing.T) {
+	if err := client.Do("SELECT", "0").Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	for _, index := range []string{"1", "-1"} {
+		if err := client.Do("SELECT", index).Err(); err == nil || err.Error() != "ERR DB index is out of range" {
+			t.Errorf("[%s] expected out of range error, got %v", index, err)
+		}
+	}
+	if err := client.Do("SELECT", "not-a-number").Err(); err == nil || err.Error() != "ERR value is not an integer or out of range" {
+		t.Error("expected not an integer error, got", err)
+	}
+}
+
+func TestServer_WithDatabases(t *testing.T) {
+	serverWithDatabases := NewServer(gocache.NewCache().WithMaxSize(1234)).WithPort(16171).WithDatabases(3)
+	go serverWithDatabases.Start()
+	defer serverWithDatabases.Stop()
+	if len(serverWithDatabases.Databases) != 3 || serverWithDatabases.Databases[0] != serverWithDatabases.Cache {
+		t.Fatal("expected 3 databases, the first of which being Cache")
+	}
+	if serverWithDatabases.Databases[2].MaxSize() != 1234 {
+		t.Error("expected databases to have been created with the same max size as Cache, got", serverWithDatabases.Databases[2].MaxSize())
+	}
+	firstClient := redis.NewClient(&redis.Options{Addr: "localhost:16171", PoolSize: 1})
+	defer firstClient.Close()
+	secondClient := redis.NewClient(&redis.Options{Addr: "localhost:16171", PoolSize: 1, DB: 2})
+	defer secondClient.Close()
+	for firstClient.Ping().Err() != nil {
+		time.Sleep(time.Millisecond)
+	}
+	firstClient.Set("key", "db0", 0)
+	secondClient.Set("key", "db2", 0)
+	if value, err := firstClient.Get("key").Result(); err != nil || value != "db0" {
+		t.Errorf("expected db0, got %s and %v", value, err)
+	}
+	if value, err := secondClient.Get("key").Result(); err != nil || value != "db2" {
+		t.Errorf("expected db2, got %s and %v", value, err)
+	}
+	if value, _ := serverWithDatabases.Databases[2].Get("key"); value != "db2" {
+		t.Error("expected key to have been set in the third database, got", value)
+	}
+	// The selected database is tracked per connection
+	if err := firstClient.Do("SELECT", "1").Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if err := firstClient.Get("key").Err(); err != redis.Nil {
+		t.Error("expected key to not exist in the second database, got", err)
+	}
+	if err := firstClient.Do("SELECT", "3").Err(); err == nil || err.Error() != "ERR DB index is out of range" {
+		t.Error("expected out of range error, got", err)
+	}
+	firstClient.Set("key", "db1", 0)
+	if err := secondClient.FlushDB().Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if serverWithDatabases.Databases[2].Count() != 0 || serverWithDatabases.Cache.Count() != 1 || serverWithDatabases.Databases[1].Count() != 1 {
+		t.Error("expected FLUSHDB to have only cleared the selected database")
+	}
+	if err := firstClient.FlushAll().Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	for i, database := range serverWithDatabases.Databases {
+		if database.Count() != 0 {
+			t.Errorf("expected FLUSHALL to have cleared database %d, got %d keys", i, database.Count())
+		}
+	}
+}
+
+func TestDBSIZE(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("key", "value")
+	server.Cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if size, err := client.DBSize().Result(); err != nil || size != 1 {
+		t.Errorf("expected only the key that has not expired to be counted, got %d and %v", size, err)
+	}
+}
+
+func TestTYPE(t *testing.T) {
+	defer server.Cache.Clear()
+	client.Set("key", "value", 0)
+	server.Cache.Set("slice", []int{1, 2})
+	scenarios := map[string]string{
+		"key":                     "string",
+		"slice":                   "[]int",
+		"key-that-does-not-exist": "none",
+	}
+	for key, expectedType := range scenarios {
+		if actualType, err := client.Type(key).Result(); err != nil || actualType != expectedType {
+			t.Errorf("[%s] expected type %s, got %s and %v", key, expectedType, actualType, err)
+		}
+	}
+}
+
 func TestPING(t *testing.T) {
 	if client.Ping().Val() != "PONG" {
 		t.Error("Server should've been able to pong :(")
@@ -359,13 +771,13 @@ func TestQUIT(t *testing.T) {
 	// First connection
 	testClient.Ping()
 	// Check how many connections the server has
-	numberOfConnections := server.numberOfConnections
+	numberOfConnections := atomic.LoadInt64(&server.numberOfConnections)
 	// Send QUIT to the test client
 	testClient.Do("QUIT").Val()
 	// Wait for a bit to make sure that the callback function that updates server.numberOfConnections has been called
 	time.Sleep(100 * time.Millisecond)
 	// Compare the number of connections we had before vs after QUIT
-	if numberOfConnections == server.numberOfConnections {
+	if numberOfConnections == atomic.LoadInt64(&server.numberOfConnections) {
 		t.Error("connection should've been closed")
 	}
 }
@@ -426,6 +838,33 @@ func TestINFOWithOnlyMemorySection(t *testing.T) {
 	}
 }
 
+func TestCONFIGRESETSTAT(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("key", "value")
+	client.Get("key")
+	client.Get("key-that-does-not-exist")
+	output := client.Info("STATS").Val()
+	if strings.Contains(output, "keyspace_hits:0") || strings.Contains(output, "keyspace_misses:0") {
+		t.Error("expected keyspace_hits and keyspace_misses to be non-zero")
+	}
+	if err := client.ConfigResetStat().Err(); err != nil {
+		t.Fatal(err)
+	}
+	output = client.Info("STATS").Val()
+	for _, stat := range []string{"keyspace_hits:0", "keyspace_misses:0", "evicted_keys:0", "expired_keys:0"} {
+		if !strings.Contains(output, stat) {
+			t.Errorf("expected %s after CONFIG RESETSTAT, got:\n%s", stat, output)
+		}
+	}
+}
+
+func TestCONFIGWithUnknownSubcommand(t *testing.T) {
+	c := client.Do("CONFIG", "INVALID_SUBCOMMAND")
+	if c.Err() == nil || !strings.Contains(c.Err().Error(), "unknown subcommand") {
+		t.Error("Expected server to return an error")
+	}
+}
+
 func TestSCAN(t *testing.T) {
 	defer server.Cache.Clear()
 	server.Cache.Set("vegetable", "true")
@@ -437,7 +876,7 @@ func TestSCAN(t *testing.T) {
 	}
 	keys, cursor := client.Scan(0, "k*", 9999).Val()
 	if cursor != 0 {
-		t.Error("cursor returned should've been 0, because it isn't supported yet")
+		t.Error("cursor returned should've been 0, because every key was returned")
 	}
 	if len(keys) != 2 {
 		t.Error("should've returned 2 keys")
@@ -459,14 +898,51 @@ func TestSCANIsRespectingCount(t *testing.T) {
 		t.Error("cache should have a size of 4")
 	}
 	keys, cursor := client.Scan(0, "k*", 1).Val()
-	if cursor != 0 {
-		t.Error("cursor returned should've been 0, because it isn't supported yet")
+	if cursor == 0 {
+		t.Error("cursor returned shouldn't have been 0, because not every key was returned")
 	}
 	if len(keys) != 1 {
 		t.Error("should've returned 1 key, because the limit was set to 1")
 	}
 }
 
+func TestSCANWithCursor(t *testing.T) {
+	defer server.Cache.Clear()
+	for i := 0; i < 95; i++ {
+		server.Cache.Set(fmt.Sprintf("key%d", i), "value")
+	}
+	server.Cache.Set("other", "value")
+	returnedKeys := make(map[string]int)
+	var cursor uint64
+	for iterations := 0; ; iterations++ {
+		if iterations > 10 {
+			t.Fatal("expected the iteration to be over after 10 SCAN commands")
+		}
+		var keys []string
+		var err error
+		keys, cursor, err = client.Scan(cursor, "key*", 10).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range keys {
+			returnedKeys[key]++
+		}
+		if cursor == 0 {
+			break
+		}
+		// Keys created during the iteration may or may not be returned, but must not prevent it from completing
+		server.Cache.Set(fmt.Sprintf("new-key%d", iterations), "value")
+	}
+	if len(returnedKeys) != 95 {
+		t.Errorf("expected 95 keys to have been returned, got %d", len(returnedKeys))
+	}
+	for key, n := range returnedKeys {
+		if n != 1 {
+			t.Errorf("expected %s to have been returned once, got %d", key, n)
+		}
+	}
+}
+
 func TestSCANWithDefaultLimit(t *testing.T) {
 	defer server.Cache.Clear()
 	for i := 0; i < 20; i++ {
@@ -483,6 +959,49 @@ func TestSCANWithDefaultLimit(t *testing.T) {
 	}
 }
 
+func TestSCANWithTYPE(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("string", "value")
+	server.Cache.Set("number", 5)
+	server.Cache.Set("slice", []int{1, 2})
+	output, err := client.Do("SCAN", 0, "COUNT", 10, "TYPE", "string").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprintf("%v", output) != "[0 [string number]]" {
+		t.Error("expected string and number with a cursor of 0, got", output)
+	}
+	if output, _ := client.Do("SCAN", 0, "TYPE", "[]int").Result(); fmt.Sprintf("%v", output) != "[0 [slice]]" {
+		t.Error("expected slice, got", output)
+	}
+	if output, _ := client.Do("SCAN", 0, "TYPE", "STRING").Result(); fmt.Sprintf("%v", output) != "[0 [string number]]" {
+		t.Error("expected TYPE to be case-insensitive, got", output)
+	}
+}
+
+func TestSCANIsConsistentWithCacheScan(t *testing.T) {
+	defer server.Cache.Clear()
+	for i := 0; i < 30; i++ {
+		server.Cache.Set(fmt.Sprintf("key%d", i), "value")
+		server.Cache.Set(fmt.Sprintf("other%d", i), "value")
+	}
+	var cursor, expectedCursor uint64
+	for {
+		keys, nextCursor, err := client.Scan(cursor, "key*", 7).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedKeys []string
+		expectedKeys, expectedCursor = server.Cache.Scan(cursor, "key*", 7)
+		if nextCursor != expectedCursor || !reflect.DeepEqual(keys, expectedKeys) {
+			t.Fatalf("expected %v and %d, got %v and %d", expectedKeys, expectedCursor, keys, nextCursor)
+		}
+		if cursor = nextCursor; cursor == 0 {
+			break
+		}
+	}
+}
+
 func TestSCANWithInvalidNumberOfArgs(t *testing.T) {
 	c := client.Do("SCAN")
 	if !strings.Contains(c.Err().Error(), "wrong number of arguments") {
@@ -504,6 +1023,15 @@ func TestSCANWithInvalidCount(t *testing.T) {
 	}
 }
 
+func TestSCANWithCountLowerThanOne(t *testing.T) {
+	for _, count := range []int{0, -1} {
+		c := client.Do("SCAN", 0, "COUNT", count)
+		if c.Err() == nil || c.Err().Error() != "ERR syntax error" {
+			t.Errorf("expected COUNT %d to return a syntax error, got %v", count, c.Err())
+		}
+	}
+}
+
 func TestSCANWithSyntaxError(t *testing.T) {
 	c := client.Do("SCAN", 0, "COUNT", 10, "INVALID-ARGUMENT", "1234")
 	if c.Err().Error() != "ERR syntax error" {
@@ -548,6 +1076,222 @@ func TestTTLWithKeyThatDoesNotHaveAnExpiration(t *testing.T) {
 	}
 }
 
+func TestPTTL(t *testing.T) {
+	defer server.Cache.Clear()
+	client.Set("key", "value", 500*time.Millisecond)
+	ttl, err := client.PTTL("key").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A key expiring in less than a second must not be reported as 0
+	if ttl < 490*time.Millisecond || ttl > 500*time.Millisecond {
+		t.Error("expected PTTL of ~500ms, got", ttl)
+	}
+}
+
+func TestPTTLWithKeyThatDoesNotExistOrDoesNotHaveAnExpiration(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("key", "value")
+	// The client converts the reply into a duration, which means that -1 and -2 are returned as -1ms and -2ms
+	if ttl := client.PTTL("key").Val(); ttl != -time.Millisecond {
+		t.Errorf("expected PTTL to return -1 because the key does not have an expiration time, got %v", ttl)
+	}
+	if ttl := client.PTTL("key-that-does-not-exist").Val(); ttl != -2*time.Millisecond {
+		t.Errorf("expected PTTL to return -2 because the key does not exist, got %v", ttl)
+	}
+}
+
+func TestRENAME(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.SetWithTTL("key", "value", time.Hour)
+	server.Cache.Set("new-key", "other-value")
+	if err := client.Rename("key", "new-key").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := server.Cache.Get("new-key"); value != "value" {
+		t.Errorf("expected new-key to have the value of key, got %v", value)
+	}
+	if ttl, err := server.Cache.TTL("new-key"); err != nil || ttl <= 0 {
+		t.Error("expected new-key to have kept the expiration time of key")
+	}
+	if _, ok := server.Cache.Get("key"); ok {
+		t.Error("expected key to no longer exist")
+	}
+}
+
+func TestRENAMEWithKeyThatDoesNotExist(t *testing.T) {
+	c := client.Rename("key-that-does-not-exist", "new-key")
+	if c.Err() == nil || c.Err().Error() != "ERR no such key" {
+		t.Error("Expected server to return an error")
+	}
+}
+
+func TestCOPY(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.SetWithTTL("key", "value", time.Hour)
+	server.Cache.Set("existing-key", "existing-value")
+	if copied, err := client.Do("COPY", "key", "new-key").Int64(); err != nil || copied != 1 {
+		t.Errorf("expected key to have been copied, got %d and %v", copied, err)
+	}
+	if value, err := client.Get("new-key").Result(); err != nil || value != "value" {
+		t.Errorf("expected value, got %s and %v", value, err)
+	}
+	if ttl, err := client.TTL("new-key").Result(); err != nil || ttl <= 0 {
+		t.Errorf("expected new key to have kept the expiration time of the source, got %s and %v", ttl, err)
+	}
+	if copied, err := client.Do("COPY", "key", "existing-key").Int64(); err != nil || copied != 0 {
+		t.Errorf("expected 0, since the destination already exists, got %d and %v", copied, err)
+	}
+	if copied, err := client.Do("COPY", "key", "existing-key", "REPLACE").Int64(); err != nil || copied != 1 {
+		t.Errorf("expected destination to have been replaced, got %d and %v", copied, err)
+	}
+	if value, _ := client.Get("existing-key").Result(); value != "value" {
+		t.Error("expected value, got", value)
+	}
+	if copied, err := client.Do("COPY", "key-that-does-not-exist", "new-key", "REPLACE").Int64(); err != nil || copied != 0 {
+		t.Errorf("expected 0, since the source doesn't exist, got %d and %v", copied, err)
+	}
+	if err := client.Do("COPY", "key", "key").Err(); err == nil || err.Error() != "ERR source and destination objects are the same" {
+		t.Error("expected same objects error, got", err)
+	}
+	if err := client.Do("COPY", "key", "new-key", "DB", "1").Err(); err == nil {
+		t.Error("expected an error, since the DB option is not supported")
+	}
+}
+
+func TestRENAMENX(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("key", "value")
+	renamed, err := client.RenameNX("key", "new-key").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !renamed {
+		t.Error("expected key to have been renamed")
+	}
+	if value, _ := server.Cache.Get("new-key"); value != "value" {
+		t.Errorf("expected new-key to have the value of key, got %v", value)
+	}
+	if _, ok := server.Cache.Get("key"); ok {
+		t.Error("expected key to no longer exist")
+	}
+}
+
+func TestRENAMENXWhenNewKeyAlreadyExists(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("key", "value")
+	server.Cache.Set("new-key", "other-value")
+	renamed, err := client.RenameNX("key", "new-key").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed {
+		t.Error("expected key to not have been renamed")
+	}
+	if value, _ := server.Cache.Get("new-key"); value != "other-value" {
+		t.Errorf("expected new-key to have been left untouched, got %v", value)
+	}
+}
+
+func TestRENAMENXWithKeyThatDoesNotExist(t *testing.T) {
+	c := client.RenameNX("key-that-does-not-exist", "new-key")
+	if c.Err() == nil || c.Err().Error() != "ERR no such key" {
+		t.Error("Expected server to return an error")
+	}
+}
+
+func TestROLE(t *testing.T) {
+	output, err := client.Do("ROLE").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	role, ok := output.([]interface{})
+	if !ok || len(role) != 3 {
+		t.Fatalf("expected an array with 3 elements, got %v", output)
+	}
+	if role[0] != "master" {
+		t.Errorf("expected role to be master, got %v", role[0])
+	}
+	if role[1] != int64(0) {
+		t.Errorf("expected replication offset to be 0, got %v", role[1])
+	}
+	if replicas, ok := role[2].([]interface{}); !ok || len(replicas) != 0 {
+		t.Errorf("expected no replicas, got %v", role[2])
+	}
+}
+
+func TestDEBUGCHANGEREPLID(t *testing.T) {
+	if err := client.Do("DEBUG", "CHANGE-REPL-ID").Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+}
+
+func TestDEBUGVERIFY(t *testing.T) {
+	defer server.Cache.Clear()
+	client.MSet("k1", "v1", "k2", "v2", "k3", "v3")
+	client.Get("k1")
+	client.Del("k2")
+	if err := client.Do("DEBUG", "VERIFY").Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if err := client.Do("DEBUG", "VERIFY", "extra").Err(); err == nil || !strings.Contains(err.Error(), "wrong number of arguments") {
+		t.Error("expected wrong number of arguments error, got", err)
+	}
+}
+
+func TestDEBUGWithUnknownSubcommand(t *testing.T) {
+	c := client.Do("DEBUG", "INVALID_SUBCOMMAND")
+	if c.Err() == nil || !strings.Contains(c.Err().Error(), "unknown subcommand") {
+		t.Error("Expected server to return an error")
+	}
+}
+
+func TestOBJECTREFCOUNT(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("key", "value")
+	refCount, err := client.ObjectRefCount("key").Result()
+	if err != nil {
+		t.Error(err)
+	}
+	if refCount < 1 {
+		t.Errorf("expected a positive refcount, got %d", refCount)
+	}
+}
+
+func TestOBJECTREFCOUNTWithKeyThatDoesNotExist(t *testing.T) {
+	defer server.Cache.Clear()
+	c := client.ObjectRefCount("key-that-does-not-exist")
+	if c.Err() == nil || c.Err().Error() != "ERR no such key" {
+		t.Error("Expected server to return an error")
+	}
+}
+
+func TestOBJECTIDLETIMEAndFREQ(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("key", "value")
+	client.Get("key")
+	if idleTime, err := client.ObjectIdleTime("key").Result(); err != nil || idleTime != 0 {
+		t.Errorf("expected an idle time of 0, got %v and %v", idleTime, err)
+	}
+	for i := 0; i < 2; i++ {
+		if frequency, err := client.Do("OBJECT", "FREQ", "key").Int64(); err != nil || frequency != 1 {
+			t.Errorf("expected a frequency of 1, since OBJECT shouldn't count as accessing the key, got %v and %v", frequency, err)
+		}
+	}
+	for _, subcommand := range []string{"IDLETIME", "FREQ"} {
+		if err := client.Do("OBJECT", subcommand, "key-that-does-not-exist").Err(); err == nil || err.Error() != "ERR no such key" {
+			t.Errorf("[%s] expected no such key error, got %v", subcommand, err)
+		}
+	}
+}
+
+func TestOBJECTWithUnknownSubcommand(t *testing.T) {
+	c := client.Do("OBJECT", "INVALID_SUBCOMMAND", "key")
+	if c.Err() == nil || !strings.Contains(c.Err().Error(), "unknown subcommand") {
+		t.Error("Expected server to return an error")
+	}
+}
+
 func TestUnknownCommand(t *testing.T) {
 	c := client.Do("INVALID_COMMAND")
 	if !strings.Contains(c.Err().Error(), "unknown command") {
@@ -587,6 +1331,421 @@ func TestServer_WithAutoSave(t *testing.T) {
 	}
 }
 
+func TestServer_WithAutoSaveOnChanges(t *testing.T) {
+	file := t.TempDir() + "/" + "TestServer_WithAutoSaveOnChanges.bak"
+	serverWithAutoSaveOnChanges := NewServer(gocache.NewCache()).WithPort(16169).WithAutoSave(0, file).WithAutoSaveOnChanges(5, time.Minute)
+	stopped := make(chan struct{})
+	go func() {
+		serverWithAutoSaveOnChanges.Start()
+		close(stopped)
+	}()
+	defer func() {
+		serverWithAutoSaveOnChanges.Stop()
+		// Wait for the save performed when the server stops before the temporary directory is removed
+		<-stopped
+	}()
+	autoSaveClient := redis.NewClient(&redis.Options{Addr: "localhost:16169"})
+	defer autoSaveClient.Close()
+	for autoSaveClient.Ping().Err() != nil {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 4; i++ {
+		autoSaveClient.Set(fmt.Sprintf("key%d", i), "value", 0)
+	}
+	// Read commands must not count as changes
+	for i := 0; i < 10; i++ {
+		autoSaveClient.Get("key0")
+	}
+	numberOfKeysSaved := func() int {
+		cache := gocache.NewCache()
+		if _, err := cache.ReadFromFile(file); err != nil {
+			t.Fatal(err)
+		}
+		return cache.Count()
+	}
+	time.Sleep(50 * time.Millisecond)
+	if numberOfKeysSaved() != 0 {
+		t.Fatal("expected no save to have been triggered, since there have only been 4 changes")
+	}
+	autoSaveClient.Set("key4", "value", 0)
+	for i := 0; i < 100 && numberOfKeysSaved() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if count := numberOfKeysSaved(); count != 5 {
+		t.Errorf("expected a save of the 5 keys to have been triggered after 5 changes, got %d keys", count)
+	}
+}
+
+func TestServer_WithMaxPipelineDepth(t *testing.T) {
+	serverWithMaxPipelineDepth := NewServer(gocache.NewCache().WithMaxSize(0)).WithPort(16164).WithMaxPipelineDepth(10)
+	go serverWithMaxPipelineDepth.Start()
+	defer serverWithMaxPipelineDepth.Stop()
+	pipelineClient := redis.NewClient(&redis.Options{Addr: "localhost:16164"})
+	defer pipelineClient.Close()
+	for pipelineClient.Ping().Err() != nil {
+		time.Sleep(time.Millisecond)
+	}
+	const NumberOfCommands = 10000
+	pipeline := pipelineClient.Pipeline()
+	for i := 0; i < NumberOfCommands; i++ {
+		pipeline.Set(fmt.Sprintf("key%d", i), strings.Repeat("0", 128), 0)
+		pipeline.Get(fmt.Sprintf("key%d", i))
+	}
+	cmds, err := pipeline.Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds) != NumberOfCommands*2 {
+		t.Fatalf("expected %d replies, got %d", NumberOfCommands*2, len(cmds))
+	}
+	for i := 1; i < len(cmds); i += 2 {
+		if value := cmds[i].(*redis.StringCmd).Val(); value != strings.Repeat("0", 128) {
+			t.Fatalf("expected reply %d to be the value that was set, got %s", i, value)
+		}
+	}
+	if serverWithMaxPipelineDepth.Cache.Count() != NumberOfCommands {
+		t.Errorf("expected %d keys, got %d", NumberOfCommands, serverWithMaxPipelineDepth.Cache.Count())
+	}
+}
+
+func TestKEYS(t *testing.T) {
+	defer server.Cache.Clear()
+	const NumberOfKeys = 5000
+	for i := 0; i < NumberOfKeys; i++ {
+		server.Cache.Set(fmt.Sprintf("key%d", i), "value")
+	}
+	server.Cache.Set("other", "value")
+	keys, err := client.Keys("key*").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != NumberOfKeys {
+		t.Errorf("expected %d keys, got %d", NumberOfKeys, len(keys))
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "key") {
+			t.Errorf("expected key %s to match pattern", key)
+		}
+	}
+}
+
+func TestKEYSWhenClientDoesNotReadReply(t *testing.T) {
+	keysServer := NewServer(gocache.NewCache().WithMaxSize(gocache.NoMaxSize)).WithPort(16174)
+	go keysServer.Start()
+	defer keysServer.Stop()
+	keysClient := redis.NewClient(&redis.Options{Addr: "localhost:16174"})
+	defer keysClient.Close()
+	for deadline := time.Now().Add(5 * time.Second); keysClient.Ping().Err() != nil; {
+		if time.Now().After(deadline) {
+			t.Fatal("server did not start in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// The reply must be much larger than what the socket buffers can hold
+	padding := strings.Repeat("x", 100)
+	for i := 0; i < 100000; i++ {
+		keysServer.Cache.Set(fmt.Sprintf("key%d-%s", i, padding), "value")
+	}
+	conn, err := net.Dial("tcp", "localhost:16174")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("KEYS *\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	done := make(chan error, 1)
+	go func() {
+		done <- keysClient.Set("other", "value", 0).Err()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error("expected no error, got", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected SET to not be blocked by a client that isn't reading the reply of KEYS")
+	}
+}
+
+func TestKEYSWithWildcard(t *testing.T) {
+	defer server.Cache.Clear()
+	server.Cache.Set("key1", "value")
+	server.Cache.Set("key2", "value")
+	server.Cache.SetWithTTL("expired", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	keys, err := client.Keys("*").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "key1" || keys[1] != "key2" {
+		t.Errorf("expected every key except the expired one, got %v", keys)
+	}
+}
+
+func TestServer_WithMaxKeysReply(t *testing.T) {
+	serverWithMaxKeysReply := NewServer(gocache.NewCache().WithMaxSize(0)).WithPort(16165).WithMaxKeysReply(100)
+	go serverWithMaxKeysReply.Start()
+	defer serverWithMaxKeysReply.Stop()
+	keysClient := redis.NewClient(&redis.Options{Addr: "localhost:16165"})
+	defer keysClient.Close()
+	for keysClient.Ping().Err() != nil {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 1000; i++ {
+		serverWithMaxKeysReply.Cache.Set(fmt.Sprintf("key%d", i), "value")
+	}
+	keys, err := keysClient.Keys("*").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 100 {
+		t.Errorf("expected the reply to be capped at 100 keys, got %d", len(keys))
+	}
+}
+
+func TestServer_WithManyConnectionsAtOnce(t *testing.T) {
+	serverWithManyConnections := NewServer(gocache.NewCache()).WithPort(16166)
+	go serverWithManyConnections.Start()
+	defer serverWithManyConnections.Stop()
+	for {
+		if conn, err := net.Dial("tcp", "localhost:16166"); err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	const NumberOfConnections = 200
+	errs := make(chan error, NumberOfConnections)
+	wg := sync.WaitGroup{}
+	for i := 0; i < NumberOfConnections; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := redis.NewClient(&redis.Options{Addr: "localhost:16166", PoolSize: 1})
+			defer c.Close()
+			errs <- c.Ping().Err()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error("expected all connections to succeed, got", err)
+		}
+	}
+}
+
+func TestServer_WithCommandTimeout(t *testing.T) {
+	serverWithCommandTimeout := NewServer(gocache.NewCache()).WithPort(16167).WithCommandTimeout(50 * time.Millisecond)
+	go serverWithCommandTimeout.Start()
+	defer serverWithCommandTimeout.Stop()
+	timeoutClient := redis.NewClient(&redis.Options{Addr: "localhost:16167", ReadTimeout: 5 * time.Second})
+	defer timeoutClient.Close()
+	for timeoutClient.Ping().Err() != nil {
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	err := timeoutClient.Do("DEBUG", "SLEEP", "1").Err()
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Error("expected command to have timed out, got", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("expected the server to reply as soon as the command timed out, took", time.Since(start))
+	}
+	// Commands that complete on time must not be affected
+	if err := timeoutClient.Set("key", "value", 0).Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if value, err := timeoutClient.Get("key").Result(); err != nil || value != "value" {
+		t.Errorf("expected value, got %s and %v", value, err)
+	}
+	if err := timeoutClient.Do("DEBUG", "SLEEP", "0").Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+}
+
+func TestServer_WithCommandTimeoutAndWriteCommand(t *testing.T) {
+	// Evicting an entry makes the SET below take longer than the timeout
+	cache := gocache.NewCache().WithMaxSize(1).WithOnEvict(func(key string, value interface{}) {
+		time.Sleep(200 * time.Millisecond)
+	})
+	serverWithCommandTimeout := NewServer(cache).WithPort(16175).WithCommandTimeout(50 * time.Millisecond)
+	go serverWithCommandTimeout.Start()
+	defer serverWithCommandTimeout.Stop()
+	timeoutClient := redis.NewClient(&redis.Options{Addr: "localhost:16175", ReadTimeout: 5 * time.Second})
+	defer timeoutClient.Close()
+	for start := time.Now(); timeoutClient.Ping().Err() != nil; time.Sleep(time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("expected the server to have started, got", timeoutClient.Ping().Err())
+		}
+	}
+	if err := timeoutClient.Set("first", "value", 0).Err(); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	// A write command must never be reported as having failed while it actually went through
+	if err := timeoutClient.Set("second", "value", 0).Err(); err != nil {
+		t.Error("expected write command to have completed instead of timing out, got", err)
+	}
+	if _, ok := cache.Get("second"); !ok {
+		t.Error("expected the key to have been set")
+	}
+}
+
+func TestServer_WithCommandTimeoutAndConnectionState(t *testing.T) {
+	serverWithCommandTimeout := NewServer(gocache.NewCache()).WithPort(16173).WithCommandTimeout(50 * time.Millisecond).WithDatabases(2).WithPassword("secret")
+	go serverWithCommandTimeout.Start()
+	defer serverWithCommandTimeout.Stop()
+	// The client runs AUTH and SELECT when it connects, neither of which may be handled with a timeout, since they
+	// change the state of the connection
+	timeoutClient := redis.NewClient(&redis.Options{Addr: "localhost:16173", Password: "secret", DB: 1, PoolSize: 1})
+	defer timeoutClient.Close()
+	for start := time.Now(); timeoutClient.Ping().Err() != nil; time.Sleep(time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("expected the client to have been able to authenticate and select a database, got", timeoutClient.Ping().Err())
+		}
+	}
+	if err := timeoutClient.Set("key", "value", 0).Err(); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if _, ok := serverWithCommandTimeout.Databases[1].Get("key"); !ok {
+		t.Error("expected the key to have been set in the database selected by the client")
+	}
+	if keys, err := timeoutClient.Keys("*").Result(); err != nil || !reflect.DeepEqual(keys, []string{"key"}) {
+		t.Errorf("expected [key], got %v and %v", keys, err)
+	}
+	if err := timeoutClient.Do("HELLO", "2").Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if err := timeoutClient.Do("SELECT", "0").Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if err := timeoutClient.Do("SET", "other-key", "value").Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if _, ok := serverWithCommandTimeout.Cache.Get("other-key"); !ok {
+		t.Error("expected the key to have been set in the database selected by SELECT")
+	}
+}
+
+func TestServer_WithPassword(t *testing.T) {
+	serverWithPassword := NewServer(gocache.NewCache()).WithPort(16170).WithPassword("secret")
+	go serverWithPassword.Start()
+	defer serverWithPassword.Stop()
+	unauthenticatedClient := redis.NewClient(&redis.Options{Addr: "localhost:16170", PoolSize: 1})
+	defer unauthenticatedClient.Close()
+	for err := unauthenticatedClient.Ping().Err(); err == nil || !strings.HasPrefix(err.Error(), "NOAUTH"); err = unauthenticatedClient.Ping().Err() {
+		time.Sleep(time.Millisecond)
+	}
+	if err := unauthenticatedClient.Do("INVALID_COMMAND").Err(); err == nil || err.Error() != "NOAUTH Authentication required." {
+		t.Error("expected NOAUTH error for unknown commands as well, got", err)
+	}
+	if err := unauthenticatedClient.Do("AUTH", "wrong-password").Err(); err == nil || err.Error() != "ERR invalid password" {
+		t.Error("expected invalid password error, got", err)
+	}
+	if err := unauthenticatedClient.Do("AUTH", "someone", "secret").Err(); err == nil || err.Error() != "ERR invalid password" {
+		t.Error("expected invalid password error for a username other than default, got", err)
+	}
+	authenticatedClient := redis.NewClient(&redis.Options{Addr: "localhost:16170", Password: "secret", PoolSize: 1})
+	defer authenticatedClient.Close()
+	if err := authenticatedClient.Set("key", "value", 0).Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	// Authenticating a connection must not authenticate the others
+	if err := unauthenticatedClient.Get("key").Err(); err == nil || !strings.HasPrefix(err.Error(), "NOAUTH") {
+		t.Error("expected NOAUTH error, got", err)
+	}
+	if err := unauthenticatedClient.Do("AUTH", "default", "secret").Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if value, err := unauthenticatedClient.Get("key").Result(); err != nil || value != "value" {
+		t.Errorf("expected value, got %s and %v", value, err)
+	}
+	helloClient := redis.NewClient(&redis.Options{Addr: "localhost:16170", PoolSize: 1})
+	defer helloClient.Close()
+	if err := helloClient.Do("HELLO").Err(); err == nil || !strings.HasPrefix(err.Error(), "NOAUTH") {
+		t.Error("expected NOAUTH error, got", err)
+	}
+	if err := helloClient.Do("HELLO", "2", "AUTH", "default", "wrong-password").Err(); err == nil || !strings.HasPrefix(err.Error(), "WRONGPASS") {
+		t.Error("expected WRONGPASS error, got", err)
+	}
+	if err := helloClient.Do("HELLO", "2", "AUTH", "default", "secret", "SETNAME", "name").Err(); err != nil {
+		t.Error("expected HELLO to have authenticated the connection, got", err)
+	}
+	if err := helloClient.Get("key").Err(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+}
+
+func TestHELLO(t *testing.T) {
+	for _, args := range [][]interface{}{{"HELLO"}, {"HELLO", "2"}} {
+		reply, err := client.Do(args...).Result()
+		if err != nil {
+			t.Fatalf("%v expected no error, got %v", args, err)
+		}
+		fields := reply.([]interface{})
+		if len(fields) != 14 {
+			t.Fatalf("%v expected 7 key/value pairs, got %d elements", args, len(fields))
+		}
+		info := make(map[string]interface{})
+		for i := 0; i < len(fields); i += 2 {
+			info[fields[i].(string)] = fields[i+1]
+		}
+		if info["server"] != "gocache" || info["proto"] != int64(2) || info["mode"] != "standalone" || info["role"] != "master" {
+			t.Errorf("%v unexpected reply: %v", args, info)
+		}
+		if id, ok := info["id"].(int64); !ok || id < 1 {
+			t.Errorf("%v expected a positive connection id, got %v", args, info["id"])
+		}
+	}
+}
+
+func TestHELLOWithUnsupportedProtocolVersion(t *testing.T) {
+	if err := client.Do("HELLO", "3").Err(); err == nil || err.Error() != "NOPROTO unsupported protocol version" {
+		t.Error("expected NOPROTO error, got", err)
+	}
+	if err := client.Do("HELLO", "three").Err(); err == nil || !strings.Contains(err.Error(), "not an integer") {
+		t.Error("expected not an integer error, got", err)
+	}
+	if err := client.Do("HELLO", "2", "INVALID").Err(); err == nil || err.Error() != "ERR syntax error" {
+		t.Error("expected syntax error, got", err)
+	}
+}
+
+func TestAUTHWithoutPassword(t *testing.T) {
+	if err := client.Do("AUTH", "password").Err(); err == nil || !strings.Contains(err.Error(), "without any password configured") {
+		t.Error("expected an error, since the server has no password, got", err)
+	}
+}
+
+func TestServer_WithScanHardLimit(t *testing.T) {
+	serverWithScanHardLimit := NewServer(gocache.NewCache()).WithPort(16168).WithScanHardLimit(5)
+	go serverWithScanHardLimit.Start()
+	defer serverWithScanHardLimit.Stop()
+	scanClient := redis.NewClient(&redis.Options{Addr: "localhost:16168"})
+	defer scanClient.Close()
+	for scanClient.Ping().Err() != nil {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 100; i++ {
+		serverWithScanHardLimit.Cache.Set(fmt.Sprintf("key%d", i), "value")
+	}
+	for _, count := range []int64{1000, 0} {
+		keys, _, err := scanClient.Scan(0, "*", count).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 5 {
+			t.Errorf("expected COUNT %d to have been clamped to 5, got %d keys", count, len(keys))
+		}
+	}
+	if keys, _, _ := scanClient.Scan(0, "*", 3).Result(); len(keys) != 3 {
+		t.Errorf("expected a COUNT lower than the hard limit to be respected, got %d keys", len(keys))
+	}
+}
+
 func TestServer_StartWhenAlreadyStarted(t *testing.T) {
 	err := server.Start()
 	if err == nil {
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tidwall/redcon"
+)
+
+// commandsHandledWithoutTimeout are the commands that are never handled by handleWithTimeout, on top of the commands
+// flagged as write or pubsub (see isHandledWithTimeout)
+//
+// SELECT, AUTH, HELLO, CLIENT and QUIT change the state of the connection, which must only ever be modified by the goroutine
+// handling the connection, since a command that timed out keeps running while the next command is being handled.
+// KEYS streams its reply to the client as it goes, which cannot be done with a reply that is discarded on timeout.
+var commandsHandledWithoutTimeout = map[string]bool{
+	"SELECT": true,
+	"AUTH":   true,
+	"HELLO":  true,
+	"CLIENT": true,
+	"QUIT":   true,
+	"KEYS":   true,
+}
+
+// bufferedConn is a redcon.Conn that buffers the replies written to it instead of sending them to the client
+//
+// It also holds a copy of the state of the connection, so that a handler that keeps running after timing out never
+// accesses the state of the connection while the goroutine handling the connection modifies it.
+type bufferedConn struct {
+	redcon.Conn
+	writer  *redcon.Writer
+	context interface{}
+}
+
+func newBufferedConn(conn redcon.Conn) *bufferedConn {
+	state := *connectionOf(conn)
+	return &bufferedConn{Conn: conn, writer: redcon.NewWriter(nil), context: &state}
+}
+
+func (conn *bufferedConn) Context() interface{}        { return conn.context }
+func (conn *bufferedConn) SetContext(v interface{})    { conn.context = v }
+func (conn *bufferedConn) WriteError(msg string)       { conn.writer.WriteError(msg) }
+func (conn *bufferedConn) WriteString(str string)      { conn.writer.WriteString(str) }
+func (conn *bufferedConn) WriteBulk(bulk []byte)       { conn.writer.WriteBulk(bulk) }
+func (conn *bufferedConn) WriteBulkString(bulk string) { conn.writer.WriteBulkString(bulk) }
+func (conn *bufferedConn) WriteInt(num int)            { conn.writer.WriteInt(num) }
+func (conn *bufferedConn) WriteInt64(num int64)        { conn.writer.WriteInt64(num) }
+func (conn *bufferedConn) WriteUint64(num uint64)      { conn.writer.WriteUint64(num) }
+func (conn *bufferedConn) WriteArray(count int)        { conn.writer.WriteArray(count) }
+func (conn *bufferedConn) WriteNull()                  { conn.writer.WriteNull() }
+func (conn *bufferedConn) WriteRaw(data []byte)        { conn.writer.WriteRaw(data) }
+func (conn *bufferedConn) WriteAny(v interface{})      { conn.writer.WriteAny(v) }
+
+// handleWithTimeout handles a command, but replies with an error instead if handling the command takes longer than
+// CommandTimeout
+//
+// The reply of the command is buffered until the command completes, so that nothing is sent to the client if the
+// command times out. Note that a command that timed out is not interrupted, only its reply is discarded, which is why
+// commands that change the state of the connection or the content of the cache must not be handled by this function
+// (see isHandledWithTimeout).
+func (server *Server) handleWithTimeout(c *command, cmd redcon.Command, conn redcon.Conn) {
+	buffered := newBufferedConn(conn)
+	// The arguments of a command are only valid until this function returns, which may happen before the command
+	// completes, so they must be copied
+	cmd = copyCommand(cmd)
+	done := make(chan struct{})
+	go func() {
+		c.handler(server, cmd, buffered)
+		close(done)
+	}()
+	timer := time.NewTimer(server.CommandTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		conn.WriteRaw(buffered.writer.Buffer())
+	case <-timer.C:
+		conn.WriteError(fmt.Sprintf("ERR command timed out after %s", server.CommandTimeout))
+	}
+}
+
+// isHandledWithTimeout returns whether a command must be handled by handleWithTimeout rather than directly
+//
+// Write commands are never handled with a timeout, because a command that timed out is not interrupted: replying with
+// an error while the write still goes through would lead the client to believe that it failed, and a client retrying
+// it would apply it twice. Pub/sub commands may detach the connection, which must happen on the goroutine handling the
+// connection.
+func (server *Server) isHandledWithTimeout(c *command, name string) bool {
+	return server.CommandTimeout > 0 && !c.hasFlag("write") && !c.hasFlag("pubsub") && !commandsHandledWithoutTimeout[name]
+}
+
+func copyCommand(cmd redcon.Command) redcon.Command {
+	copied := redcon.Command{
+		Raw:  append([]byte(nil), cmd.Raw...),
+		Args: make([][]byte, len(cmd.Args)),
+	}
+	for i, arg := range cmd.Args {
+		copied.Args[i] = append([]byte(nil), arg...)
+	}
+	return copied
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TwinProduction/gocache"
+	"github.com/tidwall/redcon"
+)
+
+// invalidationChannel is the channel to which the invalidation messages of CLIENT TRACKING are sent
+const invalidationChannel = "__redis__:invalidate"
+
+// tracking keeps track of the keys read by the connections that enabled client-side caching through CLIENT TRACKING
+//
+// Each key read is watched through gocache.Cache.WatchKey until it is modified, at which point the connections that
+// read it are sent an invalidation message and the key is no longer tracked until it is read again.
+type tracking struct {
+	mutex sync.Mutex
+
+	// keys are the keys tracked in each database
+	keys map[*gocache.Cache]map[string]*trackedKey
+}
+
+// trackedKey is a key read by at least one connection that enabled CLIENT TRACKING
+type trackedKey struct {
+	// redirects are the ids of the connections to which the invalidation message of the key must be sent
+	redirects map[int64]bool
+
+	// cancel stops watching the key
+	cancel func()
+}
+
+// track registers the keys of a read command run by a connection that enabled CLIENT TRACKING, so that the connection
+// it redirects to is sent an invalidation message once any of these keys is modified
+//
+// This must be called before the command is handled, since a key modified between the moment it is read and the
+// moment it is tracked would otherwise never be invalidated.
+func (server *Server) track(c *command, cmd redcon.Command, conn redcon.Conn) {
+	redirect := connectionOf(conn).trackingRedirect
+	cache := server.cacheOf(conn)
+	server.tracking.mutex.Lock()
+	defer server.tracking.mutex.Unlock()
+	if server.tracking.keys == nil {
+		server.tracking.keys = make(map[*gocache.Cache]map[string]*trackedKey)
+	}
+	if server.tracking.keys[cache] == nil {
+		server.tracking.keys[cache] = make(map[string]*trackedKey)
+	}
+	for _, key := range c.keysOf(cmd) {
+		if tracked, ok := server.tracking.keys[cache][key]; ok {
+			tracked.redirects[redirect] = true
+			continue
+		}
+		key := key
+		tracked := &trackedKey{redirects: map[int64]bool{redirect: true}}
+		// The function is called while the cache is locked, so the invalidation must happen on another goroutine.
+		// It can't happen before cancel is set, since invalidate needs the lock of tracking, which is held until then.
+		tracked.cancel = cache.WatchKey(key, func(op string, value interface{}) {
+			go server.invalidate(cache, key, tracked)
+		})
+		server.tracking.keys[cache][key] = tracked
+	}
+}
+
+// invalidate stops tracking a key that has been modified, and sends an invalidation message to every connection that
+// a connection that read the key redirects to
+func (server *Server) invalidate(cache *gocache.Cache, key string, tracked *trackedKey) {
+	server.tracking.mutex.Lock()
+	if server.tracking.keys[cache][key] != tracked {
+		// The key has already been invalidated, and it may have been read again since
+		server.tracking.mutex.Unlock()
+		return
+	}
+	delete(server.tracking.keys[cache], key)
+	server.tracking.mutex.Unlock()
+	tracked.cancel()
+	for _, s := range server.pubSub.subscribersOf(invalidationChannel) {
+		if tracked.redirects[s.id] {
+			server.sendMessage(s, invalidationChannel, func(conn redcon.Conn) {
+				conn.WriteArray(1)
+				conn.WriteBulkString(key)
+			})
+		}
+	}
+}
+
+// keysOf returns the keys passed to a command, as described by its firstKey, lastKey and step
+func (c *command) keysOf(cmd redcon.Command) []string {
+	if c.firstKey == 0 {
+		return nil
+	}
+	lastKey := c.lastKey
+	if lastKey < 0 || lastKey >= len(cmd.Args) {
+		lastKey = len(cmd.Args) - 1
+	}
+	var keys []string
+	for index := c.firstKey; index <= lastKey; index += c.step {
+		keys = append(keys, string(cmd.Args[index]))
+	}
+	return keys
+}
+
+// client is used to manage the connection of the client
+//
+// Supported forms are CLIENT ID and CLIENT TRACKING ON|OFF [REDIRECT id].
+func (server *Server) client(cmd redcon.Command, conn redcon.Conn) {
+	switch strings.ToUpper(string(cmd.Args[1])) {
+	case "ID":
+		if len(cmd.Args) != 2 {
+			conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s|%s' command", string(cmd.Args[0]), string(cmd.Args[1])))
+			return
+		}
+		conn.WriteInt64(connectionOf(conn).id)
+	case "TRACKING":
+		server.clientTracking(cmd, conn)
+	default:
+		conn.WriteError(fmt.Sprintf("ERR unknown subcommand '%s'", string(cmd.Args[1])))
+	}
+}
+
+// clientTracking is used to enable or disable client-side caching for the connection of the client
+//
+// Only RESP2 is supported, which means that invalidation messages can't be pushed to the connection itself, but must be
+// sent to another connection instead, designated by REDIRECT, which must already be subscribed to __redis__:invalidate.
+// Each invalidation message is sent on that channel as an array containing the key that was modified. The BCAST,
+// PREFIX, OPTIN, OPTOUT and NOLOOP options are not supported.
+func (server *Server) clientTracking(cmd redcon.Command, conn redcon.Conn) {
+	if len(cmd.Args) < 3 {
+		conn.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s|%s' command", string(cmd.Args[0]), string(cmd.Args[1])))
+		return
+	}
+	var redirect int64
+	for index := 3; index < len(cmd.Args); index++ {
+		if strings.ToUpper(string(cmd.Args[index])) != "REDIRECT" || index+1 >= len(cmd.Args) {
+			conn.WriteError(toRESPError(ErrSyntax))
+			return
+		}
+		id, err := strconv.ParseInt(string(cmd.Args[index+1]), 10, 64)
+		if err != nil || id <= 0 {
+			conn.WriteError("ERR Invalid client ID")
+			return
+		}
+		redirect = id
+		index++
+	}
+	switch strings.ToUpper(string(cmd.Args[2])) {
+	case "ON":
+		if redirect == 0 {
+			conn.WriteError("ERR CLIENT TRACKING without REDIRECT requires RESP3, which is not supported")
+			return
+		}
+		if !server.pubSub.hasSubscriber(redirect) {
+			conn.WriteError("ERR The client ID you want redirect to does not exist")
+			return
+		}
+		connectionOf(conn).trackingRedirect = redirect
+	case "OFF":
+		// The keys already tracked are left as is, since an extra invalidation message is harmless
+		connectionOf(conn).trackingRedirect = 0
+	default:
+		conn.WriteError(toRESPError(ErrSyntax))
+		return
+	}
+	conn.WriteString("OK")
+}
@@ -0,0 +1,96 @@
+// +build !race
+
+package server
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TwinProduction/gocache"
+	"github.com/go-redis/redis"
+)
+
+func TestCLIENTTRACKINGWithRedirect(t *testing.T) {
+	trackingServer := NewServer(gocache.NewCache()).WithPort(16177)
+	go trackingServer.Start()
+	defer trackingServer.Stop()
+	writerClient := redis.NewClient(&redis.Options{Addr: "localhost:16177"})
+	defer writerClient.Close()
+	for deadline := time.Now().Add(5 * time.Second); writerClient.Ping().Err() != nil; {
+		if time.Now().After(deadline) {
+			t.Fatal("server did not start in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	subscriberConn, err := net.Dial("tcp", "localhost:16177")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer subscriberConn.Close()
+	subscriberReader := bufio.NewReader(subscriberConn)
+	readLines := func(numberOfLines int) []string {
+		t.Helper()
+		_ = subscriberConn.SetReadDeadline(time.Now().Add(time.Second))
+		var lines []string
+		for len(lines) < numberOfLines {
+			line, err := subscriberReader.ReadString('\n')
+			if err != nil {
+				t.Fatal("expected", numberOfLines, "lines, got", lines, "and", err)
+			}
+			lines = append(lines, strings.TrimSpace(line))
+		}
+		return lines
+	}
+	subscriberConn.Write([]byte("CLIENT ID\r\nSUBSCRIBE __redis__:invalidate\r\n"))
+	lines := readLines(7)
+	id := strings.TrimPrefix(lines[0], ":")
+	if _, err := strconv.Atoi(id); err != nil {
+		t.Fatal("expected CLIENT ID to return an integer, got", lines[0])
+	}
+	// The redirect must designate a connection that exists
+	if err := writerClient.Do("CLIENT", "TRACKING", "ON", "REDIRECT", "999999").Err(); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Error("expected an error for a connection that doesn't exist, got", err)
+	}
+	if err := writerClient.Do("CLIENT", "TRACKING", "ON").Err(); err == nil || !strings.Contains(err.Error(), "RESP3") {
+		t.Error("expected an error without REDIRECT, got", err)
+	}
+	trackingConn, err := net.Dial("tcp", "localhost:16177")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer trackingConn.Close()
+	trackingReader := bufio.NewReader(trackingConn)
+	trackingConn.Write([]byte("CLIENT TRACKING ON REDIRECT " + id + "\r\nMGET key other-key\r\n"))
+	for _, expected := range []string{"+OK", "*2", "$-1", "$-1"} {
+		if line, err := trackingReader.ReadString('\n'); err != nil || strings.TrimSpace(line) != expected {
+			t.Fatalf("expected %s, got %s and %v", expected, line, err)
+		}
+	}
+	// A key that wasn't read must not be invalidated
+	writerClient.Set("untracked-key", "value", 0)
+	writerClient.Set("key", "value", 0)
+	if lines := readLines(8); lines[5] != "*1" || lines[7] != "key" {
+		t.Error("expected an invalidation message for key, got", lines)
+	}
+	// Once invalidated, a key is no longer tracked until it is read again
+	writerClient.Set("key", "other-value", 0)
+	writerClient.Set("other-key", "value", 0)
+	if lines := readLines(8); lines[5] != "*1" || lines[7] != "other-key" {
+		t.Error("expected an invalidation message for other-key only, got", lines)
+	}
+	trackingConn.Write([]byte("CLIENT TRACKING OFF\r\nGET key\r\n"))
+	for _, expected := range []string{"+OK", "$11", "other-value"} {
+		if line, err := trackingReader.ReadString('\n'); err != nil || strings.TrimSpace(line) != expected {
+			t.Fatalf("expected %s, got %s and %v", expected, line, err)
+		}
+	}
+	writerClient.Set("key", "value", 0)
+	_ = subscriberConn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if line, err := subscriberReader.ReadString('\n'); err == nil {
+		t.Error("expected no invalidation message once tracking is disabled, got", line)
+	}
+}
@@ -0,0 +1,144 @@
+package gocache
+
+import "time"
+
+// ShardedCache is a cache that partitions its keys across multiple independent Cache instances, called shards, based
+// on the hash of each key
+//
+// Because each shard has its own lock, operations on keys that belong to different shards never contend with each
+// other, which significantly improves throughput under heavy concurrent load, especially when using LRU, since
+// retrieving an entry then requires a write lock.
+//
+// The catch is that the limits of the cache (MaxSize and MaxMemoryUsage) are divided evenly across the shards, and
+// that each shard evicts its own entries independently from the others. In other words, the eviction policy is only
+// respected per shard: the entry evicted when a shard is full is the oldest (or least recently used) entry of that
+// shard, not necessarily of the whole cache.
+type ShardedCache struct {
+	shards []*Cache
+}
+
+// NewShardedCache creates a new ShardedCache with the given number of shards, each of which is configured using the
+// Options passed as parameter, except that MaxSize and MaxMemoryUsage are divided by the number of shards
+//
+// e.g.
+//
+//	options := gocache.DefaultOptions()
+//	options.EvictionPolicy = gocache.LeastRecentlyUsed
+//	cache := gocache.NewShardedCache(16, options)
+//
+// If the number of shards is lower than 1, a single shard is used.
+func NewShardedCache(numberOfShards int, options Options) *ShardedCache {
+	if numberOfShards < 1 {
+		numberOfShards = 1
+	}
+	if options.MaxSize != NoMaxSize {
+		options.MaxSize = divideRoundingUp(options.MaxSize, numberOfShards)
+	}
+	if options.MaxMemoryUsage != NoMaxMemoryUsage {
+		options.MaxMemoryUsage = divideRoundingUp(options.MaxMemoryUsage, numberOfShards)
+	}
+	if options.InitialCapacity > 0 {
+		options.InitialCapacity = divideRoundingUp(options.InitialCapacity, numberOfShards)
+	}
+	shardedCache := &ShardedCache{shards: make([]*Cache, numberOfShards)}
+	for i := range shardedCache.shards {
+		shardedCache.shards[i] = NewCacheWithOptions(options)
+	}
+	return shardedCache
+}
+
+// Shards returns the Cache instances the keys are partitioned across
+//
+// This can be used to configure the shards or to perform operations that ShardedCache doesn't expose, such as
+// starting the janitor of each shard.
+func (shardedCache *ShardedCache) Shards() []*Cache {
+	return shardedCache.shards
+}
+
+// Set creates or updates a key with a given value
+func (shardedCache *ShardedCache) Set(key string, value interface{}) {
+	shardedCache.shard(key).Set(key, value)
+}
+
+// SetWithTTL creates or updates a key with a given value and sets an expiration time (-1 is NoExpiration)
+//
+// See Cache.SetWithTTL for more details.
+func (shardedCache *ShardedCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	shardedCache.shard(key).SetWithTTL(key, value, ttl)
+}
+
+// Get retrieves an entry using the key passed as parameter
+//
+// See Cache.Get for more details.
+func (shardedCache *ShardedCache) Get(key string) (interface{}, bool) {
+	return shardedCache.shard(key).Get(key)
+}
+
+// Delete removes a key from the cache
+//
+// Returns false if the key did not exist.
+func (shardedCache *ShardedCache) Delete(key string) bool {
+	return shardedCache.shard(key).Delete(key)
+}
+
+// Count returns the total amount of entries in the cache, regardless of whether they're expired or not
+//
+// Because each shard is counted separately, the result may not reflect the content of the cache at any single point
+// in time if the cache is being modified concurrently.
+func (shardedCache *ShardedCache) Count() int {
+	count := 0
+	for _, shard := range shardedCache.shards {
+		count += shard.Count()
+	}
+	return count
+}
+
+// Snapshot returns the keys of every entry in the cache, as well as their number, as they were at a single point in
+// time, regardless of whether they're expired or not
+//
+// Unlike Count, which counts each shard separately, every shard is locked for the duration of the snapshot, which
+// means that all operations on the cache are blocked until it is taken. This is much heavier than reading the shards
+// one at a time, and is meant for administrative use only.
+func (shardedCache *ShardedCache) Snapshot() (keys []string, count int) {
+	// Shards are always locked in the same order, so that concurrent snapshots can't deadlock each other
+	for _, shard := range shardedCache.shards {
+		shard.mutex.RLock()
+		count += len(shard.entries)
+	}
+	keys = make([]string, 0, count)
+	for _, shard := range shardedCache.shards {
+		for key := range shard.entries {
+			keys = append(keys, key)
+		}
+	}
+	for i := len(shardedCache.shards) - 1; i >= 0; i-- {
+		shardedCache.shards[i].mutex.RUnlock()
+	}
+	return keys, count
+}
+
+// Clear deletes all entries from the cache
+func (shardedCache *ShardedCache) Clear() {
+	for _, shard := range shardedCache.shards {
+		shard.Clear()
+	}
+}
+
+// shard returns the shard responsible for the key passed as parameter
+func (shardedCache *ShardedCache) shard(key string) *Cache {
+	if len(shardedCache.shards) == 1 {
+		return shardedCache.shards[0]
+	}
+	// FNV-1a is computed inline rather than using hash/fnv, which would allocate for every key
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	return shardedCache.shards[hash%uint32(len(shardedCache.shards))]
+}
+
+// divideRoundingUp divides a by b, rounding up so that the sum of the parts is never lower than a
+func divideRoundingUp(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,115 @@
+package gocache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewShardedCache(t *testing.T) {
+	options := DefaultOptions()
+	options.MaxSize = 1000
+	options.MaxMemoryUsage = Megabyte
+	options.EvictionPolicy = LeastRecentlyUsed
+	cache := NewShardedCache(3, options)
+	if len(cache.Shards()) != 3 {
+		t.Fatal("expected 3 shards, got", len(cache.Shards()))
+	}
+	for _, shard := range cache.Shards() {
+		if shard.MaxSize() != 334 {
+			t.Error("expected MaxSize of each shard to be 334, got", shard.MaxSize())
+		}
+		if shard.MaxMemoryUsage() != 349526 {
+			t.Error("expected MaxMemoryUsage of each shard to be 349526, got", shard.MaxMemoryUsage())
+		}
+		if shard.EvictionPolicy() != LeastRecentlyUsed {
+			t.Error("expected EvictionPolicy of each shard to be LeastRecentlyUsed, got", shard.EvictionPolicy())
+		}
+	}
+	if len(NewShardedCache(0, DefaultOptions()).Shards()) != 1 {
+		t.Error("expected a single shard to be used when the number of shards is lower than 1")
+	}
+	noMaxSizeOptions := DefaultOptions()
+	noMaxSizeOptions.MaxSize = NoMaxSize
+	if NewShardedCache(4, noMaxSizeOptions).Shards()[0].MaxSize() != NoMaxSize {
+		t.Error("expected shards to have no max size")
+	}
+}
+
+func TestShardedCache(t *testing.T) {
+	cache := NewShardedCache(4, DefaultOptions())
+	for i := 0; i < 100; i++ {
+		cache.Set(strconv.Itoa(i), i)
+	}
+	if cache.Count() != 100 {
+		t.Error("expected 100 entries, got", cache.Count())
+	}
+	for _, shard := range cache.Shards() {
+		if shard.Count() == 0 || shard.Count() == 100 {
+			t.Error("expected the keys to have been distributed across the shards, got", shard.Count())
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if value, ok := cache.Get(strconv.Itoa(i)); !ok || value != i {
+			t.Errorf("expected %d, got %v", i, value)
+		}
+	}
+	if !cache.Delete("50") {
+		t.Error("expected key to have been deleted")
+	}
+	if cache.Delete("50") {
+		t.Error("expected false, since the key no longer exists")
+	}
+	if cache.Count() != 99 {
+		t.Error("expected 99 entries, got", cache.Count())
+	}
+	cache.SetWithTTL("ttl", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := cache.Get("ttl"); ok {
+		t.Error("expected key to have expired")
+	}
+	cache.Clear()
+	if cache.Count() != 0 {
+		t.Error("expected cache to have been cleared, got", cache.Count())
+	}
+}
+
+func TestShardedCache_EvictionIsPerShard(t *testing.T) {
+	options := DefaultOptions()
+	options.MaxSize = 20
+	cache := NewShardedCache(4, options)
+	for i := 0; i < 1000; i++ {
+		cache.Set(strconv.Itoa(i), i)
+	}
+	if cache.Count() != 20 {
+		t.Error("expected each shard to have reached its max size, for a total of 20 entries, got", cache.Count())
+	}
+	for _, shard := range cache.Shards() {
+		if shard.Count() != 5 {
+			t.Error("expected each shard to have 5 entries, got", shard.Count())
+		}
+	}
+}
+
+func TestShardedCache_Snapshot(t *testing.T) {
+	cache := NewShardedCache(4, DefaultOptions())
+	for i := 0; i < 100; i++ {
+		cache.Set(strconv.Itoa(i), i)
+	}
+	keys, count := cache.Snapshot()
+	if count != 100 || len(keys) != 100 {
+		t.Fatalf("expected 100 keys, got %d keys and a count of %d", len(keys), count)
+	}
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		seen[key] = true
+	}
+	for i := 0; i < 100; i++ {
+		if !seen[strconv.Itoa(i)] {
+			t.Error("expected the snapshot to contain the key", i)
+		}
+	}
+	if cache.Count() != count {
+		t.Errorf("expected Count and Snapshot to agree when there are no concurrent writers, got %d and %d", cache.Count(), count)
+	}
+}
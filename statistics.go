@@ -12,4 +12,8 @@ type Statistics struct {
 
 	// Misses is the number of cache misses
 	Misses uint64
+
+	// DroppedEvents is the number of events that could not be sent to the channel configured through
+	// WithEventChannel, because it was full
+	DroppedEvents uint64
 }
@@ -0,0 +1,71 @@
+package gocache
+
+import (
+	"reflect"
+	"time"
+)
+
+// TypedCache is a wrapper around a Cache that only stores values of type T, which removes the need for type
+// assertions when retrieving values
+//
+// Values are stored in the underlying Cache as is, which means that the Cache can still be used directly for any
+// operation that TypedCache doesn't expose, as long as only values of type T are stored.
+type TypedCache[T any] struct {
+	cache *Cache
+}
+
+// NewTypedCache creates a TypedCache storing values of type T in the cache passed as parameter
+//
+// e.g.
+//
+//	cache := gocache.NewTypedCache[*User](gocache.NewCache().WithMaxSize(1000))
+//	cache.Set("john", &User{Name: "John"})
+//	user, ok := cache.Get("john")
+func NewTypedCache[T any](cache *Cache) *TypedCache[T] {
+	return &TypedCache[T]{cache: cache}
+}
+
+// Cache returns the underlying Cache
+func (typedCache *TypedCache[T]) Cache() *Cache {
+	return typedCache.cache
+}
+
+// Set creates or updates a key with a given value
+func (typedCache *TypedCache[T]) Set(key string, value T) {
+	typedCache.cache.Set(key, value)
+}
+
+// SetWithTTL creates or updates a key with a given value and sets an expiration time (-1 is NoExpiration)
+//
+// See Cache.SetWithTTL for more details.
+func (typedCache *TypedCache[T]) SetWithTTL(key string, value T, ttl time.Duration) {
+	typedCache.cache.SetWithTTL(key, value, ttl)
+}
+
+// Get retrieves an entry using the key passed as parameter
+//
+// If there is no such entry, or if the value of the entry is not of type T, the value returned will be the zero value
+// of T and the boolean will be false.
+func (typedCache *TypedCache[T]) Get(key string) (T, bool) {
+	var zero T
+	value, ok := typedCache.cache.Get(key)
+	if !ok {
+		return zero, false
+	}
+	if value == nil {
+		// Nil pointers may have been stored as nil, see Cache.WithForceNilInterfaceOnNilPointer
+		return zero, canBeNil(reflect.TypeOf(&zero).Elem())
+	}
+	typedValue, ok := value.(T)
+	return typedValue, ok
+}
+
+// canBeNil returns whether the zero value of the type passed as parameter is nil
+func canBeNil(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Chan, reflect.Func:
+		return true
+	default:
+		return false
+	}
+}
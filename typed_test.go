@@ -0,0 +1,48 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTypedCache(t *testing.T) {
+	cache := NewTypedCache[int](NewCache())
+	cache.Set("key", 42)
+	value, ok := cache.Get("key")
+	if !ok || value != 42 {
+		t.Errorf("expected 42 and the key to exist, got %d and %v", value, ok)
+	}
+	value, ok = cache.Get("key-that-does-not-exist")
+	if ok || value != 0 {
+		t.Errorf("expected 0 and the key to not exist, got %d and %v", value, ok)
+	}
+}
+
+func TestTypedCache_GetWithMismatchedType(t *testing.T) {
+	cache := NewTypedCache[int](NewCache())
+	cache.Cache().Set("string", "value")
+	cache.Cache().Set("nil", nil)
+	for _, key := range []string{"string", "nil"} {
+		if value, ok := cache.Get(key); ok || value != 0 {
+			t.Errorf("[%s] expected 0 and false because the value is not an int, got %d and %v", key, value, ok)
+		}
+	}
+}
+
+func TestTypedCache_GetWithNilPointer(t *testing.T) {
+	type Struct struct{}
+	cache := NewTypedCache[*Struct](NewCache().WithForceNilInterfaceOnNilPointer(true))
+	cache.Set("key", nil)
+	if value, ok := cache.Get("key"); !ok || value != nil {
+		t.Errorf("expected nil and the key to exist, got %v and %v", value, ok)
+	}
+}
+
+func TestTypedCache_SetWithTTL(t *testing.T) {
+	cache := NewTypedCache[string](NewCache())
+	cache.SetWithTTL("key", "value", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if value, ok := cache.Get("key"); ok || value != "" {
+		t.Errorf("expected the key to have expired, got %s and %v", value, ok)
+	}
+}
@@ -0,0 +1,71 @@
+package gocache
+
+import "sync"
+
+const (
+	// DefaultWarmupWorkers is the number of keys loaded concurrently by Warmup, unless configured otherwise through
+	// WithWarmupWorkers
+	DefaultWarmupWorkers = 8
+)
+
+// WithWarmupKeys sets the keys to load through the loader configured with WithLoader when Warmup is called, which
+// allows populating the cache with the keys that are known to be accessed frequently before serving any traffic,
+// rather than having the first retrieval of each key wait for the loader.
+//
+// Note that the server calls Warmup on each of its databases when it starts, before accepting connections.
+func (cache *Cache) WithWarmupKeys(keys []string) *Cache {
+	cache.warmupKeys = keys
+	return cache
+}
+
+// WithWarmupWorkers sets the maximum number of keys loaded concurrently by Warmup, which bounds the load put on the
+// backend the loader retrieves the values from.
+//
+// A number of workers lower than 1 means DefaultWarmupWorkers.
+func (cache *Cache) WithWarmupWorkers(workers int) *Cache {
+	cache.warmupWorkers = workers
+	return cache
+}
+
+// Warmup loads every key configured through WithWarmupKeys using the loader configured through WithLoader, and
+// returns once all of them have been loaded
+//
+// Keys that already exist are left untouched. If the loader fails to load some of the keys, the other keys are still
+// loaded, and the first error encountered is returned. If keys were configured but no loader was, ErrKeyDoesNotExist
+// is returned, like GetWithLoad.
+func (cache *Cache) Warmup() error {
+	workers := cache.warmupWorkers
+	if workers < 1 {
+		workers = DefaultWarmupWorkers
+	}
+	if workers > len(cache.warmupKeys) {
+		workers = len(cache.warmupKeys)
+	}
+	keys := make(chan string)
+	var (
+		waitGroup  sync.WaitGroup
+		errorMutex sync.Mutex
+		firstErr   error
+	)
+	for i := 0; i < workers; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for key := range keys {
+				if _, err := cache.GetWithLoad(key); err != nil {
+					errorMutex.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					errorMutex.Unlock()
+				}
+			}
+		}()
+	}
+	for _, key := range cache.warmupKeys {
+		keys <- key
+	}
+	close(keys)
+	waitGroup.Wait()
+	return firstErr
+}
@@ -0,0 +1,70 @@
+package gocache
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_Warmup(t *testing.T) {
+	var concurrentLoads, maximumConcurrentLoads int32
+	cache := NewCache().WithLoader(func(key string) (interface{}, time.Duration, error) {
+		loads := atomic.AddInt32(&concurrentLoads, 1)
+		defer atomic.AddInt32(&concurrentLoads, -1)
+		for {
+			maximum := atomic.LoadInt32(&maximumConcurrentLoads)
+			if loads <= maximum || atomic.CompareAndSwapInt32(&maximumConcurrentLoads, maximum, loads) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return "value-of-" + key, NoExpiration, nil
+	})
+	var keys []string
+	for i := 0; i < 20; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+	cache.WithWarmupKeys(keys).WithWarmupWorkers(4)
+	if err := cache.Warmup(); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if cache.Count() != 20 {
+		t.Error("expected every key to have been loaded, got", cache.Count(), "entries")
+	}
+	for _, key := range keys {
+		if value, ok := cache.Peek(key); !ok || value != "value-of-"+key {
+			t.Errorf("expected %s to have been loaded, got %v", key, value)
+		}
+	}
+	if maximumConcurrentLoads > 4 {
+		t.Error("expected no more than 4 keys to be loaded concurrently, got", maximumConcurrentLoads)
+	}
+}
+
+func TestCache_WarmupWhenLoaderFails(t *testing.T) {
+	expectedErr := errors.New("failed")
+	cache := NewCache().WithLoader(func(key string) (interface{}, time.Duration, error) {
+		if key == "key2" {
+			return nil, 0, expectedErr
+		}
+		return key, NoExpiration, nil
+	}).WithWarmupKeys([]string{"key1", "key2", "key3"})
+	if err := cache.Warmup(); err != expectedErr {
+		t.Error("expected the error of the loader to have been returned, got", err)
+	}
+	if cache.Count() != 2 {
+		t.Error("expected the other keys to have been loaded anyway, got", cache.Count(), "entries")
+	}
+}
+
+func TestCache_WarmupWithoutLoader(t *testing.T) {
+	cache := NewCache()
+	if err := cache.Warmup(); err != nil {
+		t.Error("expected no error when there are no keys to warm up, got", err)
+	}
+	if err := cache.WithWarmupKeys([]string{"key"}).Warmup(); err != ErrKeyDoesNotExist {
+		t.Error("expected ErrKeyDoesNotExist, got", err)
+	}
+}
@@ -0,0 +1,148 @@
+package gocache
+
+import "strings"
+
+const (
+	// WatchOperationSet is the operation passed to the functions registered through WatchKey when the key is
+	// created or updated
+	WatchOperationSet = "set"
+
+	// WatchOperationDelete is the operation passed to the functions registered through WatchKey when the key is
+	// deleted, including when the cache is cleared
+	WatchOperationDelete = "delete"
+
+	// WatchOperationExpire is the operation passed to the functions registered through WatchKey when the key is
+	// deleted because it has expired
+	WatchOperationExpire = "expire"
+
+	// WatchOperationEvict is the operation passed to the functions registered through WatchKey when the key is
+	// evicted to make room for other entries
+	WatchOperationEvict = "evict"
+)
+
+// invalidationBridgeBufferSize is the number of keys that the channels returned by InvalidationBridge can hold
+// before keys start being dropped
+const invalidationBridgeBufferSize = 1024
+
+// invalidationBridge is a channel returned by InvalidationBridge, along with the prefix of the keys sent to it
+type invalidationBridge struct {
+	prefix string
+	keys   chan string
+}
+
+// Event is an event sent to the channel configured through Cache.WithEventChannel
+type Event struct {
+	// Type is what happened to the key, which is one of WatchOperationSet, WatchOperationDelete,
+	// WatchOperationExpire and WatchOperationEvict
+	Type string
+
+	// Key is the key of the entry
+	Key string
+}
+
+// WatchKey registers a function to call whenever the key passed as parameter is set, deleted, expired or evicted
+//
+// The function is called with the operation (see WatchOperationSet, WatchOperationDelete, WatchOperationExpire and
+// WatchOperationEvict) as well as the new value of the key if it was set, or the value it had if it was removed.
+// Because the function is called while the cache is locked, it must return quickly and must not call the cache.
+//
+// Note that an expired key is only reported as such once it is actually deleted, either by the janitor or by
+// accessing it.
+//
+// Returns a function to call to stop watching the key.
+func (cache *Cache) WatchKey(key string, fn func(op string, value interface{})) (cancel func()) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.watchers == nil {
+		cache.watchers = make(map[string]map[uint64]func(op string, value interface{}))
+	}
+	if cache.watchers[key] == nil {
+		cache.watchers[key] = make(map[uint64]func(op string, value interface{}))
+	}
+	cache.lastWatcherID++
+	id := cache.lastWatcherID
+	cache.watchers[key][id] = fn
+	return func() {
+		cache.mutex.Lock()
+		defer cache.mutex.Unlock()
+		delete(cache.watchers[key], id)
+		if len(cache.watchers[key]) == 0 {
+			delete(cache.watchers, key)
+		}
+	}
+}
+
+// InvalidationBridge returns a channel to which the key of every entry whose key starts with the prefix passed as
+// parameter is sent, stripped of the prefix, whenever the entry is set or deleted, including when the cache is
+// cleared
+//
+// This is meant for layers caching data derived from the entries of the cache, such as HTTP responses, which must be
+// purged whenever the entries they were built from change, e.g.
+//
+//	keys, cancel := cache.InvalidationBridge("user:")
+//	defer cancel()
+//	for key := range keys {
+//		responseCache.Delete("/users/" + key)
+//	}
+//
+// Expirations and evictions are not reported, since the data itself hasn't changed. Like WithEventChannel, keys are
+// sent without blocking, which means that if the buffer of the channel is full, the key is dropped and counted in the
+// DroppedEvents statistic (see Stats).
+//
+// Returns a function to call to stop sending keys, which closes the channel.
+func (cache *Cache) InvalidationBridge(prefix string) (keys <-chan string, cancel func()) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.invalidationBridges == nil {
+		cache.invalidationBridges = make(map[uint64]*invalidationBridge)
+	}
+	cache.lastWatcherID++
+	id := cache.lastWatcherID
+	bridge := &invalidationBridge{prefix: prefix, keys: make(chan string, invalidationBridgeBufferSize)}
+	cache.invalidationBridges[id] = bridge
+	return bridge.keys, func() {
+		cache.mutex.Lock()
+		defer cache.mutex.Unlock()
+		if _, ok := cache.invalidationBridges[id]; ok {
+			delete(cache.invalidationBridges, id)
+			close(bridge.keys)
+		}
+	}
+}
+
+// notifyWatchers calls every function registered through WatchKey for the key passed as parameter, and sends the
+// corresponding Event to the channel configured through WithEventChannel, if any, as well as the key to the channels
+// returned by InvalidationBridge whose prefix it matches
+//
+// Keys that are set are also marked as dirty for WithWriteBehind.
+//
+// The caller is responsible for locking the cache.
+func (cache *Cache) notifyWatchers(key, op string, value interface{}) {
+	if op == WatchOperationSet {
+		cache.markAsDirty(key, value)
+	}
+	if cache.eventChannel != nil {
+		select {
+		case cache.eventChannel <- Event{Type: op, Key: key}:
+		default:
+			cache.stats.DroppedEvents++
+		}
+	}
+	if op == WatchOperationSet || op == WatchOperationDelete {
+		for _, bridge := range cache.invalidationBridges {
+			if strings.HasPrefix(key, bridge.prefix) {
+				select {
+				case bridge.keys <- key[len(bridge.prefix):]:
+				default:
+					cache.stats.DroppedEvents++
+				}
+			}
+		}
+	}
+	if len(cache.watchers) == 0 {
+		return
+	}
+	for _, fn := range cache.watchers[key] {
+		fn(op, value)
+	}
+}
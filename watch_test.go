@@ -0,0 +1,190 @@
+package gocache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type watchEvent struct {
+	op    string
+	value interface{}
+}
+
+func TestCache_WatchKey(t *testing.T) {
+	cache := NewCache()
+	var events []watchEvent
+	cancel := cache.WatchKey("watched", func(op string, value interface{}) {
+		events = append(events, watchEvent{op: op, value: value})
+	})
+	cache.Set("watched", "v1")
+	cache.Set("other", "v1")
+	cache.Set("watched", "v2")
+	cache.Delete("other")
+	cache.Delete("watched")
+	cache.SetWithTTL("watched", "v3", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	cache.Get("watched")
+	expectedEvents := []watchEvent{
+		{op: WatchOperationSet, value: "v1"},
+		{op: WatchOperationSet, value: "v2"},
+		{op: WatchOperationDelete, value: "v2"},
+		{op: WatchOperationSet, value: "v3"},
+		{op: WatchOperationExpire, value: "v3"},
+	}
+	if len(events) != len(expectedEvents) {
+		t.Fatalf("expected %d events, got %d: %v", len(expectedEvents), len(events), events)
+	}
+	for i, expectedEvent := range expectedEvents {
+		if events[i] != expectedEvent {
+			t.Errorf("expected event #%d to be %v, got %v", i, expectedEvent, events[i])
+		}
+	}
+	cancel()
+	cache.Set("watched", "v4")
+	if len(events) != len(expectedEvents) {
+		t.Error("expected no more events after cancelling, got", events[len(expectedEvents):])
+	}
+	if len(cache.watchers) != 0 {
+		t.Error("expected watchers to have been cleaned up, got", cache.watchers)
+	}
+}
+
+func TestCache_WatchKeyWithEviction(t *testing.T) {
+	cache := NewCache().WithMaxSize(1)
+	var events []watchEvent
+	cache.WatchKey("1", func(op string, value interface{}) {
+		events = append(events, watchEvent{op: op, value: value})
+	})
+	cache.Set("1", 1)
+	cache.Set("2", 2)
+	if len(events) != 2 || events[1] != (watchEvent{op: WatchOperationEvict, value: 1}) {
+		t.Errorf("expected key to have been set and then evicted, got %v", events)
+	}
+}
+
+func TestCache_WatchKeyWithMultipleWatchers(t *testing.T) {
+	cache := NewCache()
+	numberOfCallsForFirstWatcher, numberOfCallsForSecondWatcher := 0, 0
+	cancelFirstWatcher := cache.WatchKey("key", func(op string, value interface{}) {
+		numberOfCallsForFirstWatcher++
+	})
+	cache.WatchKey("key", func(op string, value interface{}) {
+		numberOfCallsForSecondWatcher++
+	})
+	cache.Set("key", "value")
+	cancelFirstWatcher()
+	cancelFirstWatcher()
+	cache.Set("key", "value")
+	if numberOfCallsForFirstWatcher != 1 {
+		t.Errorf("expected first watcher to have been called once, got %d", numberOfCallsForFirstWatcher)
+	}
+	if numberOfCallsForSecondWatcher != 2 {
+		t.Errorf("expected second watcher to have been called twice, got %d", numberOfCallsForSecondWatcher)
+	}
+}
+
+func TestCache_WithEventChannel(t *testing.T) {
+	events := make(chan Event, 10)
+	cache := NewCache().WithMaxSize(2).WithEventChannel(events)
+	cache.Set("1", 1)
+	cache.Set("2", 2)
+	cache.Set("3", 3)
+	cache.Delete("2")
+	cache.SetWithTTL("4", 4, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	cache.Get("4")
+	expectedEvents := []Event{
+		{Type: WatchOperationSet, Key: "1"},
+		{Type: WatchOperationSet, Key: "2"},
+		{Type: WatchOperationSet, Key: "3"},
+		{Type: WatchOperationEvict, Key: "1"},
+		{Type: WatchOperationDelete, Key: "2"},
+		{Type: WatchOperationSet, Key: "4"},
+		{Type: WatchOperationExpire, Key: "4"},
+	}
+	if len(events) != len(expectedEvents) {
+		t.Fatalf("expected %d events, got %d", len(expectedEvents), len(events))
+	}
+	for i, expectedEvent := range expectedEvents {
+		if event := <-events; event != expectedEvent {
+			t.Errorf("expected event #%d to be %v, got %v", i, expectedEvent, event)
+		}
+	}
+	if cache.Stats().DroppedEvents != 0 {
+		t.Error("expected no event to have been dropped, got", cache.Stats().DroppedEvents)
+	}
+}
+
+func TestCache_WithEventChannelAndClear(t *testing.T) {
+	events := make(chan Event, 10)
+	cache := NewCache().WithEventChannel(events)
+	cache.Set("1", 1)
+	cache.Set("2", 2)
+	cache.Set("3", 3)
+	for len(events) > 0 {
+		<-events
+	}
+	cache.Clear()
+	expectedEvents := []Event{
+		{Type: WatchOperationDelete, Key: "1"},
+		{Type: WatchOperationDelete, Key: "2"},
+		{Type: WatchOperationDelete, Key: "3"},
+	}
+	if len(events) != len(expectedEvents) {
+		t.Fatalf("expected an event for each of the %d entries cleared, got %d", len(expectedEvents), len(events))
+	}
+	for i, expectedEvent := range expectedEvents {
+		if event := <-events; event != expectedEvent {
+			t.Errorf("expected event #%d to be %v, got %v", i, expectedEvent, event)
+		}
+	}
+}
+
+func TestCache_WithEventChannelWhenChannelIsFull(t *testing.T) {
+	events := make(chan Event, 1)
+	cache := NewCache().WithEventChannel(events)
+	cache.Set("1", 1)
+	cache.Set("2", 2)
+	cache.Set("3", 3)
+	if event := <-events; event.Key != "1" {
+		t.Error("expected the first event to have been sent, got", event)
+	}
+	if cache.Stats().DroppedEvents != 2 {
+		t.Error("expected 2 events to have been dropped, got", cache.Stats().DroppedEvents)
+	}
+	cache.WithEventChannel(nil)
+	cache.Set("4", 4)
+	if len(events) != 0 || cache.Stats().DroppedEvents != 2 {
+		t.Error("expected no more events to have been sent")
+	}
+}
+
+func TestCache_InvalidationBridge(t *testing.T) {
+	cache := NewCache()
+	keys, cancel := cache.InvalidationBridge("user:")
+	cache.Set("user:1", "john")
+	cache.Set("post:1", "hello")
+	cache.Set("user:2", "jane")
+	cache.Delete("user:1")
+	cache.Delete("post:1")
+	cache.SetWithTTL("user:3", "jack", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	// Expirations must not be reported
+	cache.Get("user:3")
+	cache.Clear()
+	var received []string
+	for len(keys) > 0 {
+		received = append(received, <-keys)
+	}
+	if expected := []string{"1", "2", "1", "3", "2"}; !reflect.DeepEqual(received, expected) {
+		t.Errorf("expected %v, got %v", expected, received)
+	}
+	cancel()
+	if _, open := <-keys; open {
+		t.Error("expected the channel to have been closed")
+	}
+	cache.Set("user:4", "joe")
+	// Calling cancel more than once must be harmless
+	cancel()
+}
@@ -0,0 +1,120 @@
+package gocache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithWriteBehind configures the cache to write the entries that are set to a backing store in the background, in
+// batches, using the function passed as parameter.
+//
+// Every time an entry is set, its key is marked as dirty, and every interval, the latest value of every dirty key is
+// passed to the flush function in a single batch. Flush can be called to write the dirty entries right away, which
+// you should do before the application terminates (see StopWriteBehind). If the interval is 0 or lower, entries are
+// only written when Flush is called.
+//
+// If the flush function returns an error, the batch is kept and retried on the next flush, unless the keys were set
+// again in the meantime, in which case their newer value is written instead.
+//
+// Note that write-behind trades durability for speed: entries that have been set but not flushed yet are lost if the
+// application crashes, and the backing store may lag behind the cache by up to the interval, or longer if flushes fail.
+// Only sets are written; deleting, expiring or evicting an entry does not remove it from the backing store, although
+// an entry evicted before being flushed is still written.
+//
+// The flush function is called without the cache's lock held, so it may freely use the cache, but it is never called
+// concurrently with itself.
+func (cache *Cache) WithWriteBehind(interval time.Duration, flush func(batch map[string]interface{}) error) *Cache {
+	cache.stopWriteBehindFlusher()
+	cache.mutex.Lock()
+	cache.writeBehindFlush = flush
+	if cache.dirtyEntries == nil {
+		cache.dirtyEntries = make(map[string]interface{})
+	}
+	cache.mutex.Unlock()
+	if interval > 0 {
+		cache.stopWriteBehind = make(chan bool)
+		atomic.AddInt32(&cache.backgroundWorkers, 1)
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					// A failed batch is kept as dirty, so it will be retried on the next tick
+					_ = cache.Flush()
+				case <-cache.stopWriteBehind:
+					atomic.AddInt32(&cache.backgroundWorkers, -1)
+					cache.stopWriteBehind <- true
+					return
+				}
+			}
+		}()
+	}
+	return cache
+}
+
+// Flush writes every dirty entry to the backing store configured through WithWriteBehind right away
+//
+// Returns the error returned by the flush function, in which case the entries remain dirty and will be retried.
+// If write-behind hasn't been configured or if there are no dirty entries, nothing is done.
+func (cache *Cache) Flush() error {
+	cache.flushMutex.Lock()
+	defer cache.flushMutex.Unlock()
+	cache.mutex.Lock()
+	if cache.writeBehindFlush == nil || len(cache.dirtyEntries) == 0 {
+		cache.mutex.Unlock()
+		return nil
+	}
+	flush := cache.writeBehindFlush
+	batch := cache.dirtyEntries
+	cache.dirtyEntries = make(map[string]interface{})
+	cache.mutex.Unlock()
+	if err := flush(batch); err != nil {
+		cache.mutex.Lock()
+		for key, value := range batch {
+			// Keys that were set again while flushing already have a newer value to write
+			if _, isDirty := cache.dirtyEntries[key]; !isDirty {
+				cache.dirtyEntries[key] = value
+			}
+		}
+		cache.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// StopWriteBehind stops writing entries to the backing store configured through WithWriteBehind, after flushing the
+// entries that are still dirty
+//
+// Returns the error returned by the flush function, in which case write-behind is still stopped, but the entries that
+// could not be written remain dirty and can be written by calling Flush.
+func (cache *Cache) StopWriteBehind() error {
+	cache.stopWriteBehindFlusher()
+	err := cache.Flush()
+	if err == nil {
+		cache.mutex.Lock()
+		cache.writeBehindFlush = nil
+		cache.dirtyEntries = nil
+		cache.mutex.Unlock()
+	}
+	return err
+}
+
+// stopWriteBehindFlusher stops the goroutine that periodically flushes the dirty entries, if it is running
+func (cache *Cache) stopWriteBehindFlusher() {
+	if cache.stopWriteBehind != nil {
+		// Like StopJanitor, wait for the goroutine to reply before forgetting about the channel
+		cache.stopWriteBehind <- true
+		<-cache.stopWriteBehind
+		cache.stopWriteBehind = nil
+	}
+}
+
+// markAsDirty marks a key as needing to be written to the backing store configured through WithWriteBehind, if any
+//
+// The caller is responsible for locking the cache.
+func (cache *Cache) markAsDirty(key string, value interface{}) {
+	if cache.dirtyEntries != nil {
+		cache.dirtyEntries[key] = value
+	}
+}
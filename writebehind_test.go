@@ -0,0 +1,116 @@
+package gocache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache_WithWriteBehind(t *testing.T) {
+	var mutex sync.Mutex
+	store := make(map[string]interface{})
+	cache := NewCache().WithWriteBehind(10*time.Millisecond, func(batch map[string]interface{}) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for key, value := range batch {
+			store[key] = value
+		}
+		return nil
+	})
+	defer cache.StopWriteBehind()
+	if cache.BackgroundWorkers() != 1 {
+		t.Error("expected the flusher to be running in the background, got", cache.BackgroundWorkers(), "background workers")
+	}
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Set("key2", "new-value2")
+	time.Sleep(50 * time.Millisecond)
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(store) != 2 || store["key1"] != "value1" || store["key2"] != "new-value2" {
+		t.Error("expected the latest value of each key to have been written to the store, got", store)
+	}
+}
+
+func TestCache_WithWriteBehindWhenFlushFails(t *testing.T) {
+	var batches []map[string]interface{}
+	expectedErr := errors.New("failed")
+	fail := true
+	cache := NewCache().WithWriteBehind(0, func(batch map[string]interface{}) error {
+		if fail {
+			return expectedErr
+		}
+		batches = append(batches, batch)
+		return nil
+	})
+	if cache.BackgroundWorkers() != 0 {
+		t.Error("expected no flusher to be running in the background when the interval is 0")
+	}
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	if err := cache.Flush(); err != expectedErr {
+		t.Error("expected the error of the flush function to have been returned, got", err)
+	}
+	// key2 is set again after the failed flush, so its newer value must be written
+	cache.Set("key2", "new-value2")
+	fail = false
+	if err := cache.Flush(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if len(batches) != 1 || len(batches[0]) != 2 || batches[0]["key1"] != "value1" || batches[0]["key2"] != "new-value2" {
+		t.Error("expected the entries of the failed flush to have been retried, got", batches)
+	}
+	if err := cache.Flush(); err != nil || len(batches) != 1 {
+		t.Error("expected nothing to be written, since there are no dirty entries")
+	}
+}
+
+func TestCache_WithWriteBehindWritesEvictedEntries(t *testing.T) {
+	var written map[string]interface{}
+	cache := NewCache().WithMaxSize(1).WithWriteBehind(0, func(batch map[string]interface{}) error {
+		written = batch
+		return nil
+	})
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	if cache.Count() != 1 {
+		t.Fatal("expected key1 to have been evicted")
+	}
+	if err := cache.Flush(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if len(written) != 2 {
+		t.Error("expected both keys to have been written, even though key1 was evicted before being flushed, got", written)
+	}
+}
+
+func TestCache_StopWriteBehind(t *testing.T) {
+	numberOfFlushes := 0
+	cache := NewCache().WithWriteBehind(time.Hour, func(batch map[string]interface{}) error {
+		numberOfFlushes++
+		return nil
+	})
+	cache.Set("key", "value")
+	if err := cache.StopWriteBehind(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+	if numberOfFlushes != 1 {
+		t.Error("expected the dirty entries to have been flushed when stopping, got", numberOfFlushes, "flushes")
+	}
+	if cache.BackgroundWorkers() != 0 {
+		t.Error("expected the flusher to have been stopped")
+	}
+	cache.Set("key", "new-value")
+	if err := cache.Flush(); err != nil || numberOfFlushes != 1 {
+		t.Error("expected nothing to be written once write-behind has been stopped")
+	}
+}
+
+func TestCache_FlushWithoutWriteBehind(t *testing.T) {
+	cache := NewCache()
+	cache.Set("key", "value")
+	if err := cache.Flush(); err != nil {
+		t.Error("expected no error, got", err)
+	}
+}